@@ -18,12 +18,18 @@ export const options = {
   },
 };
 
+// Paths resolve against the directory of this script, like open(). Opening
+// the file in the init context also bundles it into the archives created by
+// k6 archive and k6 cloud.
+const filename = './data/sample.parquet';
+parquet.getMetadata(filename);
+
 // Use SharedArray to share data across VUs efficiently
 const users = new SharedArray('users', function() {
   console.log('Loading user data from Parquet...');
 
   // Read only specific columns to reduce memory usage
-  const data = parquet.read('./examples/data/sample.parquet', {
+  const data = parquet.read(filename, {
     columns: ['id', 'username', 'email', 'created_at'],
     rowLimit: 10000, // Limit to first 10,000 records
   });
@@ -42,7 +48,7 @@ const premiumUsers = new SharedArray('premium-users', function() {
   const allUsers = [];
 
   // Use chunked reading for large files
-  parquet.readChunked('./examples/data/sample.parquet', 5000, (chunk) => {
+  parquet.readChunked(filename, 5000, (chunk) => {
     // Filter premium users only
     const premium = chunk.filter(user => user.subscription === 'premium');
     allUsers.push(...premium);
@@ -57,7 +63,7 @@ const premiumUsers = new SharedArray('premium-users', function() {
 
 export function setup() {
   // Get file metadata
-  const metadata = parquet.getMetadata('./examples/data/sample.parquet');
+  const metadata = parquet.getMetadata(filename);
 
   console.log('\n=== Dataset Information ===');
   console.log(`Total records: ${metadata.numRows}`);
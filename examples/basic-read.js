@@ -11,12 +11,18 @@ export const options = {
   duration: '30s',
 };
 
+// Paths resolve against the directory of this script, like open(). Opening
+// the file in the init context also bundles it into the archives created by
+// k6 archive and k6 cloud.
+const filename = './data/sample.parquet';
+parquet.getMetadata(filename);
+
 // Setup function - runs once before the test
 export function setup() {
   console.log('Loading test data from Parquet file...');
 
   // Read the entire Parquet file
-  const data = parquet.read('./examples/data/sample.parquet');
+  const data = parquet.read(filename);
 
   console.log(`Loaded ${data.length} records from Parquet file`);
 
@@ -11,6 +11,12 @@ export const options = {
   duration: '1m',
 };
 
+// Paths resolve against the directory of this script, like open(). Opening
+// the file in the init context also bundles it into the archives created by
+// k6 archive and k6 cloud.
+const filename = './data/sample.parquet';
+parquet.getMetadata(filename);
+
 export function setup() {
   console.log('Loading large Parquet file in chunks...');
 
@@ -21,7 +27,7 @@ export function setup() {
   // Read file in chunks of 1000 rows
   const chunkSize = 1000;
 
-  parquet.readChunked('./examples/data/sample.parquet', chunkSize, (chunk) => {
+  parquet.readChunked(filename, chunkSize, (chunk) => {
     chunkCount++;
     totalRows += chunk.length;
 
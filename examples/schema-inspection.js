@@ -9,9 +9,13 @@ export const options = {
   iterations: 1,
 };
 
-export default function() {
-  const filename = './examples/data/sample.parquet';
+// Paths resolve against the directory of this script, like open(). Opening
+// the file in the init context also bundles it into the archives created by
+// k6 archive and k6 cloud.
+const filename = './data/sample.parquet';
+parquet.getMetadata(filename);
 
+export default function() {
   console.log('=== Parquet File Inspection ===\n');
 
   // Get and display schema
@@ -21,10 +25,10 @@ export default function() {
 
   // Validate schema structure
   const schemaChecks = check(schema, {
-    'schema is not empty': (s) => Object.keys(s).length > 0,
+    'schema is not empty': (s) => s.fields.length > 0,
     'has expected fields': (s) => {
       // Add your expected fields here
-      return s.id !== undefined;
+      return s.fields.some((f) => f.name === 'id');
     },
   });
 
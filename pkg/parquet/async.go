@@ -0,0 +1,170 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/sobek"
+)
+
+// errReadStopped stops an async chunked read whose promise was rejected by
+// the chunk callback, or whose VU stopped.
+var errReadStopped = errors.New("read stopped")
+
+// recovered calls fn and returns a panic it raises as an error, so that a
+// failure off the JS thread rejects a promise instead of crashing k6.
+func recovered(fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("unexpected error: %v", r)
+		}
+	}()
+	return fn()
+}
+
+// asyncCall runs work off the JS thread and returns a promise settled on the
+// event loop with the JS value of its result. The options of the call must
+// be parsed beforehand, on the JS thread.
+func (p *Parquet) asyncCall(work func() (func() interface{}, error)) *sobek.Promise {
+	promise, resolve, reject := p.vu.Runtime().NewPromise()
+	enqueue := p.vu.RegisterCallback()
+
+	go func() {
+		var result func() interface{}
+		err := recovered(func() (err error) {
+			result, err = work()
+			return err
+		})
+		enqueue(func() error {
+			if err != nil {
+				return reject(err)
+			}
+			return resolve(result())
+		})
+	}()
+	return promise
+}
+
+// rejected returns a promise rejected with an error.
+func (p *Parquet) rejected(err error) *sobek.Promise {
+	promise, _, reject := p.vu.Runtime().NewPromise()
+	_ = reject(err)
+	return promise
+}
+
+// readAsync is the JS binding of Read returning a promise. The rows are read
+// and decoded off the JS thread.
+func (p *Parquet) readAsync(filename string, options ...map[string]interface{}) *sobek.Promise {
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return p.rejected(err)
+	}
+	filename = p.files.resolve(filename)
+
+	return p.asyncCall(func() (func() interface{}, error) {
+		rows, order, err := p.read(filename, opts)
+		if err != nil {
+			return nil, err
+		}
+		return func() interface{} { return p.export(rows, order, &opts.ConvertOptions) }, nil
+	})
+}
+
+// getMetadataAsync is the JS binding of GetMetadata returning a promise.
+func (p *Parquet) getMetadataAsync(filename string, options ...map[string]interface{}) *sobek.Promise {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return p.rejected(err)
+	}
+	filename = p.files.resolve(filename)
+
+	return p.asyncCall(func() (func() interface{}, error) {
+		metadata, err := p.metadata(filename, &opts.ConvertOptions)
+		if err != nil {
+			return nil, err
+		}
+		return func() interface{} { return p.export(metadata, nil, &opts.ConvertOptions) }, nil
+	})
+}
+
+// readChunkedAsync is the JS binding of ReadChunked returning a promise,
+// resolved once all chunks have been passed to the callback. Chunks are read
+// off the JS thread, and the callback is called on the event loop, one chunk
+// at a time: the next chunk is only read once the callback has returned. The
+// promise is rejected if the callback throws.
+func (p *Parquet) readChunkedAsync(
+	filename string, chunkSize int, callback func(interface{}) error, options ...map[string]interface{},
+) *sobek.Promise {
+	if err := checkChunkSize(chunkSize); err != nil {
+		return p.rejected(err)
+	}
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return p.rejected(err)
+	}
+	filename = p.files.resolve(filename)
+
+	ctx := p.vu.Context()
+	promise, resolve, reject := p.vu.Runtime().NewPromise()
+	enqueue := p.vu.RegisterCallback()
+
+	// Each chunk is handed to the event loop with its own registered
+	// callback, which the previous chunk registers from the event loop. When
+	// the VU stops while a chunk is pending, the chunk is abandoned so that
+	// no callback is left registered.
+	var mu sync.Mutex
+	abandoned := false
+	handoff := func(chunk []map[string]interface{}, order *fieldOrder) error {
+		done := make(chan error, 1)
+		enqueue(func() error {
+			mu.Lock()
+			defer mu.Unlock()
+			if abandoned {
+				return nil
+			}
+			if err := callback(p.export(chunk, order, &opts.ConvertOptions)); err != nil {
+				done <- errReadStopped
+				var exception *sobek.Exception
+				if errors.As(err, &exception) {
+					return reject(exception.Value())
+				}
+				return reject(err)
+			}
+			enqueue = p.vu.RegisterCallback()
+			done <- nil
+			return nil
+		})
+
+		select {
+		case err := <-done:
+			return err
+		case <-ctx.Done():
+			mu.Lock()
+			defer mu.Unlock()
+			select {
+			case err := <-done:
+				return err
+			default:
+				abandoned = true
+				return errReadStopped
+			}
+		}
+	}
+
+	go func() {
+		err := recovered(func() error {
+			return p.readChunked(filename, chunkSize, opts, handoff)
+		})
+		if errors.Is(err, errReadStopped) {
+			return
+		}
+		enqueue(func() error {
+			if err != nil {
+				return reject(err)
+			}
+			return resolve(sobek.Undefined())
+		})
+	}()
+	return promise
+}
@@ -0,0 +1,123 @@
+package parquet
+
+import (
+	"testing"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/modulestest"
+)
+
+// runAsync runs a script on the event loop of a VU with the module
+// exported as parquet and the path of a test file as filename, and returns
+// the value of its result variable once all promises have settled.
+func runAsync(t *testing.T, filename, script string) string {
+	t.Helper()
+
+	runtime := modulestest.NewRuntime(t)
+	p := New().NewModuleInstance(runtime.VU)
+	rt := runtime.VU.Runtime()
+	if err := rt.Set("parquet", p.Exports().Named); err != nil {
+		t.Fatalf("failed to set exports: %v", err)
+	}
+	if err := rt.Set("filename", filename); err != nil {
+		t.Fatalf("failed to set filename: %v", err)
+	}
+
+	err := runtime.EventLoop.Start(func() error {
+		_, err := rt.RunString("var result;\n" + script)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	return rt.Get("result").String()
+}
+
+func TestReadAsync(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	t.Run("Rows and metadata", func(t *testing.T) {
+		result := runAsync(t, filename, `
+			Promise.all([
+				parquet.readAsync(filename, { filter: 'id >= 2990' }),
+				parquet.getMetadataAsync(filename),
+			]).then(([rows, metadata]) => {
+				result = [rows.length, rows[0].id, metadata.numRowGroups].join(',');
+			});
+		`)
+		if result != "10,2990,5" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("Chunks are passed in order", func(t *testing.T) {
+		result := runAsync(t, filename, `
+			const sizes = [];
+			let last = -1, ordered = true;
+			parquet.readChunkedAsync(filename, 1000, (chunk) => {
+				sizes.push(chunk.length);
+				ordered = ordered && chunk[0].id === last + 1;
+				last = chunk[chunk.length - 1].id;
+			}, { rowLimit: 2500 }).then(() => {
+				result = sizes.join(',') + ':' + ordered;
+			});
+		`)
+		if result != "1000,1000,500:true" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("Errors reject promises", func(t *testing.T) {
+		result := runAsync(t, filename, `
+			const errors = [];
+			const caught = (e) => { errors.push(String(e)); };
+			Promise.all([
+				parquet.readAsync('missing.parquet').catch(caught),
+				parquet.readAsync(filename, { filter: 'nope > 1' }).catch(caught),
+				parquet.getMetadataAsync('missing.parquet').catch(caught),
+				parquet.getMetadataAsync(filename, { binary: 'nope' }).catch(caught),
+				parquet.readChunkedAsync(filename, 100, (chunk) => {
+					throw new Error('stop at ' + chunk[0].id);
+				}).catch(caught),
+				parquet.readChunkedAsync(filename, -1, () => {}).catch(caught),
+				parquet.readChunkedAsync(filename, 0, () => {}).catch(caught),
+			]).then(() => {
+				result = errors.length + ':' + errors.some((e) => e.includes('stop at 0')) + ':' +
+					errors.some((e) => e.includes('invalid binary option')) + ':' +
+					errors.filter((e) => e.includes('invalid chunkSize')).length;
+			});
+		`)
+		if result != "7:true:true:2" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("Panics reject promises", func(t *testing.T) {
+		runtime := modulestest.NewRuntime(t)
+		p, ok := New().NewModuleInstance(runtime.VU).(*Parquet)
+		if !ok {
+			t.Fatal("unexpected module instance type")
+		}
+		rt := runtime.VU.Runtime()
+		err := rt.Set("panicking", func() *sobek.Promise {
+			return p.asyncCall(func() (func() interface{}, error) { panic("boom") })
+		})
+		if err != nil {
+			t.Fatalf("failed to set function: %v", err)
+		}
+
+		err = runtime.EventLoop.Start(func() error {
+			_, err := rt.RunString(`
+				var result;
+				panicking().catch((e) => { result = String(e); });
+			`)
+			return err
+		})
+		if err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		if result := rt.Get("result").String(); result != "unexpected error: boom" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+}
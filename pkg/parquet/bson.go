@@ -0,0 +1,147 @@
+package parquet
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+var errInvalidBSON = errors.New("invalid BSON document")
+
+// decodeBSON decodes a BSON document into a map. Only the element types that
+// have a natural JS representation are supported; documents containing other
+// types are rejected so that callers can fall back to the raw bytes.
+func decodeBSON(doc []byte) (map[string]interface{}, error) {
+	value, rest, err := decodeBSONDocument(doc)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) != 0 {
+		return nil, errInvalidBSON
+	}
+	return value, nil
+}
+
+// decodeBSONDocument decodes the document at the start of b and returns the
+// remaining bytes.
+func decodeBSONDocument(b []byte) (map[string]interface{}, []byte, error) {
+	if len(b) < 5 {
+		return nil, nil, errInvalidBSON
+	}
+	size := int(binary.LittleEndian.Uint32(b))
+	if size < 5 || size > len(b) || b[size-1] != 0 {
+		return nil, nil, errInvalidBSON
+	}
+
+	result := make(map[string]interface{})
+	elements := b[4 : size-1]
+	for len(elements) > 0 {
+		elementType := elements[0]
+		name, rest, err := decodeBSONCString(elements[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		value, rest, err := decodeBSONElement(elementType, rest)
+		if err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", name, err)
+		}
+		result[name] = value
+		elements = rest
+	}
+
+	return result, b[size:], nil
+}
+
+// decodeBSONElement decodes a single element value of the given type.
+func decodeBSONElement(elementType byte, b []byte) (interface{}, []byte, error) {
+	switch elementType {
+	case 0x01: // double
+		if len(b) < 8 {
+			return nil, nil, errInvalidBSON
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(b)), b[8:], nil
+
+	case 0x02: // string
+		if len(b) < 4 {
+			return nil, nil, errInvalidBSON
+		}
+		size := int(binary.LittleEndian.Uint32(b))
+		if size < 1 || size+4 > len(b) {
+			return nil, nil, errInvalidBSON
+		}
+		return string(b[4 : 4+size-1]), b[4+size:], nil
+
+	case 0x03: // embedded document
+		return decodeBSONDocument(b)
+
+	case 0x04: // array, encoded as a document keyed by index
+		doc, rest, err := decodeBSONDocument(b)
+		if err != nil {
+			return nil, nil, err
+		}
+		array := make([]interface{}, len(doc))
+		for i := range array {
+			array[i] = doc[fmt.Sprint(i)]
+		}
+		return array, rest, nil
+
+	case 0x05: // binary
+		if len(b) < 5 {
+			return nil, nil, errInvalidBSON
+		}
+		size := int(binary.LittleEndian.Uint32(b))
+		if size < 0 || size+5 > len(b) {
+			return nil, nil, errInvalidBSON
+		}
+		return b[5 : 5+size], b[5+size:], nil
+
+	case 0x07: // ObjectId
+		if len(b) < 12 {
+			return nil, nil, errInvalidBSON
+		}
+		return hex.EncodeToString(b[:12]), b[12:], nil
+
+	case 0x08: // boolean
+		if len(b) < 1 {
+			return nil, nil, errInvalidBSON
+		}
+		return b[0] != 0, b[1:], nil
+
+	case 0x09: // UTC datetime, milliseconds since the Unix epoch
+		if len(b) < 8 {
+			return nil, nil, errInvalidBSON
+		}
+		return time.UnixMilli(int64(binary.LittleEndian.Uint64(b))).UTC(), b[8:], nil
+
+	case 0x0A: // null
+		return nil, b, nil
+
+	case 0x10: // int32
+		if len(b) < 4 {
+			return nil, nil, errInvalidBSON
+		}
+		return int32(binary.LittleEndian.Uint32(b)), b[4:], nil
+
+	case 0x11, 0x12: // timestamp, int64
+		if len(b) < 8 {
+			return nil, nil, errInvalidBSON
+		}
+		return int64(binary.LittleEndian.Uint64(b)), b[8:], nil
+
+	default:
+		return nil, nil, fmt.Errorf("unsupported BSON element type 0x%02x", elementType)
+	}
+}
+
+// decodeBSONCString decodes a NUL terminated string.
+func decodeBSONCString(b []byte) (string, []byte, error) {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i]), b[i+1:], nil
+		}
+	}
+	return "", nil, errInvalidBSON
+}
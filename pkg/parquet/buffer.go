@@ -0,0 +1,233 @@
+package parquet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+)
+
+// openParquetBuffer opens a Parquet file held in memory by a JS value: an
+// ArrayBuffer, such as the body of a binary HTTP response or the result of
+// open(path, 'b'), the bytes viewed by a typed array or DataView, or a file
+// of k6/experimental/fs. The returned function must be called once done with
+// the Parquet file.
+func openParquetBuffer(buffer interface{}) (*parquet.File, func(), error) {
+	r, size, release, err := bufferReader(buffer)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pf, err := parquet.OpenFile(r, size)
+	if err != nil {
+		release()
+		return nil, nil, fmt.Errorf("failed to open parquet buffer: %w", err)
+	}
+	return pf, release, nil
+}
+
+// bufferReader returns a reader over the content of a JS buffer, its size,
+// and a function releasing the reader.
+func bufferReader(buffer interface{}) (io.ReaderAt, int64, func(), error) {
+	var data []byte
+	switch b := buffer.(type) {
+	case sobek.ArrayBuffer:
+		data = b.Bytes()
+	case *sobek.ArrayBuffer:
+		data = b.Bytes()
+	case []byte:
+		data = b
+	case nil:
+		return nil, 0, nil, errors.New("invalid buffer: expected an ArrayBuffer, got null")
+	default:
+		if rs, ok := fileReadSeeker(buffer); ok {
+			return newSeekingReaderAt(rs)
+		}
+		return nil, 0, nil, fmt.Errorf("invalid buffer: expected an ArrayBuffer, a typed array or a file, got %T", buffer)
+	}
+	return bytes.NewReader(data), int64(len(data)), func() {}, nil
+}
+
+// jsBuffer returns the Go value of a JS buffer argument. Typed arrays and
+// DataViews are resolved to the bytes they view of their ArrayBuffer, as
+// only byte arrays export to bytes.
+func jsBuffer(v sobek.Value) (interface{}, error) {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return exportValue(v), nil
+	}
+	ab, ok := exportValue(obj.Get("buffer")).(sobek.ArrayBuffer)
+	if !ok {
+		return obj.Export(), nil
+	}
+
+	data := ab.Bytes()
+	offset := obj.Get("byteOffset").ToInteger()
+	length := obj.Get("byteLength").ToInteger()
+	if offset < 0 || length < 0 || offset+length > int64(len(data)) {
+		return nil, fmt.Errorf("invalid buffer: view of %d bytes at offset %d exceeds its ArrayBuffer of %d bytes",
+			length, offset, len(data))
+	}
+	return data[offset : offset+length], nil
+}
+
+// exportValue exports a JS value, nil for undefined values.
+func exportValue(v sobek.Value) interface{} {
+	if v == nil {
+		return nil
+	}
+	return v.Export()
+}
+
+// fileReadSeeker returns the reader of a file of k6/experimental/fs, which
+// exposes it to other modules through its ReadSeekStater field.
+func fileReadSeeker(v interface{}) (io.ReadSeeker, bool) {
+	value := reflect.ValueOf(v)
+	if value.Kind() != reflect.Pointer || value.Elem().Kind() != reflect.Struct {
+		return nil, false
+	}
+	field := value.Elem().FieldByName("ReadSeekStater")
+	if !field.IsValid() || !field.CanInterface() {
+		return nil, false
+	}
+	rs, ok := field.Interface().(io.ReadSeeker)
+	return rs, ok && rs != nil
+}
+
+// seekingReaderAt reads a file at arbitrary offsets by seeking it. The offset
+// of the file is restored on release, so that reading it from Parquet does
+// not move the position seen by the script.
+type seekingReaderAt struct {
+	mu sync.Mutex
+	rs io.ReadSeeker
+}
+
+// newSeekingReaderAt returns a reader at arbitrary offsets of a file, its
+// size, and a function restoring the offset of the file.
+func newSeekingReaderAt(rs io.ReadSeeker) (io.ReaderAt, int64, func(), error) {
+	offset, err := rs.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to seek file: %w", err)
+	}
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, 0, nil, fmt.Errorf("failed to seek file: %w", err)
+	}
+
+	r := &seekingReaderAt{rs: rs}
+	release := func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		_, _ = rs.Seek(offset, io.SeekStart)
+	}
+	return r, size, release, nil
+}
+
+func (r *seekingReaderAt) ReadAt(b []byte, off int64) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.rs.Seek(off, io.SeekStart); err != nil {
+		return 0, err
+	}
+	return io.ReadFull(r.rs, b)
+}
+
+// ReadBuffer reads the rows of a Parquet file held in memory, with the same
+// options as Read. Results are not cached.
+func (p *Parquet) ReadBuffer(buffer interface{}, options ...map[string]interface{}) ([]map[string]interface{}, error) {
+	rows, _, err := p.readBuffer(buffer, options...)
+	return rows, err
+}
+
+// readBuffer reads the rows of a Parquet file held in memory, and returns
+// them with their field order.
+func (p *Parquet) readBuffer(
+	buffer interface{}, options ...map[string]interface{},
+) ([]map[string]interface{}, *fieldOrder, error) {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.resolvePartition(opts.Partition); err != nil {
+		return nil, nil, err
+	}
+
+	pf, release, err := openParquetBuffer(buffer)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer release()
+
+	rows, err := readRows(&fileSet{pfs: []*parquet.File{pf}, schema: pf.Schema()}, &opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rows, schemaOrder(pf.Schema()), nil
+}
+
+// readBufferJS is the JS binding of ReadBuffer.
+func (p *Parquet) readBufferJS(buffer sobek.Value, options ...map[string]interface{}) (interface{}, error) {
+	b, err := jsBuffer(buffer)
+	if err != nil {
+		return nil, err
+	}
+	rows, order, err := p.readBuffer(b, options...)
+	if err != nil {
+		return nil, err
+	}
+	return p.export(rows, order, exportOptions(options...)), nil
+}
+
+// GetBufferSchema returns the schema of a Parquet file held in memory.
+func (p *Parquet) GetBufferSchema(buffer interface{}) (map[string]interface{}, error) {
+	pf, release, err := openParquetBuffer(buffer)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return ConvertSchema(pf.Schema()), nil
+}
+
+// getBufferSchemaJS is the JS binding of GetBufferSchema.
+func (p *Parquet) getBufferSchemaJS(buffer sobek.Value) (map[string]interface{}, error) {
+	b, err := jsBuffer(buffer)
+	if err != nil {
+		return nil, err
+	}
+	return p.GetBufferSchema(b)
+}
+
+// GetBufferMetadata returns the metadata of a Parquet file held in memory.
+func (p *Parquet) GetBufferMetadata(buffer interface{}, options ...map[string]interface{}) (map[string]interface{}, error) {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	pf, release, err := openParquetBuffer(buffer)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return metadataOf(pf, &opts.ConvertOptions), nil
+}
+
+// getBufferMetadataJS is the JS binding of GetBufferMetadata.
+func (p *Parquet) getBufferMetadataJS(buffer sobek.Value, options ...map[string]interface{}) (interface{}, error) {
+	b, err := jsBuffer(buffer)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := p.GetBufferMetadata(b, options...)
+	if err != nil {
+		return nil, err
+	}
+	return p.export(metadata, nil, exportOptions(options...)), nil
+}
@@ -0,0 +1,136 @@
+package parquet
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+func TestReadBuffer(t *testing.T) {
+	data, err := os.ReadFile(createRowGroupsParquetFile(t, 3000, 700))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Read options apply", func(t *testing.T) {
+		rows, err := p.ReadBuffer(data, map[string]interface{}{
+			"columns": []interface{}{"id"},
+			"filter":  "id >= 2990",
+		})
+		if err != nil {
+			t.Fatalf("ReadBuffer() error = %v", err)
+		}
+		if len(rows) != 10 || rows[0]["id"] != int64(2990) || len(rows[0]) != 1 {
+			t.Errorf("expected 10 projected rows from 2990, got %d: %v", len(rows), rows[0])
+		}
+	})
+
+	t.Run("Schema and metadata", func(t *testing.T) {
+		schema, err := p.GetBufferSchema(data)
+		if err != nil {
+			t.Fatalf("GetBufferSchema() error = %v", err)
+		}
+		if len(schema["fields"].([]interface{})) != 2 || schemaField(schema, "id") == nil {
+			t.Errorf("expected the id and name fields, got %v", schema)
+		}
+
+		metadata, err := p.GetBufferMetadata(data)
+		if err != nil {
+			t.Fatalf("GetBufferMetadata() error = %v", err)
+		}
+		if metadata["numRows"] != int64(3000) || metadata["numRowGroups"] != 5 || metadata["size"] != int64(len(data)) {
+			t.Errorf("unexpected metadata: %v", metadata)
+		}
+	})
+
+	t.Run("File handles keep their offset", func(t *testing.T) {
+		type file struct {
+			ReadSeekStater io.ReadSeeker
+		}
+		r := bytes.NewReader(data)
+		if _, err := r.Seek(42, io.SeekStart); err != nil {
+			t.Fatalf("Seek() error = %v", err)
+		}
+
+		rows, err := p.ReadBuffer(&file{ReadSeekStater: r}, map[string]interface{}{"skipRows": 2999})
+		if err != nil {
+			t.Fatalf("ReadBuffer() error = %v", err)
+		}
+		if len(rows) != 1 || rows[0]["id"] != int64(2999) {
+			t.Errorf("expected the last row, got %v", rows)
+		}
+		if offset, _ := r.Seek(0, io.SeekCurrent); offset != 42 {
+			t.Errorf("expected the offset to be restored to 42, got %d", offset)
+		}
+	})
+
+	t.Run("Invalid buffers", func(t *testing.T) {
+		for _, buffer := range []interface{}{nil, "data.parquet", []byte("not parquet"), &struct{ Name string }{}} {
+			if _, err := p.ReadBuffer(buffer); err == nil {
+				t.Errorf("%v: expected an error", buffer)
+			}
+		}
+	})
+}
+
+func TestReadBufferJS(t *testing.T) {
+	data, err := os.ReadFile(createRowGroupsParquetFile(t, 3000, 700))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	runtime := modulestest.NewRuntime(t)
+	p := New().NewModuleInstance(runtime.VU)
+	rt := runtime.VU.Runtime()
+	if err := rt.Set("parquet", p.Exports().Named); err != nil {
+		t.Fatalf("failed to set exports: %v", err)
+	}
+	if err := rt.Set("buffer", rt.NewArrayBuffer(data)); err != nil {
+		t.Fatalf("failed to set buffer: %v", err)
+	}
+
+	// An Int32Array can only view a file whose size is a multiple of 4.
+	var aligned []byte
+	for n := 10; len(aligned) == 0 || len(aligned)%4 != 0; n++ {
+		if aligned, err = os.ReadFile(createRowGroupsParquetFile(t, n, n)); err != nil {
+			t.Fatalf("failed to read test file: %v", err)
+		}
+	}
+	if err := rt.Set("aligned", rt.NewArrayBuffer(aligned)); err != nil {
+		t.Fatalf("failed to set buffer: %v", err)
+	}
+
+	v, err := rt.RunString(`
+		const rows = parquet.readBuffer(buffer, { rowLimit: 5 });
+		const bytes = parquet.readBuffer(new Uint8Array(buffer), { skipRows: 2999 });
+
+		// Views read the bytes they cover, wherever they are in their buffer.
+		const padded = new ArrayBuffer(buffer.byteLength + 8);
+		new Uint8Array(padded).set(new Uint8Array(buffer), 4);
+		const paddedAligned = new ArrayBuffer(aligned.byteLength + 8);
+		new Uint8Array(paddedAligned).set(new Uint8Array(aligned), 4);
+		const ints = new Int32Array(paddedAligned, 4, aligned.byteLength / 4);
+		const view = new DataView(padded, 4, buffer.byteLength);
+		[
+			rows.length,
+			rows[4].id,
+			bytes[0].id,
+			parquet.getBufferSchema(buffer).fields.length,
+			parquet.getBufferMetadata(buffer).numRows,
+			parquet.readBuffer(ints, { skipRows: 9 })[0].id,
+			parquet.readBuffer(view, { rowLimit: 1 })[0].id,
+			parquet.getBufferSchema(view).fields.length,
+			parquet.getBufferMetadata(new Uint8Array(padded, 4, buffer.byteLength)).numRows,
+		].join(',');
+	`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	if v.String() != "5,4,2999,2,3000,9,0,2,3000" {
+		t.Errorf("unexpected result: %s", v.String())
+	}
+}
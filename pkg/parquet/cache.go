@@ -1,36 +1,81 @@
 package parquet
 
 import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"github.com/parquet-go/parquet-go"
 )
 
-// ReaderCache manages cached Parquet file data.
+// defaultMaxMemory is the default memory budget of the cache (1 GiB).
+const defaultMaxMemory = 1 << 30
+
+// ReaderCache manages cached Parquet file data. A single cache is shared by
+// all VUs, so cached data must be treated as read-only.
 type ReaderCache struct {
-	cache map[string]*CacheEntry
-	mu    sync.RWMutex
-	ttl   time.Duration
+	cache     map[string]*CacheEntry
+	mu        sync.RWMutex
+	ttl       time.Duration
+	maxMemory int64
+	used      int64
+
+	// loading holds the loads in progress, by key.
+	loading map[string]*cacheLoad
+}
+
+// cacheLoad is a load of data in progress, whose result is shared by the
+// concurrent readers of its key.
+type cacheLoad struct {
+	done  chan struct{}
+	entry *CacheEntry
+	err   error
 }
 
 // CacheEntry represents a single cache entry with data and timestamp.
 type CacheEntry struct {
-	data      []map[string]interface{}
-	timestamp time.Time
+	data       []map[string]interface{}
+	schema     *parquet.Schema // schema the data was decoded with, nil if unknown
+	order      *fieldOrder     // field order of the schema, nil if unknown
+	timestamp  time.Time
+	lastAccess time.Time
+	size       int64
+	refs       int
 }
 
-// NewReaderCache creates a new cache instance with default TTL.
+// NewReaderCache creates a new cache instance with default TTL and memory budget.
 func NewReaderCache() *ReaderCache {
 	return &ReaderCache{
-		cache: make(map[string]*CacheEntry),
-		ttl:   5 * time.Minute, // Default 5 minutes TTL
+		cache:     make(map[string]*CacheEntry),
+		ttl:       5 * time.Minute, // Default 5 minutes TTL
+		maxMemory: defaultMaxMemory,
 	}
 }
 
 // Get retrieves data from cache if it exists and hasn't expired.
 func (rc *ReaderCache) Get(key string) ([]map[string]interface{}, bool) {
-	rc.mu.RLock()
-	defer rc.mu.RUnlock()
+	entry, ok := rc.get(key)
+	if !ok {
+		return nil, false
+	}
+	return entry.data, true
+}
 
+// get returns the entry of a key if it exists and hasn't expired. The data,
+// schema and order of entries are never modified once set.
+func (rc *ReaderCache) get(key string) (*CacheEntry, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	return rc.lookup(key)
+}
+
+// lookup returns the entry of a key if it exists and hasn't expired. Callers
+// must hold the lock.
+func (rc *ReaderCache) lookup(key string) (*CacheEntry, bool) {
 	entry, ok := rc.cache[key]
 	if !ok {
 		return nil, false
@@ -41,17 +86,161 @@ func (rc *ReaderCache) Get(key string) ([]map[string]interface{}, bool) {
 		return nil, false
 	}
 
-	return entry.data, true
+	entry.lastAccess = time.Now()
+	return entry, true
+}
+
+// load returns the entry cached under key, calling decode to load its data
+// when it is missing. Concurrent loads of a key are deduplicated: the first
+// caller decodes and caches the data while the others wait for its result,
+// so that VUs initialized in parallel share a single decoded copy. The entry
+// is returned even when it does not fit in the memory budget.
+func (rc *ReaderCache) load(
+	key string, decode func() ([]map[string]interface{}, *parquet.Schema, error),
+) (*CacheEntry, error) {
+	rc.mu.Lock()
+	if entry, ok := rc.lookup(key); ok {
+		rc.mu.Unlock()
+		return entry, nil
+	}
+	if l, ok := rc.loading[key]; ok {
+		rc.mu.Unlock()
+		<-l.done
+		return l.entry, l.err
+	}
+	l := &cacheLoad{done: make(chan struct{})}
+	if rc.loading == nil {
+		rc.loading = make(map[string]*cacheLoad)
+	}
+	rc.loading[key] = l
+	rc.mu.Unlock()
+
+	defer func() {
+		rc.mu.Lock()
+		delete(rc.loading, key)
+		rc.mu.Unlock()
+		close(l.done)
+	}()
+
+	data, schema, err := decode()
+	if err != nil {
+		l.err = err
+		return nil, err
+	}
+	l.entry = newCacheEntry(data, schema)
+	rc.store(key, l.entry)
+	return l.entry, nil
 }
 
-// Set stores data in the cache with current timestamp.
+// Set stores data in the cache with current timestamp. Entries are evicted,
+// least recently used first, to keep the cache within its memory budget; data
+// larger than the whole budget is not cached.
 func (rc *ReaderCache) Set(key string, data []map[string]interface{}) {
+	rc.store(key, newCacheEntry(data, nil))
+}
+
+// newCacheEntry creates the entry of data decoded with the given schema, nil
+// if unknown. The field order of the schema is kept with the data, and
+// evicted with it.
+func newCacheEntry(data []map[string]interface{}, schema *parquet.Schema) *CacheEntry {
+	now := time.Now()
+	return &CacheEntry{
+		data:       data,
+		schema:     schema,
+		order:      schemaOrder(schema),
+		timestamp:  now,
+		lastAccess: now,
+		size:       estimateSize(data),
+	}
+}
+
+// store stores an entry in the cache, keeping the references of the entry
+// it replaces.
+func (rc *ReaderCache) store(key string, entry *CacheEntry) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	rc.cache[key] = &CacheEntry{
-		data:      data,
-		timestamp: time.Now(),
+	if old, ok := rc.cache[key]; ok {
+		entry.refs = old.refs
+		rc.remove(key)
+	}
+
+	if !rc.reserve(entry.size) {
+		return
+	}
+
+	rc.cache[key] = entry
+	rc.used += entry.size
+}
+
+// reserve evicts entries until size bytes fit in the memory budget, and
+// reports whether they do. Expired and unreferenced entries are evicted
+// first, then referenced ones: VUs keep the rows they were returned, so
+// evicting an entry only stops sharing it with later reads. Callers must hold
+// the lock.
+func (rc *ReaderCache) reserve(size int64) bool {
+	if rc.maxMemory <= 0 || rc.used+size <= rc.maxMemory {
+		return true
+	}
+	if size > rc.maxMemory {
+		return false
+	}
+
+	candidates := make([]string, 0, len(rc.cache))
+	for key := range rc.cache {
+		candidates = append(candidates, key)
+	}
+	inUse := func(entry *CacheEntry) bool {
+		return entry.refs > 0 && time.Since(entry.timestamp) <= rc.ttl
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := rc.cache[candidates[i]], rc.cache[candidates[j]]
+		if inUse(a) != inUse(b) {
+			return !inUse(a)
+		}
+		return a.lastAccess.Before(b.lastAccess)
+	})
+
+	for _, key := range candidates {
+		if rc.used+size <= rc.maxMemory {
+			break
+		}
+		rc.remove(key)
+	}
+
+	return true
+}
+
+// remove deletes an entry and releases its memory. Callers must hold the lock.
+func (rc *ReaderCache) remove(key string) {
+	if entry, ok := rc.cache[key]; ok {
+		rc.used -= entry.size
+		delete(rc.cache, key)
+	}
+}
+
+// Acquire marks an entry as in use until it is released: referenced entries
+// are evicted after the unused ones, and kept by ClearUnused. It reports
+// whether the entry exists.
+func (rc *ReaderCache) Acquire(key string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	entry, ok := rc.cache[key]
+	if !ok {
+		return false
+	}
+	entry.refs++
+	return true
+}
+
+// Release drops a reference previously taken with Acquire.
+func (rc *ReaderCache) Release(key string) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if entry, ok := rc.cache[key]; ok && entry.refs > 0 {
+		entry.refs--
 	}
 }
 
@@ -61,6 +250,19 @@ func (rc *ReaderCache) Clear() {
 	defer rc.mu.Unlock()
 
 	rc.cache = make(map[string]*CacheEntry)
+	rc.used = 0
+}
+
+// ClearUnused removes the entries that are not referenced by any VU.
+func (rc *ReaderCache) ClearUnused() {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for key, entry := range rc.cache {
+		if entry.refs == 0 {
+			rc.remove(key)
+		}
+	}
 }
 
 // Remove deletes a specific entry from the cache.
@@ -68,7 +270,7 @@ func (rc *ReaderCache) Remove(key string) {
 	rc.mu.Lock()
 	defer rc.mu.Unlock()
 
-	delete(rc.cache, key)
+	rc.remove(key)
 }
 
 // SetTTL sets the time-to-live duration for cache entries.
@@ -78,3 +280,85 @@ func (rc *ReaderCache) SetTTL(ttl time.Duration) {
 
 	rc.ttl = ttl
 }
+
+// SetMaxMemory sets the memory budget of the cache in bytes. Zero or a
+// negative value disables the limit.
+func (rc *ReaderCache) SetMaxMemory(maxMemory int64) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	rc.maxMemory = maxMemory
+}
+
+// MemoryUsage returns the estimated size in bytes of the cached data.
+func (rc *ReaderCache) MemoryUsage() int64 {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	return rc.used
+}
+
+// estimateSize approximates the memory held by decoded rows.
+func estimateSize(data []map[string]interface{}) int64 {
+	size := int64(24 + 8*len(data))
+	for _, row := range data {
+		size += estimateValueSize(row)
+	}
+	return size
+}
+
+// estimateValueSize approximates the memory held by a decoded value,
+// including map and slice headers.
+func estimateValueSize(v interface{}) int64 {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		size := int64(48)
+		for key, field := range value {
+			size += 32 + int64(len(key)) + estimateValueSize(field)
+		}
+		return size
+	case []interface{}:
+		size := int64(24)
+		for _, element := range value {
+			size += 16 + estimateValueSize(element)
+		}
+		return size
+	case string:
+		return 16 + int64(len(value))
+	case []byte:
+		return 24 + int64(len(value))
+	case time.Time:
+		return 24
+	default:
+		return 8
+	}
+}
+
+// parseByteSize parses a memory size such as "512MB", "2GiB" or "1048576".
+func parseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(strings.ToUpper(s))
+	units := []struct {
+		suffix string
+		scale  int64
+	}{
+		{"GIB", 1 << 30}, {"MIB", 1 << 20}, {"KIB", 1 << 10},
+		{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+		{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10},
+		{"B", 1},
+	}
+
+	scale := int64(1)
+	for _, unit := range units {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix))
+			scale = unit.scale
+			break
+		}
+	}
+
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid memory size %q: %w", s, err)
+	}
+	return n * scale, nil
+}
@@ -1,8 +1,13 @@
 package parquet
 
 import (
+	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/parquet-go/parquet-go"
 )
 
 func TestNewReaderCache(t *testing.T) {
@@ -49,6 +54,72 @@ func TestCacheSetAndGet(t *testing.T) {
 	}
 }
 
+func TestCacheLoad(t *testing.T) {
+	t.Run("Concurrent loads of a key decode once", func(t *testing.T) {
+		cache := NewReaderCache()
+		testData := []map[string]interface{}{{"id": 1}}
+
+		var decodes atomic.Int32
+		release := make(chan struct{})
+		decode := func() ([]map[string]interface{}, *parquet.Schema, error) {
+			decodes.Add(1)
+			<-release
+			return testData, nil, nil
+		}
+
+		const readers = 8
+		results := make([][]map[string]interface{}, readers)
+		var wg sync.WaitGroup
+		for i := 0; i < readers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				entry, err := cache.load("key", decode)
+				if err != nil {
+					t.Errorf("load() error = %v", err)
+					return
+				}
+				results[i] = entry.data
+			}(i)
+		}
+		// Let every reader reach the load in progress before it completes.
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+		wg.Wait()
+
+		if n := decodes.Load(); n != 1 {
+			t.Errorf("expected 1 decode, got %d", n)
+		}
+		for i, data := range results {
+			if len(data) != 1 || &data[0] != &testData[0] {
+				t.Errorf("reader %d did not get the shared data: %v", i, data)
+			}
+		}
+		if _, ok := cache.Get("key"); !ok {
+			t.Error("expected the loaded data to be cached")
+		}
+	})
+
+	t.Run("Failed loads are not cached", func(t *testing.T) {
+		cache := NewReaderCache()
+		failure := errors.New("boom")
+
+		_, err := cache.load("key", func() ([]map[string]interface{}, *parquet.Schema, error) {
+			return nil, nil, failure
+		})
+		if !errors.Is(err, failure) {
+			t.Fatalf("expected the decode error, got %v", err)
+		}
+
+		entry, err := cache.load("key", func() ([]map[string]interface{}, *parquet.Schema, error) {
+			return []map[string]interface{}{{"id": 1}}, nil, nil
+		})
+		if err != nil || len(entry.data) != 1 {
+			t.Errorf("expected the key to be loaded again, got %v", err)
+		}
+	})
+}
+
 func TestCacheTTL(t *testing.T) {
 	cache := NewReaderCache()
 	cache.SetTTL(50 * time.Millisecond)
@@ -130,3 +201,90 @@ func TestCacheRemove(t *testing.T) {
 		t.Error("expected key2 to still exist")
 	}
 }
+
+func TestCacheMemoryBudget(t *testing.T) {
+	cache := NewReaderCache()
+
+	testData := []map[string]interface{}{
+		{"id": 1, "name": "test"},
+	}
+	entrySize := estimateSize(testData)
+	cache.SetMaxMemory(2 * entrySize)
+
+	cache.Set("key1", testData)
+	cache.Set("key2", testData)
+	if cache.MemoryUsage() != 2*entrySize {
+		t.Errorf("expected memory usage of %d, got %d", 2*entrySize, cache.MemoryUsage())
+	}
+
+	// key2 is referenced and key1 is the least recently used entry, so adding
+	// a third entry evicts key1.
+	cache.Acquire("key2")
+	time.Sleep(time.Millisecond)
+	cache.Get("key2")
+	cache.Set("key3", testData)
+
+	if _, found := cache.Get("key1"); found {
+		t.Error("expected key1 to be evicted")
+	}
+	if _, found := cache.Get("key2"); !found {
+		t.Error("expected referenced key2 to be kept")
+	}
+	if _, found := cache.Get("key3"); !found {
+		t.Error("expected key3 to be cached")
+	}
+
+	// Both remaining entries are referenced: the least recently used one is
+	// evicted all the same, so that references never fill the cache.
+	cache.Acquire("key3")
+	time.Sleep(time.Millisecond)
+	cache.Get("key2")
+	cache.Set("key4", testData)
+	if _, found := cache.Get("key3"); found {
+		t.Error("expected referenced key3 to be evicted")
+	}
+	if _, found := cache.Get("key4"); !found {
+		t.Error("expected key4 to be cached")
+	}
+	if cache.MemoryUsage() != 2*entrySize {
+		t.Errorf("expected memory usage of %d, got %d", 2*entrySize, cache.MemoryUsage())
+	}
+
+	// Releasing references allows clearing unused entries.
+	cache.Acquire("key4")
+	cache.Release("key2")
+	cache.ClearUnused()
+	if _, found := cache.Get("key2"); found {
+		t.Error("expected released key2 to be cleared")
+	}
+	if _, found := cache.Get("key4"); !found {
+		t.Error("expected referenced key4 to be kept")
+	}
+}
+
+func TestParseByteSize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+	}{
+		{"1024", 1024},
+		{"512MB", 512 << 20},
+		{"2GiB", 2 << 30},
+		{"64k", 64 << 10},
+	}
+
+	for _, tt := range tests {
+		size, err := parseByteSize(tt.input)
+		if err != nil {
+			t.Errorf("parseByteSize(%q) error = %v", tt.input, err)
+			continue
+		}
+		if size != tt.expected {
+			t.Errorf("parseByteSize(%q) = %d, want %d", tt.input, size, tt.expected)
+		}
+	}
+
+	if _, err := parseByteSize("lots"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
@@ -0,0 +1,392 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/encoding"
+)
+
+// columnBuilder accumulates the values of a column read page by page.
+type columnBuilder interface {
+	// appendPage appends the values of a page of the column.
+	appendPage(page parquet.Page) error
+
+	// appendValue appends n copies of a value, nil for null values.
+	appendValue(value interface{}, n int64)
+
+	// values returns the values read: a slice of numbers for numeric
+	// columns, or of converted values for the others.
+	values() interface{}
+}
+
+// number is the Go type of the values of a numeric column.
+type number interface {
+	int32 | uint32 | int64 | uint64 | float32 | float64
+}
+
+// numericColumn accumulates the physical values of a numeric column, which
+// JS receives as a typed array. Nulls are 0, or NaN for floating point
+// columns.
+type numericColumn[T number] struct {
+	data []T
+	null T
+	// dense returns the values of a page without nulls from its buffer.
+	dense func(*encoding.Values) []T
+	// value converts a value of a page with nulls or a dictionary.
+	value func(parquet.Value) T
+}
+
+func (c *numericColumn[T]) appendPage(page parquet.Page) error {
+	if page.Dictionary() == nil && page.NumNulls() == 0 {
+		data := page.Data()
+		c.data = append(c.data, c.dense(&data)...)
+		return nil
+	}
+
+	values, err := readPageValues(page)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v.IsNull() {
+			c.data = append(c.data, c.null)
+		} else {
+			c.data = append(c.data, c.value(v))
+		}
+	}
+	return nil
+}
+
+func (c *numericColumn[T]) appendValue(value interface{}, n int64) {
+	v := c.null
+	if i, ok := value.(int64); ok {
+		v = T(i)
+	}
+	for ; n > 0; n-- {
+		c.data = append(c.data, v)
+	}
+}
+
+func (c *numericColumn[T]) values() interface{} {
+	if c.data == nil {
+		return []T{}
+	}
+	return c.data
+}
+
+// valueColumn accumulates the converted values of a column that has no
+// typed array equivalent, such as strings, booleans or decimals.
+type valueColumn struct {
+	data    []interface{}
+	convert valueConverter
+	opts    *ConvertOptions
+}
+
+func (c *valueColumn) appendPage(page parquet.Page) error {
+	values, err := readPageValues(page)
+	if err != nil {
+		return err
+	}
+	for _, v := range values {
+		if v.IsNull() {
+			c.data = append(c.data, nil)
+		} else {
+			c.data = append(c.data, c.convert(v, c.opts))
+		}
+	}
+	return nil
+}
+
+func (c *valueColumn) appendValue(value interface{}, n int64) {
+	for ; n > 0; n-- {
+		c.data = append(c.data, value)
+	}
+}
+
+func (c *valueColumn) values() interface{} {
+	if c.data == nil {
+		return []interface{}{}
+	}
+	return c.data
+}
+
+// readPageValues reads all the values of a page, including nulls.
+func readPageValues(page parquet.Page) ([]parquet.Value, error) {
+	values := make([]parquet.Value, page.NumValues())
+	r := page.Values()
+	for read := 0; read < len(values); {
+		n, err := r.ReadValues(values[read:])
+		read += n
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return values[:read], nil
+			}
+			return nil, fmt.Errorf("failed to read page values: %w", err)
+		}
+		if n == 0 {
+			return values[:read], nil
+		}
+	}
+	return values, nil
+}
+
+// newColumnBuilder returns the builder of a leaf column. INT32, INT64, FLOAT
+// and DOUBLE columns hold their physical values, including TIMESTAMP, TIME
+// and DATE columns, except for decimals, which are converted like the other
+// columns.
+func newColumnBuilder(node parquet.Node, opts *ConvertOptions) columnBuilder {
+	typ := node.Type()
+	lt := typ.LogicalType()
+	if lt != nil && (lt.Decimal != nil || lt.Unknown != nil) {
+		return &valueColumn{convert: newValueConverter(node), opts: opts}
+	}
+	unsigned := lt != nil && lt.Integer != nil && !lt.Integer.IsSigned
+
+	switch typ.Kind() {
+	case parquet.Int32:
+		if unsigned {
+			return &numericColumn[uint32]{
+				dense: (*encoding.Values).Uint32,
+				value: func(v parquet.Value) uint32 { return v.Uint32() },
+			}
+		}
+		return &numericColumn[int32]{
+			dense: (*encoding.Values).Int32,
+			value: func(v parquet.Value) int32 { return v.Int32() },
+		}
+	case parquet.Int64:
+		if unsigned {
+			return &numericColumn[uint64]{
+				dense: (*encoding.Values).Uint64,
+				value: func(v parquet.Value) uint64 { return v.Uint64() },
+			}
+		}
+		return &numericColumn[int64]{
+			dense: (*encoding.Values).Int64,
+			value: func(v parquet.Value) int64 { return v.Int64() },
+		}
+	case parquet.Float:
+		return &numericColumn[float32]{
+			null:  float32(math.NaN()),
+			dense: (*encoding.Values).Float,
+			value: func(v parquet.Value) float32 { return v.Float() },
+		}
+	case parquet.Double:
+		return &numericColumn[float64]{
+			null:  math.NaN(),
+			dense: (*encoding.Values).Double,
+			value: func(v parquet.Value) float64 { return v.Double() },
+		}
+	default:
+		return &valueColumn{convert: newValueConverter(node), opts: opts}
+	}
+}
+
+// ReadColumns reads whole columns of a Parquet file, a glob pattern or a
+// directory, page by page, without building rows. Numeric columns are read
+// into slices of their physical type and the others into slices of
+// converted values. Only columns outside of lists and maps can be read; the
+// row window applies, but filters and partitions do not.
+func (p *Parquet) ReadColumns(
+	filename string, columns []string, options ...map[string]interface{},
+) (map[string]interface{}, error) {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Filter != nil {
+		return nil, errors.New("filter is not supported by readColumns(), use read() to select rows")
+	}
+	if opts.Partition != nil {
+		return nil, errors.New("partition is not supported by readColumns(), use skipRows and rowLimit")
+	}
+	if len(columns) == 0 {
+		return nil, errors.New("readColumns() expects at least one column")
+	}
+
+	set, err := p.files.openSet(p.files.resolve(filename), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer set.Close()
+
+	builders := make([]columnBuilder, len(columns))
+	for i, column := range columns {
+		leaf, ok := set.schema.Lookup(strings.Split(column, ".")...)
+		if !ok || leaf.Node == nil {
+			return nil, fmt.Errorf("invalid column %q: not a primitive column of the schema", column)
+		}
+		if leaf.MaxRepetitionLevel > 0 {
+			return nil, fmt.Errorf("invalid column %q: columns of lists and maps cannot be read by readColumns()", column)
+		}
+		builders[i] = newColumnBuilder(leaf.Node, &opts.ConvertOptions)
+	}
+
+	// The row window applies to the rows of the whole set.
+	end := int64(math.MaxInt64)
+	if opts.RowLimit > 0 {
+		end = int64(opts.SkipRows) + int64(opts.RowLimit)
+	}
+	var start int64
+	for i, pf := range set.pfs {
+		lo, hi := max(int64(opts.SkipRows)-start, 0), min(pf.NumRows(), end-start)
+		start += pf.NumRows()
+		if lo >= hi {
+			continue
+		}
+
+		for c, column := range columns {
+			if set.partitions != nil {
+				if value, ok := set.partitions.values[i][column]; ok {
+					builders[c].appendValue(value, hi-lo)
+					continue
+				}
+			}
+			leaf, ok := pf.Schema().Lookup(strings.Split(column, ".")...)
+			if !ok {
+				// Columns missing from the file are null.
+				builders[c].appendValue(nil, hi-lo)
+				continue
+			}
+			if err := readColumnChunks(pf, leaf.ColumnIndex, lo, hi, builders[c]); err != nil {
+				return nil, fmt.Errorf("failed to read column %q: %w", column, err)
+			}
+		}
+	}
+
+	result := make(map[string]interface{}, len(columns))
+	for i, column := range columns {
+		result[column] = builders[i].values()
+	}
+	return result, nil
+}
+
+// readColumnChunks appends the values of rows [lo, hi) of a column of a file
+// to a builder. Row groups outside of the rows are skipped, and pages are
+// sliced to the rows.
+func readColumnChunks(pf *parquet.File, column int, lo, hi int64, builder columnBuilder) error {
+	var start int64
+	for _, rg := range pf.RowGroups() {
+		rgStart := start
+		start += rg.NumRows()
+		if start <= lo || rgStart >= hi {
+			continue
+		}
+
+		pages := rg.ColumnChunks()[column].Pages()
+		pos := rgStart
+		for pos < min(start, hi) {
+			page, err := pages.ReadPage()
+			if err != nil {
+				pages.Close()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				return err
+			}
+			n := page.NumRows()
+			from, to := max(lo-pos, 0), min(hi-pos, n)
+			pos += n
+			if from >= to {
+				continue
+			}
+			if from > 0 || to < n {
+				page = page.Slice(from, to)
+			}
+			if err := builder.appendPage(page); err != nil {
+				pages.Close()
+				return err
+			}
+		}
+		pages.Close()
+	}
+	return nil
+}
+
+// readColumnsJS is the JS binding of ReadColumns, returning numeric columns
+// as typed arrays sharing the memory of the values read. INT64 columns follow
+// the int64Mode option, which defaults to numbers as in the other read
+// functions.
+func (p *Parquet) readColumnsJS(filename string, columns []string, options ...map[string]interface{}) (interface{}, error) {
+	result, err := p.ReadColumns(filename, columns, options...)
+	if err != nil {
+		return nil, err
+	}
+	e := p.exporter(nil, exportOptions(options...))
+	rt := e.rt
+	obj := rt.NewObject()
+	for column, values := range result {
+		var value sobek.Value
+		switch v := values.(type) {
+		case []int32:
+			value, err = typedArray(rt, "Int32Array", v)
+		case []uint32:
+			value, err = typedArray(rt, "Uint32Array", v)
+		case []int64:
+			value, err = e.int64Column(v)
+		case []uint64:
+			value, err = typedArray(rt, "BigUint64Array", v)
+		case []float32:
+			value, err = typedArray(rt, "Float32Array", v)
+		case []float64:
+			value, err = typedArray(rt, "Float64Array", v)
+		default:
+			value = e.value(values)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if err := obj.Set(column, value); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// typedArray returns a JS typed array over the memory of a slice, whose
+// values are in the native byte order, as in typed arrays.
+func typedArray[T number](rt *sobek.Runtime, constructor string, values []T) (sobek.Value, error) {
+	var data []byte
+	if len(values) > 0 {
+		data = unsafe.Slice((*byte)(unsafe.Pointer(&values[0])), len(values)*int(unsafe.Sizeof(values[0])))
+	}
+	array, err := rt.New(rt.Get(constructor), rt.ToValue(rt.NewArrayBuffer(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", constructor, err)
+	}
+	return array, nil
+}
+
+// int64Column converts an INT64 column as set by the int64Mode option: a
+// Float64Array in number mode, an array of strings in string mode, and a
+// BigInt64Array otherwise, since a typed array cannot mix numbers and
+// BigInts.
+func (e *exporter) int64Column(values []int64) (sobek.Value, error) {
+	switch e.int64Mode {
+	case int64Number:
+		numbers := make([]float64, len(values))
+		for i, v := range values {
+			if (v > maxSafeInteger || v < -maxSafeInteger) && e.lossy != nil {
+				e.lossy(v)
+			}
+			numbers[i] = float64(v)
+		}
+		return typedArray(e.rt, "Float64Array", numbers)
+	case int64String:
+		strs := make([]interface{}, len(values))
+		for i, v := range values {
+			strs[i] = strconv.FormatInt(v, 10)
+		}
+		return e.rt.ToValue(strs), nil
+	default:
+		return typedArray(e.rt, "BigInt64Array", values)
+	}
+}
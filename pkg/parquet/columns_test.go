@@ -0,0 +1,155 @@
+package parquet
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// createColumnsParquetFile creates a file of 1000 rows in row groups of 300
+// rows and small pages, with a null value every 10 rows.
+func createColumnsParquetFile(t *testing.T) string {
+	t.Helper()
+
+	type MeasureRow struct {
+		TS     int64    `parquet:"ts"`
+		Value  *float64 `parquet:"value,optional"`
+		Sensor string   `parquet:"sensor,dict"`
+		Count  uint32   `parquet:"count"`
+		Tags   []string `parquet:"tags,list"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "columns.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer file.Close()
+
+	rows := make([]MeasureRow, 1000)
+	for i := range rows {
+		rows[i] = MeasureRow{TS: int64(i) * 1000, Sensor: []string{"a", "b"}[i%2], Count: uint32(i)}
+		if i%10 != 0 {
+			value := float64(i) / 2
+			rows[i].Value = &value
+		}
+	}
+
+	writer := parquet.NewGenericWriter[MeasureRow](file,
+		parquet.MaxRowsPerRowGroup(300), parquet.PageBufferSize(512))
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	return filename
+}
+
+func TestReadColumns(t *testing.T) {
+	filename := createColumnsParquetFile(t)
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Columns are read into typed slices", func(t *testing.T) {
+		columns, err := p.ReadColumns(filename, []string{"ts", "value", "sensor", "count"})
+		if err != nil {
+			t.Fatalf("ReadColumns() error = %v", err)
+		}
+
+		ts := columns["ts"].([]int64)
+		if len(ts) != 1000 || ts[999] != 999000 {
+			t.Errorf("unexpected ts column of %d values", len(ts))
+		}
+		values := columns["value"].([]float64)
+		if len(values) != 1000 || !math.IsNaN(values[10]) || values[11] != 5.5 {
+			t.Errorf("expected NaN for nulls and 5.5 at 11, got %v and %v", values[10], values[11])
+		}
+		sensors := columns["sensor"].([]interface{})
+		if len(sensors) != 1000 || sensors[0] != "a" || sensors[999] != "b" {
+			t.Errorf("unexpected sensor column %v", sensors[:2])
+		}
+		if counts := columns["count"].([]uint32); counts[500] != 500 {
+			t.Errorf("expected 500, got %d", counts[500])
+		}
+	})
+
+	t.Run("Row window", func(t *testing.T) {
+		columns, err := p.ReadColumns(filename, []string{"ts", "value"}, map[string]interface{}{
+			"skipRows": 295,
+			"rowLimit": 10,
+		})
+		if err != nil {
+			t.Fatalf("ReadColumns() error = %v", err)
+		}
+		ts := columns["ts"].([]int64)
+		if len(ts) != 10 || ts[0] != 295000 || ts[9] != 304000 {
+			t.Errorf("expected ts 295000 to 304000, got %v", ts)
+		}
+		if values := columns["value"].([]float64); len(values) != 10 || !math.IsNaN(values[5]) {
+			t.Errorf("expected a null value at 300, got %v", values)
+		}
+	})
+
+	t.Run("Datasets", func(t *testing.T) {
+		dir := createHiveDataset(t)
+		columns, err := p.ReadColumns(dir, []string{"id", "region"}, map[string]interface{}{"skipRows": 15})
+		if err != nil {
+			t.Fatalf("ReadColumns() error = %v", err)
+		}
+		ids := columns["id"].([]int64)
+		if len(ids) != 25 || ids[0] != 15 || ids[5] != 30 {
+			t.Errorf("unexpected ids %v", ids)
+		}
+		if regions := columns["region"].([]interface{}); regions[0] != "us" || regions[5] != nil {
+			t.Errorf("unexpected regions %v", regions)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for name, call := range map[string]func() error{
+			"unknown column": func() error {
+				_, err := p.ReadColumns(filename, []string{"missing"})
+				return err
+			},
+			"list column": func() error {
+				_, err := p.ReadColumns(filename, []string{"tags.list.element"})
+				return err
+			},
+			"no columns": func() error {
+				_, err := p.ReadColumns(filename, nil)
+				return err
+			},
+			"filter": func() error {
+				_, err := p.ReadColumns(filename, []string{"ts"}, map[string]interface{}{"filter": "ts > 1"})
+				return err
+			},
+		} {
+			if call() == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+	})
+}
+
+func TestReadColumnsJS(t *testing.T) {
+	filename := createColumnsParquetFile(t)
+
+	result := runAsync(t, filename, `
+		const { ts, value, sensor } = parquet.readColumns(filename, ['ts', 'value', 'sensor'], { rowLimit: 20 });
+		result = [
+			ts instanceof Float64Array,
+			ts[19] === 19000,
+			parquet.readColumns(filename, ['ts'], { int64Mode: 'bigint' }).ts[19] === 19000n,
+			value instanceof Float64Array,
+			value[11],
+			sensor.length,
+			sensor[1],
+		].join(',');
+	`)
+	if result != "true,true,true,true,5.5,20,b" {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
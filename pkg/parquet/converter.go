@@ -1,31 +1,190 @@
 package parquet
 
 import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
 	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+	"github.com/parquet-go/parquet-go/format"
 )
 
-// ConvertSchema converts a Parquet schema to a readable map format.
+// ConvertSchema converts a Parquet schema to a tree describing its fields in
+// schema order. The result holds the name of the schema, its top-level
+// "fields" and the dotted paths of its leaf "columns" in column order. Each
+// field describes its physical type ("GROUP" for groups), repetition,
+// logical and converted types with their parameters, field ID and maximum
+// definition and repetition levels. Groups list their "children", and leaves
+// their "column" path and "columnIndex".
 func ConvertSchema(schema *parquet.Schema) map[string]interface{} {
-	result := make(map[string]interface{})
+	c := &schemaConverter{}
+	return map[string]interface{}{
+		"name":    schema.Name(),
+		"fields":  c.fields(schema, nil, 0, 0),
+		"columns": c.columns,
+	}
+}
 
-	for _, field := range schema.Fields() {
-		fieldInfo := map[string]interface{}{
-			"type":     field.Type().String(),
-			"optional": field.Optional(),
-			"repeated": field.Repeated(),
-		}
+// schemaConverter walks a schema depth first, numbering the leaf columns as
+// Parquet does.
+type schemaConverter struct {
+	columns []interface{}
+}
 
-		// Add logical type if available
-		if logicalType := getLogicalType(field); logicalType != "" {
-			fieldInfo["logical"] = logicalType
-		} else {
-			fieldInfo["logical"] = "none"
+// fields converts the children of a group at the given path and levels.
+func (c *schemaConverter) fields(group parquet.Node, path []string, def, rep int) []interface{} {
+	fields := make([]interface{}, 0, len(group.Fields()))
+	for _, field := range group.Fields() {
+		fields = append(fields, c.field(field, path, def, rep))
+	}
+	return fields
+}
+
+// field converts a field nested in the groups of path, whose parent has the
+// given maximum definition and repetition levels.
+func (c *schemaConverter) field(field parquet.Field, parent []string, def, rep int) map[string]interface{} {
+	path := append(parent[:len(parent):len(parent)], field.Name())
+	repetition := "REQUIRED"
+	switch {
+	case field.Repeated():
+		repetition = "REPEATED"
+		def++
+		rep++
+	case field.Optional():
+		repetition = "OPTIONAL"
+		def++
+	}
+
+	info := map[string]interface{}{
+		"name":               field.Name(),
+		"path":               strings.Join(path, "."),
+		"repetition":         repetition,
+		"optional":           field.Optional(),
+		"repeated":           field.Repeated(),
+		"maxDefinitionLevel": def,
+		"maxRepetitionLevel": rep,
+		"logical":            "none",
+	}
+	if id := field.ID(); id != 0 {
+		info["fieldId"] = id
+	}
+
+	typ := field.Type()
+	if logical := getLogicalType(field); logical != "" {
+		info["logical"] = logical
+		info["logicalType"] = logicalTypeInfo(typ.LogicalType())
+	}
+	if ct := typ.ConvertedType(); ct != nil && int(*ct) < len(convertedTypeNames) {
+		info["convertedType"] = convertedTypeNames[*ct]
+	}
+
+	if !field.Leaf() {
+		info["type"] = "GROUP"
+		info["children"] = c.fields(field, path, def, rep)
+		return info
+	}
+	info["type"] = typ.Kind().String()
+	if typ.Kind() == parquet.FixedLenByteArray {
+		info["length"] = typ.Length()
+	}
+	info["column"] = info["path"]
+	info["columnIndex"] = len(c.columns)
+	c.columns = append(c.columns, info["path"])
+	return info
+}
+
+// logicalTypeInfo describes a logical type by its name and parameters.
+func logicalTypeInfo(lt *format.LogicalType) map[string]interface{} {
+	switch {
+	case lt.UTF8 != nil:
+		return map[string]interface{}{"type": "STRING"}
+	case lt.Map != nil:
+		return map[string]interface{}{"type": "MAP"}
+	case lt.List != nil:
+		return map[string]interface{}{"type": "LIST"}
+	case lt.Enum != nil:
+		return map[string]interface{}{"type": "ENUM"}
+	case lt.Decimal != nil:
+		return map[string]interface{}{
+			"type":      "DECIMAL",
+			"precision": int(lt.Decimal.Precision),
+			"scale":     int(lt.Decimal.Scale),
 		}
+	case lt.Date != nil:
+		return map[string]interface{}{"type": "DATE"}
+	case lt.Time != nil:
+		return map[string]interface{}{
+			"type":            "TIME",
+			"unit":            timeUnitName(&lt.Time.Unit),
+			"isAdjustedToUTC": lt.Time.IsAdjustedToUTC,
+		}
+	case lt.Timestamp != nil:
+		return map[string]interface{}{
+			"type":            "TIMESTAMP",
+			"unit":            timeUnitName(&lt.Timestamp.Unit),
+			"isAdjustedToUTC": lt.Timestamp.IsAdjustedToUTC,
+		}
+	case lt.Integer != nil:
+		return map[string]interface{}{
+			"type":     "INTEGER",
+			"bitWidth": int(lt.Integer.BitWidth),
+			"signed":   lt.Integer.IsSigned,
+		}
+	case lt.Unknown != nil:
+		return map[string]interface{}{"type": "UNKNOWN"}
+	case lt.Json != nil:
+		return map[string]interface{}{"type": "JSON"}
+	case lt.Bson != nil:
+		return map[string]interface{}{"type": "BSON"}
+	case lt.UUID != nil:
+		return map[string]interface{}{"type": "UUID"}
+	case lt.Float16 != nil:
+		return map[string]interface{}{"type": "FLOAT16"}
+	default:
+		return map[string]interface{}{"type": lt.String()}
+	}
+}
 
-		result[field.Name()] = fieldInfo
+// timeUnitName returns the name of a parquet time unit.
+func timeUnitName(unit *format.TimeUnit) string {
+	switch {
+	case unit.Millis != nil:
+		return "MILLIS"
+	case unit.Micros != nil:
+		return "MICROS"
+	default:
+		return "NANOS"
 	}
+}
 
-	return result
+// convertedTypeNames are the names of the legacy converted types, indexed by
+// their value.
+var convertedTypeNames = []string{
+	deprecated.UTF8:            "UTF8",
+	deprecated.Map:             "MAP",
+	deprecated.MapKeyValue:     "MAP_KEY_VALUE",
+	deprecated.List:            "LIST",
+	deprecated.Enum:            "ENUM",
+	deprecated.Decimal:         "DECIMAL",
+	deprecated.Date:            "DATE",
+	deprecated.TimeMillis:      "TIME_MILLIS",
+	deprecated.TimeMicros:      "TIME_MICROS",
+	deprecated.TimestampMillis: "TIMESTAMP_MILLIS",
+	deprecated.TimestampMicros: "TIMESTAMP_MICROS",
+	deprecated.Uint8:           "UINT_8",
+	deprecated.Uint16:          "UINT_16",
+	deprecated.Uint32:          "UINT_32",
+	deprecated.Uint64:          "UINT_64",
+	deprecated.Int8:            "INT_8",
+	deprecated.Int16:           "INT_16",
+	deprecated.Int32:           "INT_32",
+	deprecated.Int64:           "INT_64",
+	deprecated.Json:            "JSON",
+	deprecated.Bson:            "BSON",
+	deprecated.Interval:        "INTERVAL",
 }
 
 // getLogicalType extracts the logical type from a Parquet field.
@@ -35,3 +194,280 @@ func getLogicalType(field parquet.Field) string {
 	}
 	return ""
 }
+
+// Timestamp conversion modes accepted by the "timestamps" read option.
+const (
+	timestampsDate   = "date"   // JS Date objects
+	timestampsString = "string" // ISO 8601 strings
+	timestampsRaw    = "raw"    // physical values, as stored in the file
+)
+
+// julianDayOfUnixEpoch is the Julian day number of 1970-01-01, used to decode
+// legacy INT96 timestamps.
+const julianDayOfUnixEpoch = 2440588
+
+// ConvertOptions controls how Parquet values are converted to JS values.
+type ConvertOptions struct {
+	Timestamps string `json:"timestamps"` // "date", "string" or "raw"
+	ParseJSON  bool   `json:"parseJSON"`  // Parse JSON and BSON columns into objects
+
+	// Int64Mode sets how INT64 values are converted to JS values. It applies
+	// when rows are exported to JS, so that decoded rows are shared by all
+	// modes.
+	Int64Mode string `json:"-"`
+
+	// Binary sets how binary values, such as BYTE_ARRAY values without a
+	// string logical type, are converted to JS values. Like Int64Mode, it
+	// applies when rows are exported to JS.
+	Binary string `json:"-"`
+}
+
+// defaultConvertOptions returns the conversion options used when none are given.
+func defaultConvertOptions() ConvertOptions {
+	return ConvertOptions{
+		Timestamps: timestampsDate,
+		Int64Mode:  int64Number,
+		Binary:     binaryArrayBuffer,
+	}
+}
+
+// valueConverter converts a non-null leaf value to a Go value.
+type valueConverter func(value parquet.Value, opts *ConvertOptions) interface{}
+
+// newValueConverter returns the converter for the values of a leaf node,
+// driven by its logical type. Nodes without a logical type fall back to the
+// physical conversion of valueToInterface, which returns binary values as
+// bytes: only STRING, ENUM and JSON values are known to be text.
+func newValueConverter(node parquet.Node) valueConverter {
+	typ := node.Type()
+	if typ.Kind() == parquet.Int96 {
+		return convertInt96
+	}
+
+	lt := typ.LogicalType()
+	if lt == nil {
+		return physicalValue
+	}
+
+	switch {
+	case lt.UTF8 != nil, lt.Enum != nil:
+		return convertString
+	case lt.Integer != nil && !lt.Integer.IsSigned:
+		if typ.Kind() == parquet.Int64 {
+			return convertUint64
+		}
+		return convertUint32
+	case lt.Timestamp != nil:
+		return timestampConverter(lt.Timestamp)
+	case lt.Date != nil:
+		return convertDate
+	case lt.Time != nil:
+		return timeConverter(lt.Time)
+	case lt.Decimal != nil:
+		return decimalConverter(lt.Decimal)
+	case lt.UUID != nil:
+		return convertUUID
+	case lt.Json != nil:
+		return convertJSON
+	case lt.Bson != nil:
+		return convertBSON
+	default:
+		return physicalValue
+	}
+}
+
+// physicalValue converts a value based on its physical type only.
+func physicalValue(value parquet.Value, _ *ConvertOptions) interface{} {
+	return valueToInterface(value)
+}
+
+// convertUint32 converts unsigned INT32 values, which may exceed the range
+// of int32.
+func convertUint32(value parquet.Value, _ *ConvertOptions) interface{} {
+	return value.Uint32()
+}
+
+// convertUint64 converts unsigned INT64 values, which may exceed the range
+// of int64.
+func convertUint64(value parquet.Value, _ *ConvertOptions) interface{} {
+	return value.Uint64()
+}
+
+// convertString converts STRING and ENUM values, stored as UTF-8 bytes.
+func convertString(value parquet.Value, _ *ConvertOptions) interface{} {
+	return string(value.ByteArray())
+}
+
+// timestampConverter converts TIMESTAMP values of the given unit.
+func timestampConverter(t *format.TimestampType) valueConverter {
+	unit := timeUnitDuration(&t.Unit)
+	return func(value parquet.Value, opts *ConvertOptions) interface{} {
+		if opts.Timestamps == timestampsRaw {
+			return valueToInterface(value)
+		}
+		ts := unixTime(value.Int64(), unit)
+		return formatTime(ts, opts, t.IsAdjustedToUTC)
+	}
+}
+
+// convertDate converts DATE values, stored as days since the Unix epoch.
+func convertDate(value parquet.Value, opts *ConvertOptions) interface{} {
+	switch opts.Timestamps {
+	case timestampsRaw:
+		return valueToInterface(value)
+	case timestampsString:
+		return time.Unix(int64(value.Int32())*86400, 0).UTC().Format(time.DateOnly)
+	default:
+		return time.Unix(int64(value.Int32())*86400, 0).UTC()
+	}
+}
+
+// timeConverter converts TIME values of the given unit. Times of day have no
+// Date equivalent and are always returned as strings unless raw.
+func timeConverter(t *format.TimeType) valueConverter {
+	unit := timeUnitDuration(&t.Unit)
+	return func(value parquet.Value, opts *ConvertOptions) interface{} {
+		if opts.Timestamps == timestampsRaw {
+			return valueToInterface(value)
+		}
+		var d time.Duration
+		if value.Kind() == parquet.Int32 {
+			d = time.Duration(value.Int32()) * unit
+		} else {
+			d = time.Duration(value.Int64()) * unit
+		}
+		return time.Unix(0, int64(d)).UTC().Format("15:04:05.999999999")
+	}
+}
+
+// convertInt96 decodes legacy INT96 timestamps: nanoseconds within the day
+// in the first eight bytes, followed by the Julian day number.
+func convertInt96(value parquet.Value, opts *ConvertOptions) interface{} {
+	if opts.Timestamps == timestampsRaw {
+		return valueToInterface(value)
+	}
+	return formatTime(int96Time(value.Int96()), opts, true)
+}
+
+// int96Time returns the time of a legacy INT96 timestamp.
+func int96Time(i96 deprecated.Int96) time.Time {
+	nanos := int64(uint64(i96[1])<<32 | uint64(i96[0]))
+	days := int64(i96[2]) - julianDayOfUnixEpoch
+	return time.Unix(days*86400, nanos).UTC()
+}
+
+// formatTime returns a timestamp as a time.Time or as an ISO 8601 string.
+// Timestamps not adjusted to UTC are local date-times and carry no zone.
+func formatTime(ts time.Time, opts *ConvertOptions, adjustedToUTC bool) interface{} {
+	if opts.Timestamps != timestampsString {
+		return ts
+	}
+	if adjustedToUTC {
+		return ts.Format(time.RFC3339Nano)
+	}
+	return ts.Format("2006-01-02T15:04:05.999999999")
+}
+
+// timeUnitDuration returns the duration of one tick of a parquet time unit.
+func timeUnitDuration(unit *format.TimeUnit) time.Duration {
+	switch {
+	case unit.Millis != nil:
+		return time.Millisecond
+	case unit.Micros != nil:
+		return time.Microsecond
+	default:
+		return time.Nanosecond
+	}
+}
+
+// unixTime converts a number of ticks since the Unix epoch to a UTC time.
+func unixTime(ticks int64, unit time.Duration) time.Time {
+	switch unit {
+	case time.Millisecond:
+		return time.UnixMilli(ticks).UTC()
+	case time.Microsecond:
+		return time.UnixMicro(ticks).UTC()
+	default:
+		return time.Unix(0, ticks).UTC()
+	}
+}
+
+// decimalConverter converts DECIMAL values to exact strings, whatever the
+// physical type used to store the unscaled integer.
+func decimalConverter(t *format.DecimalType) valueConverter {
+	scale := int(t.Scale)
+	return func(value parquet.Value, _ *ConvertOptions) interface{} {
+		unscaled := new(big.Int)
+		switch value.Kind() {
+		case parquet.Int32:
+			unscaled.SetInt64(int64(value.Int32()))
+		case parquet.Int64:
+			unscaled.SetInt64(value.Int64())
+		default:
+			setTwosComplement(unscaled, value.ByteArray())
+		}
+		return formatDecimal(unscaled, scale)
+	}
+}
+
+// setTwosComplement sets n to the big-endian two's complement integer in b.
+func setTwosComplement(n *big.Int, b []byte) {
+	n.SetBytes(b)
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		n.Sub(n, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+}
+
+// formatDecimal formats an unscaled integer with the given scale.
+func formatDecimal(unscaled *big.Int, scale int) string {
+	if scale <= 0 {
+		return unscaled.String() + strings.Repeat("0", -scale)
+	}
+
+	digits := new(big.Int).Abs(unscaled).String()
+	if len(digits) <= scale {
+		digits = strings.Repeat("0", scale-len(digits)+1) + digits
+	}
+
+	sign := ""
+	if unscaled.Sign() < 0 {
+		sign = "-"
+	}
+	point := len(digits) - scale
+	return sign + digits[:point] + "." + digits[point:]
+}
+
+// convertUUID formats UUID values in their canonical textual form.
+func convertUUID(value parquet.Value, _ *ConvertOptions) interface{} {
+	b := value.ByteArray()
+	if len(b) != 16 {
+		return valueToInterface(value)
+	}
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// convertJSON returns JSON documents as strings, or parsed when requested.
+func convertJSON(value parquet.Value, opts *ConvertOptions) interface{} {
+	doc := value.ByteArray()
+	if !opts.ParseJSON {
+		return string(doc)
+	}
+	var parsed interface{}
+	if err := json.Unmarshal(doc, &parsed); err != nil {
+		return string(doc)
+	}
+	return parsed
+}
+
+// convertBSON returns BSON documents as raw bytes, or parsed when requested.
+func convertBSON(value parquet.Value, opts *ConvertOptions) interface{} {
+	doc := value.ByteArray()
+	if !opts.ParseJSON {
+		return valueToInterface(value)
+	}
+	parsed, err := decodeBSON(doc)
+	if err != nil {
+		return valueToInterface(value)
+	}
+	return parsed
+}
@@ -1,9 +1,13 @@
 package parquet
 
 import (
+	"math"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
 )
 
 // TestRecord is a simple struct for testing schema conversion
@@ -14,45 +18,85 @@ type TestRecord struct {
 	Active bool   `parquet:"active"`
 }
 
+// schemaField returns the description of a top-level field of a converted
+// schema, or nil.
+func schemaField(schema map[string]interface{}, name string) map[string]interface{} {
+	for _, field := range schema["fields"].([]interface{}) {
+		if f := field.(map[string]interface{}); f["name"] == name {
+			return f
+		}
+	}
+	return nil
+}
+
 func TestConvertSchema(t *testing.T) {
-	// Create a schema from the test struct
-	schema := parquet.SchemaOf(TestRecord{})
+	t.Run("Fields in schema order", func(t *testing.T) {
+		result := ConvertSchema(parquet.SchemaOf(TestRecord{}))
 
-	result := ConvertSchema(schema)
+		fields := result["fields"].([]interface{})
+		if len(fields) != 4 {
+			t.Fatalf("expected 4 fields, got %d", len(fields))
+		}
+		for i, name := range []string{"id", "name", "age", "active"} {
+			if field := fields[i].(map[string]interface{}); field["name"] != name || field["columnIndex"] != i {
+				t.Errorf("expected %s at %d, got %v", name, i, field)
+			}
+		}
+		if !reflect.DeepEqual(result["columns"], []interface{}{"id", "name", "age", "active"}) {
+			t.Errorf("unexpected columns %v", result["columns"])
+		}
 
-	if len(result) != 4 {
-		t.Errorf("expected 4 fields, got %d", len(result))
-	}
+		name := schemaField(result, "name")
+		if name["type"] != "BYTE_ARRAY" || name["repetition"] != "REQUIRED" || name["convertedType"] != "UTF8" {
+			t.Errorf("unexpected name field %v", name)
+		}
+		if lt, _ := name["logicalType"].(map[string]interface{}); lt["type"] != "STRING" {
+			t.Errorf("expected a STRING logical type, got %v", name["logicalType"])
+		}
+	})
 
-	// Check id field
-	if idField, ok := result["id"]; ok {
-		if field, ok := idField.(map[string]interface{}); ok {
-			if field["optional"] == nil {
-				t.Error("expected optional field to be set")
-			}
-			if field["repeated"] == nil {
-				t.Error("expected repeated field to be set")
-			}
-			if field["type"] == nil {
-				t.Error("expected type field to be set")
-			}
-		} else {
-			t.Error("expected field to be a map")
+	t.Run("Nested fields and parameters", func(t *testing.T) {
+		schema := parquet.NewSchema("event", parquet.Group{
+			"id": parquet.FieldID(parquet.Int(64), 7),
+			"ts": parquet.Timestamp(parquet.Microsecond),
+			"price": parquet.Optional(
+				parquet.Decimal(2, 9, parquet.FixedLenByteArrayType(8)),
+			),
+			"tags": parquet.Optional(parquet.List(parquet.String())),
+		})
+		result := ConvertSchema(schema)
+		if result["name"] != "event" {
+			t.Errorf("expected the name of the schema, got %v", result["name"])
 		}
-	} else {
-		t.Error("expected id field in result")
-	}
 
-	// Check name field (String type)
-	if nameField, ok := result["name"]; ok {
-		if field, ok := nameField.(map[string]interface{}); ok {
-			if field["logical"] == "none" {
-				// This is okay, logical type might not be set
-			}
+		id := schemaField(result, "id")
+		if id["fieldId"] != 7 {
+			t.Errorf("expected field ID 7, got %v", id["fieldId"])
 		}
-	} else {
-		t.Error("expected name field in result")
-	}
+
+		ts := schemaField(result, "ts")["logicalType"].(map[string]interface{})
+		if ts["type"] != "TIMESTAMP" || ts["unit"] != "MICROS" || ts["isAdjustedToUTC"] != true {
+			t.Errorf("unexpected timestamp type %v", ts)
+		}
+
+		price := schemaField(result, "price")
+		decimal := price["logicalType"].(map[string]interface{})
+		if decimal["precision"] != 9 || decimal["scale"] != 2 || price["length"] != 8 || price["maxDefinitionLevel"] != 1 {
+			t.Errorf("unexpected decimal field %v", price)
+		}
+
+		tags := schemaField(result, "tags")
+		if tags["type"] != "GROUP" || tags["logicalType"].(map[string]interface{})["type"] != "LIST" {
+			t.Fatalf("expected a LIST group, got %v", tags)
+		}
+		list := tags["children"].([]interface{})[0].(map[string]interface{})
+		element := list["children"].([]interface{})[0].(map[string]interface{})
+		leaf, _ := schema.Lookup("tags", "list", "element")
+		if element["column"] != "tags.list.element" || element["maxDefinitionLevel"] != leaf.MaxDefinitionLevel ||
+			element["maxRepetitionLevel"] != leaf.MaxRepetitionLevel || element["columnIndex"] != leaf.ColumnIndex {
+			t.Errorf("unexpected list element %v", element)
+		}
+	})
 }
 
 func TestGetLogicalType(t *testing.T) {
@@ -95,3 +139,231 @@ func TestGetLogicalType(t *testing.T) {
 		})
 	}
 }
+
+func TestNewValueConverter(t *testing.T) {
+	date := parquet.Date()
+	timestamp := parquet.Timestamp(parquet.Millisecond)
+	local := parquet.TimestampAdjusted(parquet.Microsecond, false)
+	decimal := parquet.Decimal(2, 9, parquet.Int32Type)
+	wideDecimal := parquet.Decimal(3, 20, parquet.FixedLenByteArrayType(9))
+	uuid := parquet.UUID()
+	jsonNode := parquet.JSON()
+
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 6e6, time.UTC)
+
+	tests := []struct {
+		name       string
+		node       parquet.Node
+		value      parquet.Value
+		timestamps string
+		parseJSON  bool
+		expected   interface{}
+	}{
+		{
+			name:       "Timestamp as date",
+			node:       timestamp,
+			value:      parquet.Int64Value(ts.UnixMilli()),
+			timestamps: timestampsDate,
+			expected:   ts,
+		},
+		{
+			name:       "Timestamp as string",
+			node:       timestamp,
+			value:      parquet.Int64Value(ts.UnixMilli()),
+			timestamps: timestampsString,
+			expected:   "2026-01-02T03:04:05.006Z",
+		},
+		{
+			name:       "Timestamp as raw value",
+			node:       timestamp,
+			value:      parquet.Int64Value(ts.UnixMilli()),
+			timestamps: timestampsRaw,
+			expected:   ts.UnixMilli(),
+		},
+		{
+			name:       "Local timestamp as string",
+			node:       local,
+			value:      parquet.Int64Value(ts.UnixMicro()),
+			timestamps: timestampsString,
+			expected:   "2026-01-02T03:04:05.006",
+		},
+		{
+			name:       "Date as string",
+			node:       date,
+			value:      parquet.Int32Value(20455),
+			timestamps: timestampsString,
+			expected:   "2026-01-02",
+		},
+		{
+			name:       "Date as date",
+			node:       date,
+			value:      parquet.Int32Value(20455),
+			timestamps: timestampsDate,
+			expected:   time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "Decimal from INT32",
+			node:     decimal,
+			value:    parquet.Int32Value(-1234),
+			expected: "-12.34",
+		},
+		{
+			name:     "Decimal smaller than one",
+			node:     decimal,
+			value:    parquet.Int32Value(5),
+			expected: "0.05",
+		},
+		{
+			name:     "Decimal from FIXED_LEN_BYTE_ARRAY",
+			node:     wideDecimal,
+			value:    parquet.FixedLenByteArrayValue([]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x30, 0x39}),
+			expected: "12.345",
+		},
+		{
+			name:     "Negative decimal from FIXED_LEN_BYTE_ARRAY",
+			node:     wideDecimal,
+			value:    parquet.FixedLenByteArrayValue([]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}),
+			expected: "-0.001",
+		},
+		{
+			name: "UUID",
+			node: uuid,
+			value: parquet.FixedLenByteArrayValue([]byte{
+				0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3,
+				0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00,
+			}),
+			expected: "123e4567-e89b-12d3-a456-426614174000",
+		},
+		{
+			name:     "JSON as string",
+			node:     jsonNode,
+			value:    parquet.ByteArrayValue([]byte(`{"a":1}`)),
+			expected: `{"a":1}`,
+		},
+		{
+			name:     "String",
+			node:     parquet.String(),
+			value:    parquet.ByteArrayValue([]byte("héllo")),
+			expected: "héllo",
+		},
+		{
+			name:     "Enum",
+			node:     parquet.Enum(),
+			value:    parquet.ByteArrayValue([]byte("RED")),
+			expected: "RED",
+		},
+		{
+			name:     "Plain BYTE_ARRAY as bytes",
+			node:     parquet.Leaf(parquet.ByteArrayType),
+			value:    parquet.ByteArrayValue([]byte{0xff, 0xfe, 0x00}),
+			expected: []byte{0xff, 0xfe, 0x00},
+		},
+		{
+			name:     "UINT_8",
+			node:     parquet.Uint(8),
+			value:    parquet.Int32Value(200),
+			expected: uint32(200),
+		},
+		{
+			name:     "UINT_32 at 2^31",
+			node:     parquet.Uint(32),
+			value:    parquet.Int32Value(math.MinInt32),
+			expected: uint32(1 << 31),
+		},
+		{
+			name:     "UINT_32 maximum",
+			node:     parquet.Uint(32),
+			value:    parquet.Int32Value(-1),
+			expected: uint32(math.MaxUint32),
+		},
+		{
+			name:     "UINT_64 at 2^63",
+			node:     parquet.Uint(64),
+			value:    parquet.Int64Value(math.MinInt64),
+			expected: uint64(1 << 63),
+		},
+		{
+			name:     "UINT_64 maximum",
+			node:     parquet.Uint(64),
+			value:    parquet.Int64Value(-1),
+			expected: uint64(math.MaxUint64),
+		},
+		{
+			name:     "Signed INT_32 minimum",
+			node:     parquet.Int(32),
+			value:    parquet.Int32Value(math.MinInt32),
+			expected: int32(math.MinInt32),
+		},
+		{
+			name:     "Plain INT64",
+			node:     parquet.Leaf(parquet.Int64Type),
+			value:    parquet.Int64Value(42),
+			expected: int64(42),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := defaultConvertOptions()
+			if tt.timestamps != "" {
+				opts.Timestamps = tt.timestamps
+			}
+			opts.ParseJSON = tt.parseJSON
+
+			result := newValueConverter(tt.node)(tt.value, &opts)
+			if expectedTime, ok := tt.expected.(time.Time); ok {
+				if resultTime, ok := result.(time.Time); !ok || !resultTime.Equal(expectedTime) {
+					t.Errorf("converter() = %v (%T), want %v", result, result, expectedTime)
+				}
+				return
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("converter() = %v (%T), want %v (%T)", result, result, tt.expected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestConvertInt96(t *testing.T) {
+	// 2026-01-02T00:00:01Z: Julian day 2461043, one second into the day.
+	value := parquet.Int96Value(deprecated.Int96{1e9, 0, 2461043})
+
+	opts := defaultConvertOptions()
+	opts.Timestamps = timestampsString
+
+	result := newValueConverter(parquet.Leaf(parquet.Int96Type))(value, &opts)
+	if result != "2026-01-02T00:00:01Z" {
+		t.Errorf("expected 2026-01-02T00:00:01Z, got %v", result)
+	}
+}
+
+func TestParseJSONAndBSON(t *testing.T) {
+	opts := defaultConvertOptions()
+	opts.ParseJSON = true
+
+	parsed := newValueConverter(parquet.JSON())(parquet.ByteArrayValue([]byte(`{"a":[1,"b"]}`)), &opts)
+	doc, ok := parsed.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected parsed JSON object, got %T", parsed)
+	}
+	if list, ok := doc["a"].([]interface{}); !ok || len(list) != 2 || list[1] != "b" {
+		t.Errorf("unexpected parsed JSON: %v", doc)
+	}
+
+	// {"name": "k6", "n": int32(7), "ok": true}
+	bson := []byte{
+		0x1e, 0x00, 0x00, 0x00,
+		0x02, 'n', 'a', 'm', 'e', 0x00, 0x03, 0x00, 0x00, 0x00, 'k', '6', 0x00,
+		0x10, 'n', 0x00, 0x07, 0x00, 0x00, 0x00,
+		0x08, 'o', 'k', 0x00, 0x01,
+		0x00,
+	}
+	parsed = newValueConverter(parquet.BSON())(parquet.ByteArrayValue(bson), &opts)
+	doc, ok = parsed.(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected parsed BSON document, got %T", parsed)
+	}
+	if doc["name"] != "k6" || doc["n"] != int32(7) || doc["ok"] != true {
+		t.Errorf("unexpected parsed BSON: %v", doc)
+	}
+}
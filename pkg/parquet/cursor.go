@@ -0,0 +1,185 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/errext"
+	"go.k6.io/k6/js/common"
+)
+
+// Behaviors of a cursor once every row has been handed out.
+const (
+	cursorStop  = "stop"
+	cursorWrap  = "wrap"
+	cursorAbort = "abort"
+)
+
+// errCursorExhausted is returned by cursors aborting at the end of the data.
+var errCursorExhausted = errors.New("cursor exhausted: every row has been used")
+
+// Cursor hands out the rows of a Parquet file in order, each row once across
+// all VUs, like exec.scenario.iterationInTest indexes iterations. Rows are
+// decoded lazily by a dataset, so the file is never loaded in full.
+type Cursor struct {
+	ds    *Dataset
+	onEnd string
+	next  atomic.Int64
+}
+
+// cursorRegistry holds the cursors created by any VU. VUs creating a cursor
+// over the same file with the same options share its position.
+type cursorRegistry struct {
+	mu      sync.Mutex
+	cursors map[string]*Cursor
+}
+
+// newCursorRegistry creates an empty cursor registry.
+func newCursorRegistry() *cursorRegistry {
+	return &cursorRegistry{
+		cursors: make(map[string]*Cursor),
+	}
+}
+
+// open returns the cursor over a file with the given options, creating it if
+// needed.
+func (r *cursorRegistry) open(files *fileSystem, filename string, opts ReadOptions, onEnd string) (*Cursor, error) {
+	key := opts.cacheKey(filename) + "#" + onEnd
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c, ok := r.cursors[key]; ok {
+		return c, nil
+	}
+
+	c, err := newCursor(files, filename, opts, onEnd)
+	if err != nil {
+		return nil, err
+	}
+	r.cursors[key] = c
+	return c, nil
+}
+
+// newCursor creates a cursor at the first row of a file at a resolved path.
+func newCursor(files *fileSystem, filename string, opts ReadOptions, onEnd string) (*Cursor, error) {
+	ds, err := openDataset(files, filename, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Cursor{ds: ds, onEnd: onEnd}, nil
+}
+
+// Len returns the number of rows of the cursor.
+func (c *Cursor) Len() int64 {
+	return c.ds.Len()
+}
+
+// Next returns the next unused row, or nil once every row has been used and
+// the cursor stops. Wrapping cursors start over from the first row, and
+// aborting cursors return errCursorExhausted.
+func (c *Cursor) Next() (map[string]interface{}, error) {
+	i := c.next.Add(1) - 1
+	length := c.ds.Len()
+
+	if i >= length {
+		switch {
+		case c.onEnd == cursorWrap && length > 0:
+			i %= length
+		case c.onEnd == cursorAbort:
+			return nil, errCursorExhausted
+		default:
+			return nil, nil
+		}
+	}
+	return c.ds.Row(i)
+}
+
+// parseCursorEnd parses the onEnd option of a cursor.
+func parseCursorEnd(options ...map[string]interface{}) (string, error) {
+	if len(options) == 0 || options[0] == nil {
+		return cursorStop, nil
+	}
+	onEnd, ok := options[0]["onEnd"].(string)
+	if !ok {
+		return cursorStop, nil
+	}
+	switch onEnd {
+	case cursorStop, cursorWrap, cursorAbort:
+		return onEnd, nil
+	default:
+		return "", fmt.Errorf("invalid onEnd option %q: expected %q, %q or %q", onEnd, cursorStop, cursorWrap, cursorAbort)
+	}
+}
+
+// Cursor creates a cursor handing out each row of a Parquet file once across
+// all VUs. Cursors over the same file with the same options share their
+// position.
+func (p *Parquet) Cursor(filename string, options ...map[string]interface{}) (interface{}, error) {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Filter != nil {
+		return nil, errors.New("filter is not supported by cursor(), rows are addressed by index")
+	}
+	if opts.Partition != nil {
+		return nil, errors.New("partition is not supported by cursor(), rows are shared by all VUs")
+	}
+	onEnd, err := parseCursorEnd(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	filename = p.files.resolve(filename)
+	var c *Cursor
+	if p.cursors != nil {
+		c, err = p.cursors.open(p.files, filename, opts, onEnd)
+	} else {
+		c, err = newCursor(p.files, filename, opts, onEnd)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.vu == nil {
+		return c, nil
+	}
+	return p.cursorObject(c, p.exporter(c.ds.decoder.order, &opts.ConvertOptions)), nil
+}
+
+// cursorObject exposes a cursor to JS with a next() method and a length
+// property.
+func (p *Parquet) cursorObject(c *Cursor, e *exporter) *sobek.Object {
+	rt := e.rt
+	obj := rt.NewObject()
+
+	next := func() sobek.Value {
+		row, err := c.Next()
+		if errors.Is(err, errCursorExhausted) {
+			// Abort the test the way exec.test.abort() does.
+			rt.Interrupt(&errext.InterruptError{Reason: fmt.Sprintf("%s: %s", errext.AbortTest, err)})
+			return sobek.Null()
+		}
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		if row == nil {
+			return sobek.Null()
+		}
+		return e.value(row)
+	}
+
+	if err := obj.Set("next", next); err != nil {
+		common.Throw(rt, err)
+	}
+	if err := obj.DefineAccessorProperty("length", rt.ToValue(func() int64 {
+		return c.Len()
+	}), nil, sobek.FLAG_FALSE, sobek.FLAG_TRUE); err != nil {
+		common.Throw(rt, err)
+	}
+	return obj
+}
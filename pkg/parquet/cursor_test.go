@@ -0,0 +1,133 @@
+package parquet
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+func TestCursor(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	t.Run("Each row is handed out once across goroutines", func(t *testing.T) {
+		c, err := newCursor(nil, filename, ReadOptions{ConvertOptions: defaultConvertOptions()}, cursorStop)
+		if err != nil {
+			t.Fatalf("newCursor() error = %v", err)
+		}
+
+		var mu sync.Mutex
+		seen := make(map[int64]bool)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					row, err := c.Next()
+					if err != nil {
+						t.Errorf("Next() error = %v", err)
+						return
+					}
+					if row == nil {
+						return
+					}
+					mu.Lock()
+					if seen[row["id"].(int64)] {
+						t.Errorf("row %v handed out twice", row["id"])
+					}
+					seen[row["id"].(int64)] = true
+					mu.Unlock()
+				}
+			}()
+		}
+		wg.Wait()
+
+		if len(seen) != 3000 {
+			t.Errorf("expected 3000 rows, got %d", len(seen))
+		}
+	})
+
+	t.Run("End of data", func(t *testing.T) {
+		opts, _ := parseReadOptions(map[string]interface{}{"skipRows": 2998})
+
+		c, _ := newCursor(nil, filename, opts, cursorWrap)
+		var ids []int64
+		for i := 0; i < 5; i++ {
+			row, err := c.Next()
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			ids = append(ids, row["id"].(int64))
+		}
+		if ids[2] != 2998 || ids[4] != 2998 {
+			t.Errorf("expected wrapping ids, got %v", ids)
+		}
+
+		c, _ = newCursor(nil, filename, opts, cursorAbort)
+		_, _ = c.Next()
+		_, _ = c.Next()
+		if _, err := c.Next(); !errors.Is(err, errCursorExhausted) {
+			t.Errorf("expected errCursorExhausted, got %v", err)
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		p := &Parquet{}
+		for _, options := range []map[string]interface{}{
+			{"onEnd": "rewind"},
+			{"filter": "id > 1"},
+			{"partition": "contiguous"},
+		} {
+			if _, err := p.Cursor(filename, options); err == nil {
+				t.Errorf("%v: expected an error", options)
+			}
+		}
+	})
+}
+
+func TestCursorSharedAcrossVUs(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3, 2)
+	root := New()
+
+	runtimes := []*modulestest.Runtime{modulestest.NewRuntime(t), modulestest.NewRuntime(t)}
+	for _, runtime := range runtimes {
+		instance := root.NewModuleInstance(runtime.VU)
+		if err := runtime.VU.Runtime().Set("parquet", instance.Exports().Named); err != nil {
+			t.Fatalf("failed to set exports: %v", err)
+		}
+		if err := runtime.VU.Runtime().Set("filename", filename); err != nil {
+			t.Fatalf("failed to set filename: %v", err)
+		}
+		if _, err := runtime.VU.Runtime().RunString(`
+			const stop = parquet.cursor(filename);
+			const abort = parquet.cursor(filename, { onEnd: 'abort' });
+		`); err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+	}
+
+	var ids []string
+	for _, script := range []string{
+		`stop.next().id`,
+		`[stop.next().id, stop.length].join(',')`,
+		`stop.next().id`,
+		`stop.next()`,
+	} {
+		v, err := runtimes[len(ids)%2].VU.Runtime().RunString(script)
+		if err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		ids = append(ids, v.String())
+	}
+	if strings.Join(ids, ";") != "0;1,3;2;null" {
+		t.Errorf("unexpected rows: %v", ids)
+	}
+
+	_, err := runtimes[0].VU.Runtime().RunString(`abort.next(); abort.next(); abort.next(); abort.next(); 1`)
+	if err == nil || !strings.Contains(err.Error(), "cursor exhausted") {
+		t.Errorf("expected the test to be aborted, got %v", err)
+	}
+}
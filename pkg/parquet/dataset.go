@@ -0,0 +1,308 @@
+package parquet
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"github.com/spf13/afero"
+	"go.k6.io/k6/js/common"
+)
+
+const (
+	// datasetBlockSize is the number of rows decoded at once by a dataset.
+	datasetBlockSize = 1024
+
+	// datasetMaxBlocks is the number of decoded blocks a dataset keeps.
+	datasetMaxBlocks = 64
+)
+
+// Dataset is an immutable, index-addressable view of the rows of a Parquet
+// file, shared by all VUs. Rows are decoded lazily, one block at a time, from
+// the column chunks of the file.
+type Dataset struct {
+	name   string
+	file   afero.File
+	pf     *parquet.File
+	opts   ReadOptions
+	offset int64 // index of the first row of the dataset in the file
+	length int64
+
+	// projection selects the decoded columns, nil for all columns.
+	projection *projection
+	decoder    *recordDecoder
+
+	// rowGroupStarts holds the index of the first row of each row group.
+	rowGroupStarts []int64
+
+	mu     sync.Mutex
+	blocks map[int64]*list.Element
+	lru    *list.List
+
+	// weightsByColumn holds the cumulative weights used by weighted random
+	// sampling, by weight column, and weightsLoading the loads in progress.
+	weightsByColumn map[string]*datasetWeights
+	weightsLoading  map[string]*weightsLoad
+}
+
+// datasetBlock holds decoded rows starting at a block aligned row index.
+type datasetBlock struct {
+	start int64
+	rows  []map[string]interface{}
+}
+
+// datasetRegistry holds the datasets opened by any VU, by name.
+type datasetRegistry struct {
+	mu       sync.Mutex
+	datasets map[string]*Dataset
+}
+
+// newDatasetRegistry creates an empty dataset registry.
+func newDatasetRegistry() *datasetRegistry {
+	return &datasetRegistry{
+		datasets: make(map[string]*Dataset),
+	}
+}
+
+// open returns the dataset registered under name, opening it if needed. As
+// with SharedArray, the first VU to open a name defines its content.
+func (r *datasetRegistry) open(files *fileSystem, name, filename string, opts ReadOptions) (*Dataset, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if ds, ok := r.datasets[name]; ok {
+		return ds, nil
+	}
+
+	ds, err := openDataset(files, name, filename, opts)
+	if err != nil {
+		return nil, err
+	}
+	r.datasets[name] = ds
+	return ds, nil
+}
+
+// openDataset opens a Parquet file at a resolved path as a dataset.
+func openDataset(files *fileSystem, name, filename string, opts ReadOptions) (*Dataset, error) {
+	file, pf, err := files.open(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &Dataset{
+		name:       name,
+		file:       file,
+		pf:         pf,
+		opts:       opts,
+		projection: newProjection(pf.Schema(), opts.Columns),
+		blocks:     make(map[int64]*list.Element),
+		lru:        list.New(),
+	}
+	if ds.projection != nil {
+		ds.decoder = newRecordDecoder(ds.projection.schema)
+	} else {
+		ds.decoder = newRecordDecoder(pf.Schema())
+	}
+
+	var start int64
+	for _, rg := range pf.RowGroups() {
+		ds.rowGroupStarts = append(ds.rowGroupStarts, start)
+		start += rg.NumRows()
+	}
+
+	ds.offset = min(int64(opts.SkipRows), start)
+	ds.length = start - ds.offset
+	if opts.RowLimit > 0 {
+		ds.length = min(ds.length, int64(opts.RowLimit))
+	}
+
+	return ds, nil
+}
+
+// Name returns the name the dataset was opened with.
+func (ds *Dataset) Name() string {
+	return ds.name
+}
+
+// Len returns the number of rows of the dataset.
+func (ds *Dataset) Len() int64 {
+	return ds.length
+}
+
+// Row returns the decoded row at index i of the dataset. The returned row is
+// shared and must not be modified.
+func (ds *Dataset) Row(i int64) (map[string]interface{}, error) {
+	if i < 0 || i >= ds.length {
+		return nil, fmt.Errorf("row index %d out of range [0, %d)", i, ds.length)
+	}
+
+	index := ds.offset + i
+	block, err := ds.block(index - index%datasetBlockSize)
+	if err != nil {
+		return nil, err
+	}
+	return block.rows[index-block.start], nil
+}
+
+// block returns the decoded block starting at the given row of the file.
+func (ds *Dataset) block(start int64) (*datasetBlock, error) {
+	ds.mu.Lock()
+	if e, ok := ds.blocks[start]; ok {
+		ds.lru.MoveToFront(e)
+		ds.mu.Unlock()
+		return e.Value.(*datasetBlock), nil
+	}
+	ds.mu.Unlock()
+
+	// Decode outside of the lock so that VUs reading other blocks are not
+	// blocked; concurrent misses on the same block decode it twice at worst.
+	block, err := ds.decodeBlock(start)
+	if err != nil {
+		return nil, err
+	}
+
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+
+	if e, ok := ds.blocks[start]; ok {
+		ds.lru.MoveToFront(e)
+		return e.Value.(*datasetBlock), nil
+	}
+	ds.blocks[start] = ds.lru.PushFront(block)
+	for ds.lru.Len() > datasetMaxBlocks {
+		oldest := ds.lru.Back()
+		ds.lru.Remove(oldest)
+		delete(ds.blocks, oldest.Value.(*datasetBlock).start)
+	}
+	return block, nil
+}
+
+// decodeBlock decodes up to datasetBlockSize rows starting at the given row
+// of the file, seeking directly into the row group containing it.
+func (ds *Dataset) decodeBlock(start int64) (*datasetBlock, error) {
+	block := &datasetBlock{start: start}
+	buf := make([]parquet.Row, 128)
+
+	for g := ds.rowGroupOf(start); g < len(ds.rowGroupStarts); g++ {
+		remaining := datasetBlockSize - len(block.rows)
+		if remaining == 0 {
+			break
+		}
+
+		rg := ds.pf.RowGroups()[g]
+		if ds.projection != nil {
+			rg = ds.projection.rowGroup(rg)
+		}
+
+		// None of the requested columns exist: rows are empty.
+		if len(rg.ColumnChunks()) == 0 {
+			n := min(int64(remaining), ds.rowGroupStarts[g]+rg.NumRows()-start-int64(len(block.rows)))
+			for i := int64(0); i < n; i++ {
+				block.rows = append(block.rows, map[string]interface{}{})
+			}
+			continue
+		}
+
+		rows := rg.Rows()
+		if err := rows.SeekToRow(start + int64(len(block.rows)) - ds.rowGroupStarts[g]); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to seek to row: %w", err)
+		}
+
+		for remaining > 0 {
+			n, err := rows.ReadRows(buf[:min(len(buf), remaining)])
+			for _, row := range buf[:n] {
+				block.rows = append(block.rows, ds.decoder.decode(row, &ds.opts.ConvertOptions))
+			}
+			remaining -= n
+			if err != nil {
+				if !errors.Is(err, io.EOF) {
+					rows.Close()
+					return nil, fmt.Errorf("failed to read rows: %w", err)
+				}
+				break
+			}
+		}
+		rows.Close()
+	}
+
+	return block, nil
+}
+
+// rowGroupOf returns the index of the row group containing the given row.
+func (ds *Dataset) rowGroupOf(row int64) int {
+	g := 0
+	for g+1 < len(ds.rowGroupStarts) && ds.rowGroupStarts[g+1] <= row {
+		g++
+	}
+	return g
+}
+
+// Open opens a Parquet file as a dataset shared by all VUs, identified by
+// name like a SharedArray. It must be called in the init context.
+func (p *Parquet) Open(name, filename string, options ...map[string]interface{}) (interface{}, error) {
+	if p.vu != nil && p.vu.State() != nil {
+		return nil, errors.New("open() can only be called in the init context")
+	}
+
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Filter != nil {
+		return nil, errors.New("filter is not supported by open(), rows are addressed by index")
+	}
+	if opts.Partition != nil {
+		return nil, errors.New("partition is not supported by open(), datasets are shared by all VUs")
+	}
+
+	filename = p.files.resolve(filename)
+	var ds *Dataset
+	if p.datasets != nil {
+		ds, err = p.datasets.open(p.files, name, filename, opts)
+	} else {
+		ds, err = openDataset(p.files, name, filename, opts)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.vu == nil {
+		return ds, nil
+	}
+	e := p.exporter(ds.decoder.order, &opts.ConvertOptions)
+	view := e.rt.NewDynamicArray(&datasetView{e: e, ds: ds})
+	if err := view.SetPrototype(p.datasetPrototype(e, ds)); err != nil {
+		return nil, err
+	}
+	return view, nil
+}
+
+// datasetView exposes a dataset to JS as a read-only array, so that length,
+// at(), for...of and the other Array methods work as with a SharedArray. Its
+// prototype adds random().
+type datasetView struct {
+	e  *exporter
+	ds *Dataset
+}
+
+func (v *datasetView) Len() int { return int(v.ds.Len()) }
+
+func (v *datasetView) Get(idx int) sobek.Value {
+	if idx < 0 || int64(idx) >= v.ds.Len() {
+		return nil
+	}
+	row, err := v.ds.Row(int64(idx))
+	if err != nil {
+		common.Throw(v.e.rt, err)
+	}
+	return v.e.value(row)
+}
+
+func (v *datasetView) Set(int, sobek.Value) bool { return false }
+
+func (v *datasetView) SetLen(int) bool { return false }
@@ -0,0 +1,155 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"go.k6.io/k6/js/modulestest"
+)
+
+// createRowGroupsParquetFile creates a Parquet file of numRows rows split in
+// row groups of rowGroupSize rows.
+func createRowGroupsParquetFile(t *testing.T, numRows, rowGroupSize int) string {
+	t.Helper()
+
+	type SequenceRow struct {
+		ID   int64  `parquet:"id"`
+		Name string `parquet:"name"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "rowgroups.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer file.Close()
+
+	rows := make([]SequenceRow, numRows)
+	for i := range rows {
+		rows[i] = SequenceRow{ID: int64(i), Name: "row"}
+	}
+
+	writer := parquet.NewGenericWriter[SequenceRow](file, parquet.MaxRowsPerRowGroup(int64(rowGroupSize)))
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	return filename
+}
+
+func TestDataset(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	t.Run("Random access across row groups and blocks", func(t *testing.T) {
+		opts, _ := parseReadOptions()
+		ds, err := openDataset(nil, "all", filename, opts)
+		if err != nil {
+			t.Fatalf("openDataset() error = %v", err)
+		}
+
+		if ds.Len() != 3000 {
+			t.Fatalf("expected 3000 rows, got %d", ds.Len())
+		}
+		if len(ds.rowGroupStarts) != 5 {
+			t.Fatalf("expected 5 row groups, got %d", len(ds.rowGroupStarts))
+		}
+
+		for _, i := range []int64{2999, 0, 699, 700, 1023, 1024, 2048, 1500} {
+			row, err := ds.Row(i)
+			if err != nil {
+				t.Fatalf("Row(%d) error = %v", i, err)
+			}
+			if row["id"] != i {
+				t.Errorf("Row(%d) id = %v", i, row["id"])
+			}
+		}
+
+		if _, err := ds.Row(3000); err == nil {
+			t.Error("expected error for out of range row")
+		}
+	})
+
+	t.Run("Window and columns", func(t *testing.T) {
+		opts, _ := parseReadOptions(map[string]interface{}{
+			"columns":  []interface{}{"id"},
+			"skipRows": 1000,
+			"rowLimit": 50,
+		})
+		ds, err := openDataset(nil, "window", filename, opts)
+		if err != nil {
+			t.Fatalf("openDataset() error = %v", err)
+		}
+
+		if ds.Len() != 50 {
+			t.Fatalf("expected 50 rows, got %d", ds.Len())
+		}
+		row, err := ds.Row(49)
+		if err != nil {
+			t.Fatalf("Row() error = %v", err)
+		}
+		if row["id"] != int64(1049) || len(row) != 1 {
+			t.Errorf("unexpected row: %v", row)
+		}
+	})
+
+	t.Run("Non-existent file", func(t *testing.T) {
+		opts, _ := parseReadOptions()
+		if _, err := openDataset(nil, "missing", "/non/existent/file.parquet", opts); err == nil {
+			t.Error("expected error when opening non-existent file")
+		}
+	})
+
+	t.Run("Filter is rejected", func(t *testing.T) {
+		p := &Parquet{}
+		if _, err := p.Open("filtered", filename, map[string]interface{}{"filter": "id > 10"}); err == nil {
+			t.Error("expected error when opening with a filter")
+		}
+	})
+}
+
+func TestOpenSharedAcrossVUs(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+	root := New()
+
+	runtimes := []*modulestest.Runtime{modulestest.NewRuntime(t), modulestest.NewRuntime(t)}
+	for _, runtime := range runtimes {
+		instance := root.NewModuleInstance(runtime.VU)
+		if err := runtime.VU.Runtime().Set("parquet", instance.Exports().Named); err != nil {
+			t.Fatalf("failed to set exports: %v", err)
+		}
+		if err := runtime.VU.Runtime().Set("filename", filename); err != nil {
+			t.Fatalf("failed to set filename: %v", err)
+		}
+	}
+
+	v, err := runtimes[0].VU.Runtime().RunString(`
+		const ds = parquet.open('rows', filename);
+		let sum = 0;
+		for (const row of ds) { sum += row.id; }
+		[ds.length, ds.at(-1).id, ds[1234].id, sum, Array.isArray(ds)].join(',');
+	`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	if v.String() != "3000,2999,1234,4498500,true" {
+		t.Errorf("unexpected result: %s", v.String())
+	}
+
+	// The second VU gets the dataset registered by the first one, whatever
+	// the path it passes.
+	v, err = runtimes[1].VU.Runtime().RunString(`parquet.open('rows', '/ignored').length`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	if v.ToInteger() != 3000 {
+		t.Errorf("expected 3000 rows, got %v", v)
+	}
+	if len(root.datasets.datasets) != 1 {
+		t.Errorf("expected 1 registered dataset, got %d", len(root.datasets.datasets))
+	}
+}
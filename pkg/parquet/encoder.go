@@ -0,0 +1,608 @@
+package parquet
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
+)
+
+// fieldEncoder shreds the value of one schema node into leaf column values
+// with repetition and definition levels. It is the inverse of fieldDecoder.
+type fieldEncoder struct {
+	name     string
+	node     parquet.Node
+	kind     fieldKind
+	children []*fieldEncoder
+
+	// Index of the first leaf column under this node and number of leaf
+	// columns it spans.
+	column     int
+	numColumns int
+
+	// Repetition and definition levels reached when this node is present.
+	repetitionLevel int
+	definitionLevel int
+
+	// encode converts the values of leaf fields according to their logical
+	// type.
+	encode valueEncoder
+
+	// wrapElement is set on LIST fields following the three-level layout,
+	// where each repeated entry wraps the actual element in a single field.
+	wrapElement bool
+}
+
+// recordEncoder converts nested Go values into flat parquet rows.
+type recordEncoder struct {
+	fields     []*fieldEncoder
+	numColumns int
+}
+
+// newRecordEncoder compiles an encoder for every top-level field of the schema.
+func newRecordEncoder(schema *parquet.Schema) *recordEncoder {
+	e := &recordEncoder{}
+	for _, field := range schema.Fields() {
+		e.fields = append(e.fields, e.compile(field.Name(), field, 0, 0))
+	}
+	return e
+}
+
+// compile builds the encoder of a node given the levels of its parent.
+func (e *recordEncoder) compile(name string, node parquet.Node, repetitionLevel, definitionLevel int) *fieldEncoder {
+	if node.Repeated() {
+		repetitionLevel++
+	}
+	if !node.Required() {
+		definitionLevel++
+	}
+
+	f := &fieldEncoder{
+		name:            name,
+		node:            node,
+		column:          e.numColumns,
+		repetitionLevel: repetitionLevel,
+		definitionLevel: definitionLevel,
+	}
+
+	if node.Leaf() {
+		f.kind = leafField
+		f.encode = newValueEncoder(node)
+		f.numColumns = 1
+		e.numColumns++
+		return f
+	}
+
+	for _, child := range node.Fields() {
+		f.children = append(f.children, e.compile(child.Name(), child, repetitionLevel, definitionLevel))
+	}
+	f.numColumns = e.numColumns - f.column
+
+	switch {
+	case isListNode(node) && len(f.children) == 1 && f.children[0].node.Repeated():
+		f.kind = listField
+		entry := f.children[0]
+		f.wrapElement = entry.kind == groupField &&
+			len(entry.children) == 1 &&
+			entry.name != "array" &&
+			entry.name != name+"_tuple"
+	case isMapNode(node) && len(f.children) == 1 && f.children[0].node.Repeated() &&
+		len(f.children[0].children) == 2:
+		f.kind = mapField
+	default:
+		f.kind = groupField
+	}
+
+	return f
+}
+
+// encode shreds a record into a row.
+func (e *recordEncoder) encode(record map[string]interface{}) (parquet.Row, error) {
+	columns := make([][]parquet.Value, e.numColumns)
+	for _, f := range e.fields {
+		if err := f.shred(columns, record[f.name], 0, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	var row parquet.Row
+	for _, values := range columns {
+		row = append(row, values...)
+	}
+	return row, nil
+}
+
+// shred appends the column values of an occurrence of the field, starting
+// at the given repetition level, below a parent defined up to definitionLevel.
+func (f *fieldEncoder) shred(columns [][]parquet.Value, v interface{}, repetitionLevel, definitionLevel int) error {
+	if f.node.Repeated() {
+		elements, ok := v.([]interface{})
+		if v != nil && !ok {
+			return fmt.Errorf("column %q: expected an array, got %T", f.name, v)
+		}
+		if len(elements) == 0 {
+			f.shredNull(columns, repetitionLevel, definitionLevel)
+			return nil
+		}
+		for i, element := range elements {
+			if element == nil {
+				return fmt.Errorf("column %q: repeated values cannot be null", f.name)
+			}
+			if i > 0 {
+				repetitionLevel = f.repetitionLevel
+			}
+			if err := f.shredValue(columns, element, repetitionLevel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if v == nil {
+		// Missing required lists and maps are written empty.
+		switch {
+		case f.node.Required() && f.kind == listField:
+			v = []interface{}{}
+		case f.node.Required() && f.kind == mapField:
+			v = map[string]interface{}{}
+		case f.node.Required():
+			return fmt.Errorf("column %q: required value is missing", f.name)
+		default:
+			f.shredNull(columns, repetitionLevel, definitionLevel)
+			return nil
+		}
+	}
+	return f.shredValue(columns, v, repetitionLevel)
+}
+
+// shredNull appends a null value to every leaf column under the field.
+func (f *fieldEncoder) shredNull(columns [][]parquet.Value, repetitionLevel, definitionLevel int) {
+	for c := f.column; c < f.column+f.numColumns; c++ {
+		columns[c] = append(columns[c], parquet.NullValue().Level(repetitionLevel, definitionLevel, c))
+	}
+}
+
+// shredValue appends the column values of a present occurrence of the field.
+func (f *fieldEncoder) shredValue(columns [][]parquet.Value, v interface{}, repetitionLevel int) error {
+	switch f.kind {
+	case leafField:
+		value, err := f.encode(v)
+		if err != nil {
+			return fmt.Errorf("column %q: %w", f.name, err)
+		}
+		columns[f.column] = append(columns[f.column], value.Level(repetitionLevel, f.definitionLevel, f.column))
+		return nil
+
+	case listField:
+		elements, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("column %q: expected an array, got %T", f.name, v)
+		}
+		entries := elements
+		if f.wrapElement {
+			entries = make([]interface{}, len(elements))
+			name := f.children[0].children[0].name
+			for i, element := range elements {
+				entries[i] = map[string]interface{}{name: element}
+			}
+		}
+		return f.children[0].shred(columns, entries, repetitionLevel, f.definitionLevel)
+
+	case mapField:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("column %q: expected an object, got %T", f.name, v)
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		entry := f.children[0]
+		entries := make([]interface{}, len(keys))
+		for i, key := range keys {
+			entries[i] = map[string]interface{}{
+				entry.children[0].name: key,
+				entry.children[1].name: m[key],
+			}
+		}
+		return entry.shred(columns, entries, repetitionLevel, f.definitionLevel)
+
+	default:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("column %q: expected an object, got %T", f.name, v)
+		}
+		for _, child := range f.children {
+			if err := child.shred(columns, m[child.name], repetitionLevel, f.definitionLevel); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// valueEncoder converts a non-null Go value to a leaf value.
+type valueEncoder func(v interface{}) (parquet.Value, error)
+
+// newValueEncoder returns the encoder for the values of a leaf node, driven
+// by its logical type. It is the inverse of newValueConverter.
+func newValueEncoder(node parquet.Node) valueEncoder {
+	typ := node.Type()
+	if lt := typ.LogicalType(); lt != nil {
+		switch {
+		case lt.Timestamp != nil:
+			return timestampEncoder(lt.Timestamp)
+		case lt.Date != nil:
+			return encodeDate
+		case lt.Time != nil:
+			return timeEncoder(typ.Kind(), lt.Time)
+		case lt.Decimal != nil:
+			return decimalEncoder(typ, lt.Decimal)
+		case lt.UUID != nil:
+			return encodeUUID
+		case lt.Json != nil:
+			return encodeJSON
+		case lt.Integer != nil:
+			return integerEncoder(typ.Kind(), lt.Integer)
+		}
+	}
+	return physicalEncoder(typ)
+}
+
+// physicalEncoder converts values based on the physical type only.
+func physicalEncoder(typ parquet.Type) valueEncoder {
+	switch typ.Kind() {
+	case parquet.Boolean:
+		return func(v interface{}) (parquet.Value, error) {
+			b, ok := v.(bool)
+			if !ok {
+				return parquet.Value{}, fmt.Errorf("expected a boolean, got %T", v)
+			}
+			return parquet.BooleanValue(b), nil
+		}
+	case parquet.Int32:
+		return func(v interface{}) (parquet.Value, error) {
+			n, err := encodeInteger(v, math.MinInt32, math.MaxInt32)
+			return parquet.Int32Value(int32(n)), err
+		}
+	case parquet.Int64:
+		return func(v interface{}) (parquet.Value, error) {
+			n, err := encodeInteger(v, math.MinInt64, math.MaxInt64)
+			return parquet.Int64Value(n), err
+		}
+	case parquet.Float:
+		return func(v interface{}) (parquet.Value, error) {
+			f, ok := toFloat(v)
+			if !ok {
+				return parquet.Value{}, fmt.Errorf("expected a number, got %T", v)
+			}
+			return parquet.FloatValue(float32(f)), nil
+		}
+	case parquet.Double:
+		return func(v interface{}) (parquet.Value, error) {
+			f, ok := toFloat(v)
+			if !ok {
+				return parquet.Value{}, fmt.Errorf("expected a number, got %T", v)
+			}
+			return parquet.DoubleValue(f), nil
+		}
+	case parquet.FixedLenByteArray:
+		size := typ.Length()
+		return func(v interface{}) (parquet.Value, error) {
+			b, err := encodeBytes(v)
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			if len(b) != size {
+				return parquet.Value{}, fmt.Errorf("expected %d bytes, got %d", size, len(b))
+			}
+			return parquet.FixedLenByteArrayValue(b), nil
+		}
+	default:
+		return func(v interface{}) (parquet.Value, error) {
+			b, err := encodeBytes(v)
+			return parquet.ByteArrayValue(b), err
+		}
+	}
+}
+
+// encodeInteger converts a JS number, BigInt or numeric string to an integer
+// within [lo, hi].
+func encodeInteger(v interface{}, lo, hi int64) (int64, error) {
+	var n int64
+	switch x := v.(type) {
+	case *big.Int:
+		if !x.IsInt64() {
+			return 0, fmt.Errorf("integer %s out of range", x)
+		}
+		n = x.Int64()
+	case string:
+		parsed, err := strconv.ParseInt(x, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("expected an integer, got %q", x)
+		}
+		n = parsed
+	default:
+		parsed, ok := toInt64(v)
+		if !ok {
+			return 0, fmt.Errorf("expected an integer, got %v", v)
+		}
+		n = parsed
+	}
+	if n < lo || n > hi {
+		return 0, fmt.Errorf("integer %d out of range", n)
+	}
+	return n, nil
+}
+
+// integerEncoder encodes INTEGER values of the given bit width and
+// signedness. Unsigned 64-bit values above the int64 range are stored with
+// their bit pattern and must be given as BigInts or strings.
+func integerEncoder(kind parquet.Kind, t *format.IntType) valueEncoder {
+	bits := int(t.BitWidth)
+	lo, hi := int64(math.MinInt64)>>(64-bits), int64(math.MaxInt64)>>(64-bits)
+	if !t.IsSigned {
+		lo, hi = 0, math.MaxInt64
+		if bits < 64 {
+			hi = int64(1)<<bits - 1
+		}
+	}
+
+	return func(v interface{}) (parquet.Value, error) {
+		var n int64
+		if u, ok := bigUint64(v); ok && !t.IsSigned && bits == 64 {
+			n = int64(u)
+		} else {
+			var err error
+			if n, err = encodeInteger(v, lo, hi); err != nil {
+				return parquet.Value{}, err
+			}
+		}
+		if kind == parquet.Int32 {
+			return parquet.Int32Value(int32(n)), nil
+		}
+		return parquet.Int64Value(n), nil
+	}
+}
+
+// bigUint64 returns the value of BigInts and numeric strings above the int64
+// range that fit in a uint64.
+func bigUint64(v interface{}) (uint64, bool) {
+	var n *big.Int
+	switch x := v.(type) {
+	case *big.Int:
+		n = x
+	case string:
+		parsed, ok := new(big.Int).SetString(x, 10)
+		if !ok {
+			return 0, false
+		}
+		n = parsed
+	default:
+		return 0, false
+	}
+	if n.IsInt64() || !n.IsUint64() {
+		return 0, false
+	}
+	return n.Uint64(), true
+}
+
+// encodeBytes converts strings, byte slices and ArrayBuffers to bytes.
+func encodeBytes(v interface{}) ([]byte, error) {
+	switch x := v.(type) {
+	case string:
+		return []byte(x), nil
+	case []byte:
+		return x, nil
+	case sobek.ArrayBuffer:
+		return x.Bytes(), nil
+	case *sobek.ArrayBuffer:
+		return x.Bytes(), nil
+	default:
+		return nil, fmt.Errorf("expected a string or bytes, got %T", v)
+	}
+}
+
+// encodeTime converts Dates, ISO 8601 strings and milliseconds since the
+// Unix epoch to a time.
+func encodeTime(v interface{}) (time.Time, error) {
+	t, ok := toTime(v)
+	if !ok {
+		return time.Time{}, fmt.Errorf("expected a date, got %v", v)
+	}
+	return t, nil
+}
+
+// timestampEncoder encodes TIMESTAMP values of the given unit.
+func timestampEncoder(t *format.TimestampType) valueEncoder {
+	unit := timeUnitDuration(&t.Unit)
+	return func(v interface{}) (parquet.Value, error) {
+		ts, err := encodeTime(v)
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		switch unit {
+		case time.Millisecond:
+			return parquet.Int64Value(ts.UnixMilli()), nil
+		case time.Microsecond:
+			return parquet.Int64Value(ts.UnixMicro()), nil
+		default:
+			return parquet.Int64Value(ts.UnixNano()), nil
+		}
+	}
+}
+
+// encodeDate encodes DATE values as days since the Unix epoch.
+func encodeDate(v interface{}) (parquet.Value, error) {
+	ts, err := encodeTime(v)
+	if err != nil {
+		return parquet.Value{}, err
+	}
+	days := ts.Unix() / 86400
+	if ts.Unix() < 0 && ts.Unix()%86400 != 0 {
+		days--
+	}
+	return parquet.Int32Value(int32(days)), nil
+}
+
+// timeEncoder encodes TIME values of the given unit, given as "HH:MM:SS"
+// strings with optional fractional seconds or as a number of units since
+// midnight.
+func timeEncoder(kind parquet.Kind, t *format.TimeType) valueEncoder {
+	unit := timeUnitDuration(&t.Unit)
+	return func(v interface{}) (parquet.Value, error) {
+		var ticks int64
+		if s, ok := v.(string); ok {
+			parsed, err := time.Parse("15:04:05.999999999", s)
+			if err != nil {
+				return parquet.Value{}, fmt.Errorf("expected a time of day, got %q", s)
+			}
+			midnight := time.Date(parsed.Year(), parsed.Month(), parsed.Day(), 0, 0, 0, 0, time.UTC)
+			ticks = int64(parsed.Sub(midnight) / unit)
+		} else {
+			n, err := encodeInteger(v, 0, math.MaxInt64)
+			if err != nil {
+				return parquet.Value{}, err
+			}
+			ticks = n
+		}
+		if kind == parquet.Int32 {
+			return parquet.Int32Value(int32(ticks)), nil
+		}
+		return parquet.Int64Value(ticks), nil
+	}
+}
+
+// decimalEncoder encodes DECIMAL values given as strings or numbers into
+// the unscaled integer of the physical type.
+func decimalEncoder(typ parquet.Type, t *format.DecimalType) valueEncoder {
+	scale := int(t.Scale)
+	kind := typ.Kind()
+	size := typ.Length()
+	return func(v interface{}) (parquet.Value, error) {
+		var s string
+		switch x := v.(type) {
+		case string:
+			s = x
+		case *big.Int:
+			s = x.String()
+		default:
+			f, ok := toFloat(v)
+			if !ok {
+				return parquet.Value{}, fmt.Errorf("expected a decimal, got %T", v)
+			}
+			s = strconv.FormatFloat(f, 'f', -1, 64)
+		}
+
+		unscaled, err := parseDecimal(s, scale)
+		if err != nil {
+			return parquet.Value{}, err
+		}
+		switch kind {
+		case parquet.Int32:
+			if !unscaled.IsInt64() || unscaled.Int64() < math.MinInt32 || unscaled.Int64() > math.MaxInt32 {
+				return parquet.Value{}, fmt.Errorf("decimal %s out of range", s)
+			}
+			return parquet.Int32Value(int32(unscaled.Int64())), nil
+		case parquet.Int64:
+			if !unscaled.IsInt64() {
+				return parquet.Value{}, fmt.Errorf("decimal %s out of range", s)
+			}
+			return parquet.Int64Value(unscaled.Int64()), nil
+		default:
+			b, err := twosComplement(unscaled, size)
+			if err != nil {
+				return parquet.Value{}, fmt.Errorf("decimal %s out of range", s)
+			}
+			if kind == parquet.FixedLenByteArray {
+				return parquet.FixedLenByteArrayValue(b), nil
+			}
+			return parquet.ByteArrayValue(b), nil
+		}
+	}
+}
+
+// parseDecimal parses a decimal string into its unscaled integer, rejecting
+// values with more fractional digits than the scale.
+func parseDecimal(s string, scale int) (*big.Int, error) {
+	digits := strings.TrimSpace(s)
+	negative := strings.HasPrefix(digits, "-")
+	digits = strings.TrimLeft(digits, "+-")
+
+	integer, fraction, _ := strings.Cut(digits, ".")
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > scale {
+		return nil, fmt.Errorf("decimal %s has more than %d fractional digits", s, scale)
+	}
+
+	unscaled, ok := new(big.Int).SetString(integer+fraction+strings.Repeat("0", scale-len(fraction)), 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid decimal %q", s)
+	}
+	if negative {
+		unscaled.Neg(unscaled)
+	}
+	return unscaled, nil
+}
+
+// twosComplement returns the big-endian two's complement of n on size bytes,
+// or on the minimal number of bytes when size is zero.
+func twosComplement(n *big.Int, size int) ([]byte, error) {
+	minimal := n.BitLen()/8 + 1
+	if size == 0 {
+		size = minimal
+	}
+	if minimal > size {
+		return nil, fmt.Errorf("integer %s does not fit in %d bytes", n, size)
+	}
+
+	b := make([]byte, size)
+	if n.Sign() >= 0 {
+		n.FillBytes(b)
+		return b, nil
+	}
+	// Two's complement of a negative number: 2^(8*size) + n.
+	new(big.Int).Add(new(big.Int).Lsh(big.NewInt(1), uint(size*8)), n).FillBytes(b)
+	return b, nil
+}
+
+// encodeUUID encodes UUIDs given in their textual form.
+func encodeUUID(v interface{}) (parquet.Value, error) {
+	s, ok := v.(string)
+	if !ok {
+		b, err := encodeBytes(v)
+		if err != nil || len(b) != 16 {
+			return parquet.Value{}, fmt.Errorf("expected a UUID, got %v", v)
+		}
+		return parquet.FixedLenByteArrayValue(b), nil
+	}
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(b) != 16 {
+		return parquet.Value{}, fmt.Errorf("invalid UUID %q", s)
+	}
+	return parquet.FixedLenByteArrayValue(b), nil
+}
+
+// encodeJSON encodes JSON documents given as strings or as values to
+// serialize.
+func encodeJSON(v interface{}) (parquet.Value, error) {
+	if s, ok := v.(string); ok {
+		return parquet.ByteArrayValue([]byte(s)), nil
+	}
+	doc, err := json.Marshal(v)
+	if err != nil {
+		return parquet.Value{}, fmt.Errorf("failed to encode JSON: %w", err)
+	}
+	return parquet.ByteArrayValue(doc), nil
+}
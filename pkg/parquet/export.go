@@ -0,0 +1,483 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"math/big"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/grafana/sobek"
+)
+
+// Conversion modes of INT64 values accepted by the "int64Mode" read option.
+const (
+	int64Number = "number" // JS numbers, losing precision beyond 2^53
+	int64BigInt = "bigint" // JS BigInts
+	int64String = "string" // decimal strings
+	int64Auto   = "auto"   // numbers, or BigInts when a number would lose precision
+)
+
+// Conversion modes of binary values accepted by the "binary" read option.
+const (
+	binaryArrayBuffer = "arraybuffer" // ArrayBuffers holding a copy of the bytes
+	binaryBase64      = "base64"      // standard base64 strings
+	binaryHex         = "hex"         // lowercase hexadecimal strings
+)
+
+// maxSafeInteger is the largest integer JS numbers represent exactly.
+const maxSafeInteger = 1<<53 - 1
+
+// parseExportOptions parses the options applied when decoded values are
+// exported to JS, int64Mode and binary, into opts.
+func parseExportOptions(opts *ConvertOptions, options ...map[string]interface{}) error {
+	mode, err := parseInt64Mode(options...)
+	if err != nil {
+		return err
+	}
+	opts.Int64Mode = mode
+	if len(options) == 0 || options[0] == nil || options[0]["binary"] == nil {
+		return nil
+	}
+	switch binary := options[0]["binary"]; binary {
+	case binaryArrayBuffer, binaryBase64, binaryHex:
+		opts.Binary = binary.(string)
+		return nil
+	default:
+		return fmt.Errorf("invalid binary option %v: expected %q, %q or %q",
+			binary, binaryArrayBuffer, binaryBase64, binaryHex)
+	}
+}
+
+// exportOptions returns the export options of options already validated by
+// the read they apply to.
+func exportOptions(options ...map[string]interface{}) *ConvertOptions {
+	opts := defaultConvertOptions()
+	_ = parseExportOptions(&opts, options...)
+	return &opts
+}
+
+// parseInt64Mode parses the int64Mode option, which defaults to numbers.
+func parseInt64Mode(options ...map[string]interface{}) (string, error) {
+	if len(options) == 0 || options[0] == nil {
+		return int64Number, nil
+	}
+	mode, ok := options[0]["int64Mode"]
+	if !ok || mode == nil {
+		return int64Number, nil
+	}
+	switch mode {
+	case int64Number, int64BigInt, int64String, int64Auto:
+		return mode.(string), nil
+	default:
+		return "", fmt.Errorf("invalid int64Mode option %v: expected %q, %q, %q or %q",
+			mode, int64Number, int64BigInt, int64String, int64Auto)
+	}
+}
+
+// export converts decoded Go values into JS values, with INT64 and binary
+// values converted as set by the int64Mode and binary options. Rows, objects
+// and arrays are exposed through views over the decoded data, which may be
+// shared with other VUs through the cache: a view copies the data it exposes
+// on the first write, leaving the decoded data untouched. Keys are ordered
+// by the field order of the schema they were decoded from, if any.
+// Without a VU, as in unit tests, values are returned unchanged.
+func (p *Parquet) export(v interface{}, order *fieldOrder, opts *ConvertOptions) interface{} {
+	if p.vu == nil {
+		return v
+	}
+	return p.exporter(order, opts).value(v)
+}
+
+// exporter returns the exporter of values to the JS runtime of the VU.
+func (p *Parquet) exporter(order *fieldOrder, opts *ConvertOptions) *exporter {
+	return &exporter{
+		rt:         p.vu.Runtime(),
+		int64Mode:  opts.Int64Mode,
+		binaryMode: opts.Binary,
+		order:      order,
+		lossy:      p.warnPrecisionLoss,
+	}
+}
+
+// warnPrecisionLoss warns, once per VU, that an INT64 value was converted to
+// a JS number that does not represent it exactly.
+func (p *Parquet) warnPrecisionLoss(v interface{}) {
+	if !p.precisionWarned.CompareAndSwap(false, true) {
+		return
+	}
+	if state := p.vu.State(); state != nil && state.Logger != nil {
+		state.Logger.Warnf(precisionLossWarning, v)
+	} else if env := p.vu.InitEnv(); env != nil && env.Logger != nil {
+		env.Logger.Warnf(precisionLossWarning, v)
+	}
+}
+
+// precisionLossWarning is logged the first time an INT64 value loses
+// precision as a JS number.
+const precisionLossWarning = "INT64 value %d is beyond 2^53 and loses precision as a JS number; " +
+	`set the int64Mode option to "bigint", "string" or "auto" to read it exactly`
+
+// exporter converts decoded values to JS values, wrapping containers in
+// lazy copy-on-write views and converting values sobek cannot map on its own.
+type exporter struct {
+	rt         *sobek.Runtime
+	int64Mode  string
+	binaryMode string
+
+	// order orders the keys of the exported values, which are sorted
+	// without it.
+	order *fieldOrder
+
+	// lossy is called with the INT64 values, signed or not, converted to
+	// numbers that do not represent them exactly.
+	lossy func(interface{})
+}
+
+// value converts a decoded value to a JS value.
+func (e *exporter) value(v interface{}) sobek.Value {
+	return e.ordered(v, e.order)
+}
+
+// ordered converts a decoded value to a JS value whose keys, and those of
+// the values nested in it, follow the given order.
+func (e *exporter) ordered(v interface{}, order *fieldOrder) sobek.Value {
+	rt := e.rt
+	switch value := v.(type) {
+	case int64:
+		return e.int64(value)
+	case uint64:
+		return e.uint64(value)
+	case []byte:
+		return e.binary(value)
+	case time.Time:
+		date, err := rt.New(rt.Get("Date"), rt.ToValue(value.UnixMilli()))
+		if err != nil {
+			return rt.ToValue(v)
+		}
+		return date
+	case map[string]interface{}:
+		return rt.NewDynamicObject(&objectView{e: e, fields: value, order: order})
+	case []interface{}:
+		return rt.NewDynamicArray(&arrayView{e: e, elements: value, order: order})
+	case []map[string]interface{}:
+		return rt.NewDynamicArray(&rowsView{e: e, rows: value, order: order})
+	default:
+		return rt.ToValue(v)
+	}
+}
+
+// int64 converts an INT64 value as set by the int64Mode option.
+func (e *exporter) int64(v int64) sobek.Value {
+	unsafe := v > maxSafeInteger || v < -maxSafeInteger
+	switch {
+	case e.int64Mode == int64BigInt, e.int64Mode == int64Auto && unsafe:
+		return e.rt.ToValue(big.NewInt(v))
+	case e.int64Mode == int64String:
+		return e.rt.ToValue(strconv.FormatInt(v, 10))
+	}
+	if unsafe && e.int64Mode != int64Auto && e.lossy != nil {
+		e.lossy(v)
+	}
+	return e.rt.ToValue(v)
+}
+
+// uint64 converts an unsigned INT64 value as set by the int64Mode option,
+// like INT64 values.
+func (e *exporter) uint64(v uint64) sobek.Value {
+	if v <= math.MaxInt64 {
+		return e.int64(int64(v))
+	}
+	switch e.int64Mode {
+	case int64BigInt, int64Auto:
+		return e.rt.ToValue(new(big.Int).SetUint64(v))
+	case int64String:
+		return e.rt.ToValue(strconv.FormatUint(v, 10))
+	}
+	if e.lossy != nil {
+		e.lossy(v)
+	}
+	return e.rt.ToValue(v)
+}
+
+// binary converts a binary value as set by the binary option. ArrayBuffers
+// hold a copy, since decoded values may be shared with other VUs.
+func (e *exporter) binary(v []byte) sobek.Value {
+	switch e.binaryMode {
+	case binaryBase64:
+		return e.rt.ToValue(base64.StdEncoding.EncodeToString(v))
+	case binaryHex:
+		return e.rt.ToValue(hex.EncodeToString(v))
+	default:
+		return e.rt.ToValue(e.rt.NewArrayBuffer(bytes.Clone(v)))
+	}
+}
+
+// objectView is a JS object backed by a decoded group or row. The objects
+// of its fields are kept once converted, so that they keep their identity,
+// and the first write copies every field into the view.
+type objectView struct {
+	e      *exporter
+	fields map[string]interface{}
+	order  *fieldOrder
+
+	values map[string]sobek.Value // converted objects, or every value once copied
+	keys   []string               // keys of the copied object, nil until a write
+}
+
+func (o *objectView) Get(key string) sobek.Value {
+	if v, ok := o.values[key]; ok || o.keys != nil {
+		return v
+	}
+	field, ok := o.fields[key]
+	if !ok {
+		return nil
+	}
+	v := o.e.ordered(field, o.order.child(key))
+	if _, ok := v.(*sobek.Object); ok {
+		if o.values == nil {
+			o.values = make(map[string]sobek.Value)
+		}
+		o.values[key] = v
+	}
+	return v
+}
+
+func (o *objectView) Has(key string) bool {
+	if o.keys != nil {
+		_, ok := o.values[key]
+		return ok
+	}
+	_, ok := o.fields[key]
+	return ok
+}
+
+func (o *objectView) Keys() []string {
+	if o.keys != nil {
+		return slices.Clone(o.keys)
+	}
+	return o.order.keys(o.fields)
+}
+
+func (o *objectView) Set(key string, v sobek.Value) bool {
+	o.copy()
+	if _, ok := o.values[key]; !ok {
+		o.keys = append(o.keys, key)
+	}
+	o.values[key] = v
+	return true
+}
+
+func (o *objectView) Delete(key string) bool {
+	o.copy()
+	if _, ok := o.values[key]; ok {
+		delete(o.values, key)
+		o.keys = slices.DeleteFunc(o.keys, func(k string) bool { return k == key })
+	}
+	return true
+}
+
+// copy converts every field into the view, which then owns its values.
+func (o *objectView) copy() {
+	if o.keys != nil {
+		return
+	}
+	keys := o.order.keys(o.fields)
+	values := make(map[string]sobek.Value, len(keys))
+	for _, key := range keys {
+		values[key] = o.Get(key)
+	}
+	o.values, o.keys = values, keys
+}
+
+// fieldOrder orders the keys of decoded objects like the fields of the
+// schema node they were decoded from, since decoded rows and groups are Go
+// maps, which have no order. A nil order sorts keys lexically.
+type fieldOrder struct {
+	ranks    map[string]int         // rank of each field of a group
+	children map[string]*fieldOrder // orders of the values of the fields
+	values   *fieldOrder            // order of every value of a MAP
+}
+
+// child returns the order of the value under a key of an object.
+func (o *fieldOrder) child(key string) *fieldOrder {
+	if o == nil {
+		return nil
+	}
+	if o.values != nil {
+		return o.values
+	}
+	return o.children[key]
+}
+
+// keys returns the keys of an object in field order. The other keys, such
+// as partition columns missing from the schema and the keys of MAP values,
+// follow in lexical order.
+func (o *fieldOrder) keys(fields map[string]interface{}) []string {
+	keys := sortedKeys(fields)
+	if o != nil && o.ranks != nil {
+		sortByRank(keys, o.ranks)
+	}
+	return keys
+}
+
+// sortedKeys returns the keys of an object in lexical order.
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// sortByRank sorts keys by rank. The keys without a rank come last, in
+// their current order.
+func sortByRank(keys []string, ranks map[string]int) {
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, aok := ranks[keys[i]]
+		b, bok := ranks[keys[j]]
+		if aok != bok {
+			return aok
+		}
+		return aok && a < b
+	})
+}
+
+// elementValues holds the JS values of the elements of an array view: the
+// objects already converted, kept so that they keep their identity, and
+// every element once the first write has copied them into the view.
+type elementValues struct {
+	values []sobek.Value
+	copied bool
+}
+
+// get returns the element at idx of an array of n decoded elements,
+// converted by convert.
+func (ev *elementValues) get(idx, n int, convert func(int) sobek.Value) sobek.Value {
+	if ev.copied {
+		if idx < 0 || idx >= len(ev.values) {
+			return nil
+		}
+		return ev.values[idx]
+	}
+	if idx < 0 || idx >= n {
+		return nil
+	}
+	if ev.values != nil && ev.values[idx] != nil {
+		return ev.values[idx]
+	}
+	v := convert(idx)
+	if _, ok := v.(*sobek.Object); ok {
+		if ev.values == nil {
+			ev.values = make([]sobek.Value, n)
+		}
+		ev.values[idx] = v
+	}
+	return v
+}
+
+// length returns the length of an array of n decoded elements.
+func (ev *elementValues) length(n int) int {
+	if ev.copied {
+		return len(ev.values)
+	}
+	return n
+}
+
+// set writes the element at idx, copying the n decoded elements first.
+func (ev *elementValues) set(idx int, v sobek.Value, n int, convert func(int) sobek.Value) bool {
+	if idx < 0 {
+		return false
+	}
+	ev.copy(n, convert)
+	if idx >= len(ev.values) {
+		ev.values = append(ev.values, make([]sobek.Value, idx+1-len(ev.values))...)
+	}
+	ev.values[idx] = v
+	return true
+}
+
+// setLen sets the length of the array, copying the n decoded elements first.
+func (ev *elementValues) setLen(length, n int, convert func(int) sobek.Value) bool {
+	if length < 0 {
+		return false
+	}
+	ev.copy(n, convert)
+	if length <= len(ev.values) {
+		ev.values = ev.values[:length]
+	} else {
+		ev.values = append(ev.values, make([]sobek.Value, length-len(ev.values))...)
+	}
+	return true
+}
+
+// copy converts every element into the view, which then owns its values.
+func (ev *elementValues) copy(n int, convert func(int) sobek.Value) {
+	if ev.copied {
+		return
+	}
+	values := make([]sobek.Value, n)
+	for i := range values {
+		values[i] = ev.get(i, n, convert)
+	}
+	ev.values, ev.copied = values, true
+}
+
+// arrayView is a JS array backed by a decoded list, copied on the first
+// write.
+type arrayView struct {
+	e        *exporter
+	elements []interface{}
+	order    *fieldOrder // order of the elements
+	elementValues
+}
+
+func (a *arrayView) convert(idx int) sobek.Value {
+	return a.e.ordered(a.elements[idx], a.order)
+}
+
+func (a *arrayView) Len() int { return a.length(len(a.elements)) }
+
+func (a *arrayView) Get(idx int) sobek.Value {
+	return a.get(idx, len(a.elements), a.convert)
+}
+
+func (a *arrayView) Set(idx int, v sobek.Value) bool {
+	return a.set(idx, v, len(a.elements), a.convert)
+}
+
+func (a *arrayView) SetLen(length int) bool {
+	return a.setLen(length, len(a.elements), a.convert)
+}
+
+// rowsView is a JS array backed by decoded rows, copied on the first write.
+type rowsView struct {
+	e     *exporter
+	rows  []map[string]interface{}
+	order *fieldOrder // order of the rows
+	elementValues
+}
+
+func (r *rowsView) convert(idx int) sobek.Value {
+	return r.e.ordered(r.rows[idx], r.order)
+}
+
+func (r *rowsView) Len() int { return r.length(len(r.rows)) }
+
+func (r *rowsView) Get(idx int) sobek.Value {
+	return r.get(idx, len(r.rows), r.convert)
+}
+
+func (r *rowsView) Set(idx int, v sobek.Value) bool {
+	return r.set(idx, v, len(r.rows), r.convert)
+}
+
+func (r *rowsView) SetLen(length int) bool {
+	return r.setLen(length, len(r.rows), r.convert)
+}
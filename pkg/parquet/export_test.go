@@ -0,0 +1,368 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"go.k6.io/k6/js/modulestest"
+)
+
+func TestExporter(t *testing.T) {
+	rt := sobek.New()
+	ts := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	rows := []map[string]interface{}{
+		{"id": int64(1), "created": ts, "tags": []interface{}{"a", "b"}},
+		{"id": int64(2), "created": nil, "tags": []interface{}{}},
+	}
+
+	e := &exporter{rt: rt, int64Mode: int64Number}
+	if err := rt.Set("rows", e.value(rows)); err != nil {
+		t.Fatalf("failed to set rows: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		script   string
+		expected interface{}
+	}{
+		{name: "length", script: "rows.length", expected: int64(2)},
+		{name: "is array", script: "Array.isArray(rows)", expected: true},
+		{name: "field", script: "rows[1].id", expected: int64(2)},
+		{name: "date", script: "rows[0].created instanceof Date && rows[0].created.toISOString()", expected: "2026-01-02T03:04:05.000Z"},
+		{name: "nested array", script: "rows[0].tags.join(',')", expected: "a,b"},
+		{name: "array methods", script: "rows.map(r => r.id).join(',')", expected: "1,2"},
+		{name: "keys", script: "Object.keys(rows[0]).join(',')", expected: "created,id,tags"},
+		{name: "spread copy", script: "({...rows[0], id: 9}).id", expected: int64(9)},
+		{name: "json", script: "JSON.stringify(rows[1])", expected: `{"created":null,"id":2,"tags":[]}`},
+		{name: "identity", script: "rows[0] === rows[0] && rows[0].tags === rows[0].tags", expected: true},
+		{
+			name:     "copy on write",
+			script:   "'use strict'; rows[0].id = 5; rows[0].extra = 'x'; [rows[0].id, rows[0].extra, Object.keys(rows[0])].join(';')",
+			expected: "5;x;created,id,tags,extra",
+		},
+		{name: "delete", script: "'use strict'; delete rows[1].tags; Object.keys(rows[1]).join(',')", expected: "created,id"},
+		{
+			name:     "array writes",
+			script:   "'use strict'; rows[0].tags.push('c'); rows.push({ id: 3 }); [rows[0].tags.join(''), rows.length, rows[2].id].join(',')",
+			expected: "abc,3,3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := rt.RunString(tt.script)
+			if err != nil {
+				t.Fatalf("script error: %v", err)
+			}
+			if got := v.Export(); got != tt.expected {
+				t.Errorf("%s = %v (%T), want %v (%T)", tt.script, got, got, tt.expected, tt.expected)
+			}
+		})
+	}
+
+	if len(rows) != 2 || rows[0]["id"] != int64(1) || len(rows[0]["tags"].([]interface{})) != 2 || len(rows[1]) != 3 {
+		t.Errorf("expected decoded rows to be left unchanged, got %v", rows)
+	}
+}
+
+func TestExportInt64Mode(t *testing.T) {
+	const unsafe = int64(1)<<60 + 1
+	rt := sobek.New()
+	row := map[string]interface{}{"small": int64(42), "big": unsafe}
+
+	tests := []struct {
+		mode     string
+		expected string
+		lossy    bool
+	}{
+		{mode: int64Number, expected: "number,number", lossy: true},
+		{mode: int64BigInt, expected: "bigint,bigint:1152921504606846977"},
+		{mode: int64String, expected: "string,string:1152921504606846977"},
+		{mode: int64Auto, expected: "number,bigint:1152921504606846977"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			var lossy []interface{}
+			e := &exporter{rt: rt, int64Mode: tt.mode, lossy: func(v interface{}) { lossy = append(lossy, v) }}
+			if err := rt.Set("row", e.value(row)); err != nil {
+				t.Fatalf("failed to set row: %v", err)
+			}
+
+			v, err := rt.RunString(`[typeof row.small, typeof row.big].join(',') +
+				(typeof row.big === 'number' ? '' : ':' + String(row.big))`)
+			if err != nil {
+				t.Fatalf("script error: %v", err)
+			}
+			if got := v.String(); got != tt.expected {
+				t.Errorf("got %s, want %s", got, tt.expected)
+			}
+			if tt.lossy != (len(lossy) > 0 && lossy[0] == unsafe) {
+				t.Errorf("unexpected precision loss reports %v", lossy)
+			}
+		})
+	}
+
+	t.Run("Unsigned values beyond int64", func(t *testing.T) {
+		const huge = uint64(1)<<63 + 1
+		expected := map[string]string{
+			int64Number: "number",
+			int64BigInt: "bigint:9223372036854775809",
+			int64String: "string:9223372036854775809",
+			int64Auto:   "bigint:9223372036854775809",
+		}
+		for mode, want := range expected {
+			var lossy []interface{}
+			e := &exporter{rt: rt, int64Mode: mode, lossy: func(v interface{}) { lossy = append(lossy, v) }}
+			if err := rt.Set("value", e.value(huge)); err != nil {
+				t.Fatalf("failed to set value: %v", err)
+			}
+			v, err := rt.RunString(`typeof value + (typeof value === 'number' ? '' : ':' + String(value))`)
+			if err != nil {
+				t.Fatalf("script error: %v", err)
+			}
+			if got := v.String(); got != want {
+				t.Errorf("%s: got %s, want %s", mode, got, want)
+			}
+			if (mode == int64Number) != (len(lossy) == 1 && lossy[0] == huge) {
+				t.Errorf("%s: unexpected precision loss reports %v", mode, lossy)
+			}
+		}
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		if _, err := parseInt64Mode(map[string]interface{}{"int64Mode": "float"}); err == nil {
+			t.Error("expected an error for an invalid mode")
+		}
+		if _, err := parseReadOptions(map[string]interface{}{"int64Mode": 64}); err == nil {
+			t.Error("expected read options to reject an invalid mode")
+		}
+	})
+}
+
+func TestReadInt64ModeJS(t *testing.T) {
+	type IDRow struct {
+		ID int64 `parquet:"id"`
+	}
+	filename := filepath.Join(t.TempDir(), "ids.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	writer := parquet.NewGenericWriter[IDRow](file)
+	if _, err := writer.Write([]IDRow{{ID: 1}, {ID: 1<<62 + 3}}); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	result := runAsync(t, filename, `
+		const opts = { int64Mode: 'auto' };
+		const it = parquet.iterate(filename, { int64Mode: 'string' });
+		const cursor = parquet.cursor(filename, opts);
+		cursor.next();
+		Promise.all([
+			parquet.readAsync(filename, { int64Mode: 'bigint' }),
+		]).then(([async]) => {
+			result = [
+				typeof parquet.read(filename, opts)[0].id,
+				parquet.read(filename, opts)[1].id === 4611686018427387907n,
+				parquet.open('ids', filename, opts)[1].id === 4611686018427387907n,
+				cursor.next().id === 4611686018427387907n,
+				it.next().value.id,
+				typeof async[0].id,
+				parquet.readColumns(filename, ['id'], { int64Mode: 'bigint' }).id instanceof BigInt64Array,
+				parquet.readColumns(filename, ['id']).id instanceof Float64Array,
+				parquet.getMetadata(filename, { int64Mode: 'bigint' }).rowGroups[0].columns[0].statistics.max === 4611686018427387907n,
+			].join(',');
+		});
+	`)
+	if result != "number,true,true,true,1,bigint,true,true,true" {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestReadBinaryJS(t *testing.T) {
+	type BlobRow struct {
+		Name string `parquet:"name"`
+		Body []byte `parquet:"body"`
+	}
+	filename := filepath.Join(t.TempDir(), "blobs.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	writer := parquet.NewGenericWriter[BlobRow](file)
+	rows := []BlobRow{{Name: "a", Body: []byte{0xff, 0x00, 0x80}}, {Name: "b", Body: []byte("plain")}}
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	result := runAsync(t, filename, `
+		const rows = parquet.read(filename);
+		const body = new Uint8Array(rows[0].body);
+		body[0] = 0;
+		result = [
+			typeof rows[0].name,
+			rows[0].body instanceof ArrayBuffer,
+			Array.from(body).join(' '),
+			new Uint8Array(rows[0].body)[0],
+			new Uint8Array(parquet.read(filename)[0].body)[0],
+			parquet.read(filename, { binary: 'hex' })[0].body,
+			parquet.read(filename, { binary: 'base64' })[0].body,
+			parquet.read(filename, { binary: 'hex', filter: "body = 'plain'" })[0].name,
+		].join(',');
+	`)
+	if result != "string,true,0 0 128,0,255,ff0080,/wCA,b" {
+		t.Errorf("unexpected result: %s", result)
+	}
+
+	if _, err := parseReadOptions(map[string]interface{}{"binary": "utf8"}); err == nil {
+		t.Error("expected an error for an invalid binary option")
+	}
+}
+
+func TestReadKeyOrderJS(t *testing.T) {
+	type Point struct {
+		Yankee int32 `parquet:"yankee"`
+		Bravo  int32 `parquet:"bravo"`
+	}
+	type OrderRow struct {
+		Zulu  string `parquet:"zulu"`
+		Alpha int32  `parquet:"alpha"`
+		Point Point  `parquet:"point"`
+		Mike  bool   `parquet:"mike"`
+	}
+	filename := filepath.Join(t.TempDir(), "order.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	writer := parquet.NewGenericWriter[OrderRow](file)
+	if _, err := writer.Write([]OrderRow{{Zulu: "z", Alpha: 1, Point: Point{Yankee: 2, Bravo: 3}, Mike: true}}); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	result := runAsync(t, filename, `
+		const row = parquet.read(filename)[0];
+		result = [
+			Object.keys(row).join(' '),
+			Object.keys(row.point).join(' '),
+			JSON.stringify(row),
+			Object.keys(parquet.read(filename, { columns: ['mike', 'point.bravo', 'zulu'] })[0]).join(' '),
+		].join('|');
+	`)
+	expected := `zulu alpha point mike|yankee bravo|{"zulu":"z","alpha":1,"point":{"yankee":2,"bravo":3},"mike":true}|zulu point mike`
+	if result != expected {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestReadKeyOrderPerFileJS(t *testing.T) {
+	type Coords struct {
+		Zulu   int32 `parquet:"zulu"`
+		Yankee int32 `parquet:"yankee"`
+	}
+	type FirstRow struct {
+		Beta   string            `parquet:"beta"`
+		Alpha  string            `parquet:"alpha"`
+		Places map[string]Coords `parquet:"places"`
+	}
+	type SecondRow struct {
+		Alpha string `parquet:"alpha"`
+		Beta  string `parquet:"beta"`
+	}
+
+	dir := t.TempDir()
+	writeFile := func(name string, write func(*os.File) error) {
+		file, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		defer file.Close()
+		if err := write(file); err != nil {
+			t.Fatalf("failed to write test data: %v", err)
+		}
+	}
+	writeFile("first.parquet", func(file *os.File) error {
+		writer := parquet.NewGenericWriter[FirstRow](file)
+		row := FirstRow{Beta: "b", Alpha: "a", Places: map[string]Coords{"home": {Zulu: 1, Yankee: 2}}}
+		if _, err := writer.Write([]FirstRow{row}); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+	writeFile("second.parquet", func(file *os.File) error {
+		writer := parquet.NewGenericWriter[SecondRow](file)
+		if _, err := writer.Write([]SecondRow{{Alpha: "a", Beta: "b"}}); err != nil {
+			return err
+		}
+		return writer.Close()
+	})
+
+	// Rows with the same keys are ordered by the schema of their own file,
+	// whatever was read before.
+	result := runAsync(t, dir, `
+		const first = parquet.read(filename + '/first.parquet')[0];
+		const second = parquet.read(filename + '/second.parquet')[0];
+		let chunked;
+		parquet.readChunked(filename + '/second.parquet', 10, (chunk) => { chunked = chunk[0]; });
+		result = [
+			Object.keys(first).join(' '),
+			Object.keys(first.places.home).join(' '),
+			Object.keys(second).join(' '),
+			Object.keys(chunked).join(' '),
+			Object.keys(parquet.iterate(filename + '/first.parquet').next().value).join(' '),
+		].join('|');
+	`)
+	expected := `beta alpha places|zulu yankee|alpha beta|alpha beta|beta alpha places`
+	if result != expected {
+		t.Errorf("unexpected result: %s", result)
+	}
+}
+
+func TestReadCopyOnWriteJS(t *testing.T) {
+	filename := createTestParquetFile(t)
+
+	runtime := modulestest.NewRuntime(t)
+	p := New().NewModuleInstance(runtime.VU)
+	rt := runtime.VU.Runtime()
+	if err := rt.Set("parquet", p.Exports().Named); err != nil {
+		t.Fatalf("failed to set exports: %v", err)
+	}
+	if err := rt.Set("filename", filename); err != nil {
+		t.Fatalf("failed to set filename: %v", err)
+	}
+
+	// Both reads are served by the same cached rows: writes to the rows of
+	// one read are not seen by the other.
+	v, err := rt.RunString(`
+		'use strict';
+		const first = parquet.read(filename);
+		first[0].name = 'changed';
+		first.length = 1;
+		const second = parquet.read(filename);
+		[first[0].name, first.length, second[0].name, second.length > 1, first[0] === first[0]].join(',');
+	`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	if v.String() != "changed,1,Alice,true,true" {
+		t.Errorf("unexpected result: %s", v)
+	}
+}
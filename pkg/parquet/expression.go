@@ -0,0 +1,331 @@
+package parquet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// tokenKind identifies the kind of a filter expression token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenString
+	tokenNumber
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenComma
+)
+
+// token is a lexical token of a filter expression.
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// expressionParser is a recursive descent parser for filter expressions:
+//
+//	expr       = and { ("OR" | "||") and }
+//	and        = unary { ("AND" | "&&") unary }
+//	unary      = ("NOT" | "!") unary | "(" expr ")" | comparison
+//	comparison = column op literal
+//	           | column ["NOT"] "IN" "(" literal { "," literal } ")"
+//	           | column "IS" ["NOT"] "NULL"
+//	literal    = string | number | "true" | "false" | "null"
+//
+// Columns are identifiers, optionally dotted to reach nested fields, or
+// quoted with backticks.
+type expressionParser struct {
+	tokens []token
+	pos    int
+}
+
+// parseFilterExpression parses a filter expression such as
+// "status == 'active' AND age > 30".
+func parseFilterExpression(input string) (predicate, error) {
+	tokens, err := tokenize(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &expressionParser{tokens: tokens}
+	pred, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.errorf(t, "unexpected %q", t.text)
+	}
+	return pred, nil
+}
+
+func (p *expressionParser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *expressionParser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// keyword reports whether the next token is the given keyword or symbol,
+// consuming it if so.
+func (p *expressionParser) keyword(words ...string) bool {
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenOperator {
+		return false
+	}
+	for _, w := range words {
+		if strings.EqualFold(t.text, w) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *expressionParser) errorf(t token, format string, args ...interface{}) error {
+	return fmt.Errorf("invalid filter expression at position %d: %s", t.pos+1, fmt.Sprintf(format, args...))
+}
+
+func (p *expressionParser) parseOr() (predicate, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	operands := []predicate{left}
+	for p.keyword("OR", "||") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return orPredicate(operands), nil
+}
+
+func (p *expressionParser) parseAnd() (predicate, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	operands := []predicate{left}
+	for p.keyword("AND", "&&") {
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, right)
+	}
+	if len(operands) == 1 {
+		return left, nil
+	}
+	return andPredicate(operands), nil
+}
+
+func (p *expressionParser) parseUnary() (predicate, error) {
+	if p.keyword("NOT", "!") {
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notPredicate{operand}, nil
+	}
+
+	if p.peek().kind == tokenLParen {
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokenRParen {
+			return nil, p.errorf(t, "expected \")\"")
+		}
+		return inner, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *expressionParser) parseComparison() (predicate, error) {
+	column := p.next()
+	if column.kind != tokenIdent {
+		return nil, p.errorf(column, "expected a column name")
+	}
+
+	if p.keyword("IS") {
+		op := opIsNull
+		if p.keyword("NOT") {
+			op = opNotNull
+		}
+		if !p.keyword("NULL") {
+			return nil, p.errorf(p.peek(), "expected NULL")
+		}
+		return newComparison(column.text, op, nil)
+	}
+
+	negate := p.keyword("NOT")
+	if p.keyword("IN") {
+		values, err := p.parseList()
+		if err != nil {
+			return nil, err
+		}
+		c, err := newComparison(column.text, opIn, values)
+		if err != nil || !negate {
+			return c, err
+		}
+		return notPredicate{c}, nil
+	}
+	if negate {
+		return nil, p.errorf(p.peek(), "expected IN")
+	}
+
+	t := p.next()
+	op, ok := operatorAliases[t.text]
+	if t.kind != tokenOperator || !ok {
+		return nil, p.errorf(t, "expected a comparison operator")
+	}
+	value, err := p.parseLiteral()
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(column.text, op, value)
+}
+
+func (p *expressionParser) parseList() ([]interface{}, error) {
+	if t := p.next(); t.kind != tokenLParen {
+		return nil, p.errorf(t, "expected \"(\"")
+	}
+	var values []interface{}
+	for {
+		value, err := p.parseLiteral()
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+
+		t := p.next()
+		if t.kind == tokenRParen {
+			return values, nil
+		}
+		if t.kind != tokenComma {
+			return nil, p.errorf(t, "expected \",\" or \")\"")
+		}
+	}
+}
+
+func (p *expressionParser) parseLiteral() (interface{}, error) {
+	t := p.next()
+	switch t.kind {
+	case tokenString:
+		return t.text, nil
+	case tokenNumber:
+		if n, err := strconv.ParseInt(t.text, 10, 64); err == nil {
+			return n, nil
+		}
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, p.errorf(t, "invalid number %q", t.text)
+		}
+		return f, nil
+	case tokenIdent:
+		switch strings.ToLower(t.text) {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		}
+	}
+	return nil, p.errorf(t, "expected a literal value")
+}
+
+// tokenize splits a filter expression into tokens.
+func tokenize(input string) ([]token, error) {
+	var tokens []token
+	runes := []rune(input)
+
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+
+		case r == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case r == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+
+		case r == '\'' || r == '"' || r == '`':
+			start := i
+			var sb strings.Builder
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("invalid filter expression at position %d: unterminated quote", start+1)
+			}
+			i++
+			kind := tokenString
+			if r == '`' {
+				kind = tokenIdent
+			}
+			tokens = append(tokens, token{kind, sb.String(), start})
+
+		case unicode.IsDigit(r) || (r == '-' || r == '.') && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			start := i
+			i++
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || strings.ContainsRune(".eE+-", runes[i])) {
+				if (runes[i] == '+' || runes[i] == '-') && runes[i-1] != 'e' && runes[i-1] != 'E' {
+					break
+				}
+				i++
+			}
+			tokens = append(tokens, token{tokenNumber, string(runes[start:i]), start})
+
+		case unicode.IsLetter(r) || r == '_' || r == '$':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) || strings.ContainsRune("_$.", runes[i])) {
+				i++
+			}
+			tokens = append(tokens, token{tokenIdent, string(runes[start:i]), start})
+
+		default:
+			start := i
+			for _, op := range []string{"==", "!=", "<>", "<=", ">=", "&&", "||", "=", "<", ">", "!"} {
+				if strings.HasPrefix(string(runes[i:]), op) {
+					tokens = append(tokens, token{tokenOperator, op, start})
+					i += len(op)
+					break
+				}
+			}
+			if i == start {
+				return nil, fmt.Errorf("invalid filter expression at position %d: unexpected %q", start+1, r)
+			}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(runes)}), nil
+}
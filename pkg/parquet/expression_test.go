@@ -0,0 +1,55 @@
+package parquet
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseFilterExpression(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"status == 'active'", `status == "active"`},
+		{`status = "active"`, `status == "active"`},
+		{"age > 30 AND status != 'banned'", `(age > 30 AND status != "banned")`},
+		{"age >= 18 && age < 65 || vip == true", `((age >= 18 AND age < 65) OR vip == true)`},
+		{"age >= 18 and (age < 65 or vip = true)", `(age >= 18 AND (age < 65 OR vip == true))`},
+		{"NOT deleted", ""},
+		{"!(score <= -1.5e2)", `NOT (score <= -150)`},
+		{"country IN ('FR', 'DE')", `country IN ("FR", "DE")`},
+		{"country NOT IN ('FR')", `NOT (country IN ("FR"))`},
+		{"email IS NULL", "email IS NULL"},
+		{"email is not null", "email IS NOT NULL"},
+		{"email == null", "email IS NULL"},
+		{"address.city <> 'Paris'", `address.city != "Paris"`},
+		{"`first name` == 'O\\'Brien'", `first name == "O'Brien"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			pred, err := parseFilterExpression(tt.input)
+			if tt.expected == "" {
+				if err == nil {
+					t.Fatalf("expected error, got %s", pred)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseFilterExpression() error = %v", err)
+			}
+			if pred.String() != tt.expected {
+				t.Errorf("expected %s, got %s", tt.expected, pred)
+			}
+		})
+	}
+
+	t.Run("Errors report the position", func(t *testing.T) {
+		for _, input := range []string{"age >", "age > 1 AND", "(age > 1", "age ~ 1", "'age' > 1", "name == 'x", "age IS 1"} {
+			_, err := parseFilterExpression(input)
+			if err == nil || !strings.Contains(err.Error(), "invalid filter expression") {
+				t.Errorf("%q: expected invalid filter expression error, got %v", input, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,133 @@
+package parquet
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/spf13/afero"
+	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/lib/fsext"
+)
+
+// osFS is the file system files are read from outside of a k6 test.
+var osFS = fsext.NewOsFs()
+
+// fileSystem holds the file system of the init environment of a VU, through
+// which Parquet files are read like the files of open(): relative paths
+// resolve against the directory of the script, and the files opened in the
+// init context are included in the archives created by k6 archive and k6
+// cloud.
+type fileSystem struct {
+	fs  fsext.Fs
+	cwd string
+
+	// archive is the file system of k6 that files opened in the init context
+	// are registered with, when the files are read from the disk instead.
+	archive fsext.Fs
+	vu      modules.VU
+}
+
+// newFileSystem returns the file system of the init environment of a VU, or
+// the OS file system with paths left unchanged when there is none.
+//
+// Outside of archives, the "file" file system of k6 caches the whole files it
+// opens in memory and only allows the files opened in the init context once
+// the init context is done. Files are then read from the disk, which keeps
+// the streaming reads of large files cheap, and only registered with k6 when
+// opened in the init context, so that they are bundled into archives.
+func newFileSystem(vu modules.VU) *fileSystem {
+	env := vu.InitEnv()
+	if env == nil {
+		return &fileSystem{fs: osFS}
+	}
+	fs, ok := env.FileSystems["file"]
+	if !ok {
+		return &fileSystem{fs: osFS}
+	}
+
+	f := &fileSystem{fs: fs}
+	if env.CWD != nil {
+		f.cwd = env.CWD.Path
+	}
+	if _, ok := fs.(fsext.CacheLayerGetter); ok {
+		f.fs, f.archive, f.vu = osFS, fs, vu
+	}
+	return f
+}
+
+// resolve returns the path a file is read from.
+func (f *fileSystem) resolve(filename string) string {
+	filename = strings.TrimPrefix(filename, "file://")
+	if f == nil || f.cwd == "" || filename == "" {
+		return filename
+	}
+	return fsext.Abs(f.cwd, filename)
+}
+
+// open opens a Parquet file at a resolved path.
+func (f *fileSystem) open(filename string) (afero.File, *parquet.File, error) {
+	if f == nil {
+		return openParquetFile(osFS, filename)
+	}
+	f.register(filename)
+	return openParquetFile(f.fs, filename)
+}
+
+// register opens a file through the file system of k6 in the init context,
+// which bundles it into archives. Errors are left to the actual read.
+func (f *fileSystem) register(filename string) {
+	if f.archive == nil || f.vu.State() != nil {
+		return
+	}
+	if file, err := f.archive.Open(filename); err == nil {
+		file.Close()
+	}
+}
+
+// openParquetFile opens a Parquet file from a file system. The caller must
+// close the returned file once done with the Parquet file.
+func openParquetFile(fs fsext.Fs, filename string) (afero.File, *parquet.File, error) {
+	file, err := fs.Open(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+
+	stat, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to stat file: %w", err)
+	}
+	if stat.IsDir() {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open file: %s is a directory", filename)
+	}
+
+	// The in-memory files of the k6 file systems keep a single read offset,
+	// so concurrent reads of a shared file must be serialized.
+	if _, ok := file.(*os.File); !ok {
+		file = &lockedFile{File: file}
+	}
+
+	pf, err := parquet.OpenFile(file, stat.Size())
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("failed to open parquet file: %w", err)
+	}
+
+	return file, pf, nil
+}
+
+// lockedFile serializes the positional reads of a file.
+type lockedFile struct {
+	afero.File
+	mu sync.Mutex
+}
+
+func (f *lockedFile) ReadAt(b []byte, off int64) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.File.ReadAt(b, off)
+}
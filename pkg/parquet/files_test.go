@@ -0,0 +1,168 @@
+package parquet
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/fsext"
+)
+
+func TestFileSystemResolve(t *testing.T) {
+	files := &fileSystem{fs: osFS, cwd: "/scripts/"}
+
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"data.parquet", "/scripts/data.parquet"},
+		{"./data/../users.parquet", "/scripts/users.parquet"},
+		{"/data/users.parquet", "/data/users.parquet"},
+		{"file:///data/users.parquet", "/data/users.parquet"},
+	}
+	for _, tt := range tests {
+		if got := files.resolve(tt.filename); got != tt.expected {
+			t.Errorf("resolve(%q) = %q, expected %q", tt.filename, got, tt.expected)
+		}
+	}
+
+	var outside *fileSystem
+	if got := outside.resolve("data.parquet"); got != "data.parquet" {
+		t.Errorf("expected paths to be unchanged outside of a k6 test, got %q", got)
+	}
+}
+
+func TestReadThroughInitFileSystem(t *testing.T) {
+	data, err := os.ReadFile(createRowGroupsParquetFile(t, 3000, 700))
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+
+	// Like k6, open files through a cache layer over the disk, which k6
+	// archive bundles.
+	dir := filepath.ToSlash(t.TempDir())
+	if err := osFS.MkdirAll(dir+"/data", 0o755); err != nil {
+		t.Fatalf("failed to create test directory: %v", err)
+	}
+	for _, name := range []string{"data/rows.parquet", "late.parquet"} {
+		if err := fsext.WriteFile(osFS, dir+"/"+name, data, 0o644); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+	}
+	layer := fsext.NewMemMapFs()
+	fs := fsext.NewCacheOnReadFs(osFS, layer, 0)
+
+	runtime := modulestest.NewRuntime(t)
+	runtime.VU.InitEnvField.FileSystems = map[string]fsext.Fs{"file": fs}
+	runtime.VU.InitEnvField.CWD = &url.URL{Scheme: "file", Path: dir + "/"}
+	p, ok := New().NewModuleInstance(runtime.VU).(*Parquet)
+	if !ok {
+		t.Fatal("expected a *Parquet instance")
+	}
+
+	t.Run("Paths resolve against the script", func(t *testing.T) {
+		rows, err := p.Read("data/rows.parquet", map[string]interface{}{"rowLimit": 10})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 10 {
+			t.Errorf("expected 10 rows, got %d", len(rows))
+		}
+
+		metadata, err := p.GetMetadata("./data/rows.parquet")
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		if metadata["size"] != int64(len(data)) {
+			t.Errorf("expected size %d, got %v", len(data), metadata["size"])
+		}
+
+		if _, err := p.GetSchema("rows.parquet"); err == nil {
+			t.Error("expected an error for a path relative to the working directory")
+		}
+		if schema, err := p.GetSchema("data"); err != nil || schemaField(schema, "id") == nil {
+			t.Errorf("expected the schema of the directory, got %v, %v", schema, err)
+		}
+	})
+
+	t.Run("Files are cached for archives", func(t *testing.T) {
+		if exists, _ := fsext.Exists(layer, dir+"/data/rows.parquet"); !exists {
+			t.Error("expected the file to be in the cache layer")
+		}
+	})
+
+	t.Run("Files are read from the disk after the init context", func(t *testing.T) {
+		onlyCached, ok := fs.(fsext.OnlyCachedEnabler)
+		if !ok {
+			t.Fatal("expected a file system which allows only cached files")
+		}
+		onlyCached.AllowOnlyCached()
+		runtime.MoveToVUContext(&lib.State{})
+
+		rows, err := p.Read("late.parquet", map[string]interface{}{"rowLimit": 10})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 10 {
+			t.Errorf("expected 10 rows, got %d", len(rows))
+		}
+		if _, err := p.GetSchema("data"); err != nil {
+			t.Errorf("GetSchema() error = %v", err)
+		}
+		if exists, _ := fsext.Exists(layer, dir+"/late.parquet"); exists {
+			t.Error("expected the file not to be copied into the cache layer")
+		}
+	})
+
+	t.Run("Shared files are read concurrently", func(t *testing.T) {
+		opts, _ := parseReadOptions()
+		ds, err := openDataset(p.files, "rows", p.files.resolve("data/rows.parquet"), opts)
+		if err != nil {
+			t.Fatalf("openDataset() error = %v", err)
+		}
+
+		var wg sync.WaitGroup
+		for i := int64(0); i < 4; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := i * 700; j < 3000; j += 97 {
+					row, err := ds.Row(j)
+					if err != nil {
+						t.Errorf("Row() error = %v", err)
+						return
+					}
+					if row["id"] != j {
+						t.Errorf("expected row %d, got %v", j, row["id"])
+					}
+				}
+			}()
+		}
+		wg.Wait()
+	})
+
+	t.Run("Written paths resolve against the script", func(t *testing.T) {
+		w, err := p.Writer("data/written.parquet", map[string]interface{}{"id": "int64"})
+		if err != nil {
+			t.Fatalf("Writer() error = %v", err)
+		}
+		if err := w.Write(map[string]interface{}{"id": int64(1)}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		if exists, _ := fsext.Exists(osFS, dir+"/data/written.parquet"); !exists {
+			t.Error("expected the file to be written next to the script")
+		}
+		rows, err := p.Read("data/written.parquet")
+		if err != nil || len(rows) != 1 {
+			t.Errorf("expected the written row, got %v, %v", rows, err)
+		}
+	})
+}
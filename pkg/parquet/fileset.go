@@ -0,0 +1,319 @@
+package parquet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/spf13/afero"
+)
+
+// fileSet is the set of Parquet files read as one logical dataset: a single
+// file, the files matching a glob pattern, or the Parquet files of a
+// directory tree. Files are ordered by path, so that rows are read in a
+// stable order.
+type fileSet struct {
+	paths []string
+	files []afero.File
+	pfs   []*parquet.File
+
+	// schema is the unified schema of the files, which is the schema of the
+	// file for a single file.
+	schema *parquet.Schema
+
+	// partitions holds the partition columns of Hive style directories, nil
+	// when the files have none.
+	partitions *hivePartitions
+}
+
+// expand returns the files of a dataset path, in lexical order. Glob
+// patterns match files with the syntax of filepath.Match, and directories
+// are walked for .parquet files, ignoring the hidden files and directories
+// whose name starts with "." or "_", such as _SUCCESS markers. Any other
+// path is returned as is.
+func (f *fileSystem) expand(path string) ([]string, error) {
+	fs := osFS
+	if f != nil {
+		fs = f.fs
+	}
+
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := afero.Glob(fs, path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid glob pattern %q: %w", path, err)
+		}
+		var paths []string
+		for _, match := range matches {
+			if info, err := fs.Stat(match); err == nil && !info.IsDir() {
+				paths = append(paths, match)
+			}
+		}
+		if len(paths) == 0 {
+			return nil, fmt.Errorf("no files match %q", path)
+		}
+		sort.Strings(paths)
+		return paths, nil
+	}
+
+	info, err := fs.Stat(path)
+	if err != nil || !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var paths []string
+	err = afero.Walk(fs, path, func(name string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		hidden := name != path && (strings.HasPrefix(info.Name(), ".") || strings.HasPrefix(info.Name(), "_"))
+		switch {
+		case info.IsDir() && hidden:
+			return filepath.SkipDir
+		case !info.IsDir() && !hidden && strings.EqualFold(filepath.Ext(name), ".parquet"):
+			paths = append(paths, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list directory %q: %w", path, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no Parquet files in directory %q", path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// openSet opens the files of a dataset path and unifies their schemas. The
+// key=value directories of the files are read as partition columns, and the
+// files whose partition values cannot match the filter, if any, are removed
+// from the set once the filter has been checked against the schema of all
+// the files, so that the schema does not depend on the filter and unknown
+// columns are reported even when no file is left. The caller must close the
+// returned set.
+func (f *fileSystem) openSet(path string, filter predicate) (*fileSet, error) {
+	paths, err := f.expand(path)
+	if err != nil {
+		return nil, err
+	}
+
+	set := &fileSet{paths: paths, partitions: parseHivePartitions(datasetRoot(path), paths)}
+	for _, name := range set.paths {
+		file, pf, err := f.open(name)
+		if err != nil {
+			set.Close()
+			if len(paths) > 1 {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			return nil, err
+		}
+		set.files = append(set.files, file)
+		set.pfs = append(set.pfs, pf)
+	}
+
+	if len(set.paths) == 1 && set.partitions == nil {
+		set.schema = set.pfs[0].Schema()
+		return set, nil
+	}
+	if set.schema, err = set.unifySchemas(); err != nil {
+		set.Close()
+		return nil, err
+	}
+	if set.partitions != nil && filter != nil {
+		if err := validateFilter(filter, set.schema); err != nil {
+			set.Close()
+			return nil, err
+		}
+		set.prune(filter)
+	}
+	return set, nil
+}
+
+// prune closes and removes the files whose partition values cannot match
+// the filter.
+func (s *fileSet) prune(filter predicate) {
+	kept := s.partitions.prune(filter)
+	paths := make([]string, len(kept))
+	files := make([]afero.File, len(kept))
+	pfs := make([]*parquet.File, len(kept))
+	values := make([]map[string]interface{}, len(kept))
+	for i, index := range kept {
+		paths[i] = s.paths[index]
+		files[i], s.files[index] = s.files[index], nil
+		pfs[i] = s.pfs[index]
+		values[i] = s.partitions.values[index]
+	}
+	for _, file := range s.files {
+		if file != nil {
+			file.Close()
+		}
+	}
+	s.paths, s.files, s.pfs = paths, files, pfs
+	s.partitions.values = values
+}
+
+// Close closes the files of the set.
+func (s *fileSet) Close() {
+	for _, file := range s.files {
+		file.Close()
+	}
+}
+
+// unifySchemas returns the union of the top-level fields of the files. A
+// field present in several files must have the same type in all of them,
+// regardless of whether it is optional; it is optional in the unified schema
+// when it is optional in any file or missing from some. Fields are in the
+// order in which they first appear in the files. Partition columns are added
+// last as optional fields, and must not be columns of the files.
+func (s *fileSet) unifySchemas() (*parquet.Schema, error) {
+	fields := orderedGroup{}
+	definedBy := make(map[string]string)
+	counts := make(map[string]int)
+
+	for i, pf := range s.pfs {
+		for _, field := range pf.Schema().Fields() {
+			name := field.Name()
+			counts[name]++
+
+			existing := fields.field(name)
+			if existing == nil {
+				fields.set(name, field)
+				definedBy[name] = s.paths[i]
+				continue
+			}
+			if !compatibleNodes(existing, field) {
+				return nil, fmt.Errorf("schema mismatch: column %q of %s differs from %s", name, s.paths[i], definedBy[name])
+			}
+			if field.Optional() && !existing.Optional() {
+				fields.set(name, field)
+			}
+		}
+	}
+
+	for _, field := range fields {
+		if counts[field.Name()] < len(s.pfs) && !field.Optional() && !field.Repeated() {
+			fields.set(field.Name(), parquet.Optional(field))
+		}
+	}
+
+	if s.partitions != nil {
+		for _, field := range s.partitions.fields() {
+			name := field.Name()
+			if fields.field(name) != nil {
+				return nil, fmt.Errorf("partition column %q conflicts with a column of %s", name, definedBy[name])
+			}
+			fields.set(name, field)
+		}
+	}
+	return parquet.NewSchema(s.pfs[0].Schema().Name(), fields), nil
+}
+
+// compatibleNodes reports whether two fields have the same type, ignoring
+// whether they are optional.
+func compatibleNodes(a, b parquet.Node) bool {
+	if a.Repeated() || b.Repeated() {
+		return parquet.EqualNodes(a, b)
+	}
+	return parquet.EqualNodes(parquet.Optional(a), parquet.Optional(b))
+}
+
+// scan calls emit with each row of the set selected by the read options,
+// file after file. The row window applies to the rows of the whole set, and
+// partitions to the rows of each file. Rows hold the values of the
+// partition columns of their file.
+func (s *fileSet) scan(opts *ReadOptions, emit func(map[string]interface{}) error) error {
+	if len(s.pfs) == 0 {
+		return nil
+	}
+	if len(s.pfs) == 1 && s.partitions == nil {
+		sc, err := newScanner(s.pfs[0], opts)
+		if err != nil {
+			return err
+		}
+		return sc.scan(emit)
+	}
+
+	if err := opts.validate(s.schema); err != nil {
+		return err
+	}
+
+	skipped, emitted := 0, 0
+	for i, pf := range s.pfs {
+		fileOpts := *opts
+		fileOpts.SkipRows = opts.SkipRows - skipped
+		if opts.RowLimit > 0 {
+			if emitted >= opts.RowLimit {
+				return nil
+			}
+			fileOpts.RowLimit = opts.RowLimit - emitted
+		}
+		if opts.Partition != nil {
+			if err := opts.Partition.validate(pf.Schema()); err != nil {
+				return fmt.Errorf("%s: %w", s.paths[i], err)
+			}
+		}
+
+		sc := newFileScanner(pf, &fileOpts)
+		if s.partitions != nil {
+			sc.virtual = virtualColumns(s.partitions.values[i], opts)
+		}
+		if err := sc.scan(emit); err != nil {
+			return err
+		}
+		skipped += sc.skipped
+		emitted += sc.emitted
+	}
+	return nil
+}
+
+// metadata returns the metadata of the set: the metadata of the file for a
+// single file, or totals over the files, with the row groups of all files
+// and the unified schema.
+func (s *fileSet) metadata(opts *ConvertOptions) map[string]interface{} {
+	if len(s.pfs) == 1 && s.partitions == nil {
+		return metadataOf(s.pfs[0], opts)
+	}
+
+	var numRows, size int64
+	rowGroups := make([]map[string]interface{}, 0)
+	files := make([]map[string]interface{}, 0, len(s.pfs))
+	for i, pf := range s.pfs {
+		for g := range pf.RowGroups() {
+			rg := rowGroupMetadata(pf, g, opts)
+			rg["index"] = len(rowGroups)
+			rg["file"] = s.paths[i]
+			rowGroups = append(rowGroups, rg)
+		}
+		file := map[string]interface{}{
+			"path":             s.paths[i],
+			"numRows":          pf.NumRows(),
+			"numRowGroups":     len(pf.RowGroups()),
+			"size":             pf.Size(),
+			"createdBy":        pf.Metadata().CreatedBy,
+			"keyValueMetadata": keyValueMetadata(pf),
+		}
+		if s.partitions != nil {
+			file["partition"] = s.partitions.values[i]
+		}
+		files = append(files, file)
+		numRows += pf.NumRows()
+		size += pf.Size()
+	}
+
+	metadata := map[string]interface{}{
+		"numRows":      numRows,
+		"numRowGroups": len(rowGroups),
+		"numColumns":   len(s.schema.Fields()),
+		"size":         size,
+		"rowGroups":    rowGroups,
+		"files":        files,
+		"schema":       ConvertSchema(s.schema),
+	}
+	if s.partitions != nil {
+		metadata["partitionColumns"] = s.partitions.keys
+	}
+	return metadata
+}
@@ -0,0 +1,218 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// writePartFile writes rows of consecutive ids, from first, to a Parquet
+// file at the given path.
+func writePartFile(t *testing.T, path string, schema map[string]interface{}, first, numRows int) {
+	t.Helper()
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	w, err := NewWriter(path, schema, map[string]interface{}{"rowGroupSize": 10})
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	for i := first; i < first+numRows; i++ {
+		row := map[string]interface{}{"id": int64(i)}
+		if _, ok := schema["extra"]; ok {
+			row["extra"] = "x"
+		}
+		if err := w.Write(row); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+// createPartFiles creates a directory of three part files of 25 rows each,
+// along with files that are not part of the dataset.
+func createPartFiles(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	schema := map[string]interface{}{"id": "int64"}
+	writePartFile(t, filepath.Join(dir, "part-0001.parquet"), schema, 25, 25)
+	writePartFile(t, filepath.Join(dir, "part-0000.parquet"), schema, 0, 25)
+	writePartFile(t, filepath.Join(dir, "nested", "part-0002.parquet"), schema, 50, 25)
+	writePartFile(t, filepath.Join(dir, "_temporary", "part-0003.parquet"), schema, 75, 25)
+	writePartFile(t, filepath.Join(dir, ".part-0004.parquet"), schema, 100, 25)
+	if err := os.WriteFile(filepath.Join(dir, "_SUCCESS"), nil, 0o600); err != nil {
+		t.Fatalf("failed to write marker: %v", err)
+	}
+	return dir
+}
+
+func TestExpand(t *testing.T) {
+	dir := createPartFiles(t)
+	var files *fileSystem
+
+	t.Run("Directories are walked", func(t *testing.T) {
+		paths, err := files.expand(dir)
+		if err != nil {
+			t.Fatalf("expand() error = %v", err)
+		}
+		expected := []string{
+			filepath.Join(dir, "nested", "part-0002.parquet"),
+			filepath.Join(dir, "part-0000.parquet"),
+			filepath.Join(dir, "part-0001.parquet"),
+		}
+		if !slices.Equal(paths, expected) {
+			t.Errorf("expected %v, got %v", expected, paths)
+		}
+	})
+
+	t.Run("Glob patterns", func(t *testing.T) {
+		paths, err := files.expand(filepath.Join(dir, "part-*.parquet"))
+		if err != nil {
+			t.Fatalf("expand() error = %v", err)
+		}
+		if len(paths) != 2 || !strings.HasSuffix(paths[0], "part-0000.parquet") {
+			t.Errorf("expected the two top-level part files in order, got %v", paths)
+		}
+
+		if _, err := files.expand(filepath.Join(dir, "*.csv")); err == nil {
+			t.Error("expected an error when nothing matches")
+		}
+		if _, err := files.expand(t.TempDir()); err == nil {
+			t.Error("expected an error for a directory without Parquet files")
+		}
+	})
+
+	t.Run("Files are returned as is", func(t *testing.T) {
+		path := filepath.Join(dir, "missing.parquet")
+		if paths, err := files.expand(path); err != nil || !slices.Equal(paths, []string{path}) {
+			t.Errorf("expected the path unchanged, got %v, %v", paths, err)
+		}
+	})
+}
+
+func TestReadFileSet(t *testing.T) {
+	dir := createPartFiles(t)
+	p := &Parquet{cache: NewReaderCache()}
+
+	ids := func(rows []map[string]interface{}) []int64 {
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row["id"].(int64))
+		}
+		return ids
+	}
+
+	t.Run("Files are read in path order", func(t *testing.T) {
+		rows, err := p.Read(filepath.Join(dir, "part-*.parquet"))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if got := ids(rows); len(got) != 50 || !slices.IsSorted(got) {
+			t.Errorf("expected ids 0 to 49 in order, got %v", got)
+		}
+	})
+
+	t.Run("Row window spans files", func(t *testing.T) {
+		rows, err := p.Read(dir, map[string]interface{}{"skipRows": 20, "rowLimit": 40})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		// The nested file comes first: ids 50-74, then 0-24 and 25-49.
+		got := ids(rows)
+		if len(got) != 40 || got[0] != 70 || got[5] != 0 || got[39] != 34 {
+			t.Errorf("unexpected ids %v", got)
+		}
+
+		rows, err = p.Read(dir, map[string]interface{}{"filter": "id >= 60 or id < 20", "skipRows": 14, "rowLimit": 2})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if got := ids(rows); !slices.Equal(got, []int64{74, 0}) {
+			t.Errorf("expected ids 74 and 0, got %v", got)
+		}
+	})
+
+	t.Run("Chunks span files", func(t *testing.T) {
+		var sizes []int
+		err := p.ReadChunked(filepath.Join(dir, "part-*.parquet"), 20, func(chunk []map[string]interface{}) error {
+			sizes = append(sizes, len(chunk))
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ReadChunked() error = %v", err)
+		}
+		if !slices.Equal(sizes, []int{20, 20, 10}) {
+			t.Errorf("expected chunks of 20, 20 and 10 rows, got %v", sizes)
+		}
+	})
+
+	t.Run("Aggregated metadata", func(t *testing.T) {
+		metadata, err := p.GetMetadata(dir)
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		if metadata["numRows"] != int64(75) || metadata["numRowGroups"] != 9 {
+			t.Errorf("expected 75 rows in 9 row groups, got %v", metadata)
+		}
+		files := metadata["files"].([]map[string]interface{})
+		if len(files) != 3 || files[0]["numRowGroups"] != 3 || files[0]["numRows"] != int64(25) {
+			t.Errorf("unexpected files %v", files)
+		}
+		if files[0]["createdBy"] == "" || files[0]["keyValueMetadata"] == nil {
+			t.Errorf("expected the writer and key-value metadata of each file, got %v", files[0])
+		}
+		rowGroups := metadata["rowGroups"].([]map[string]interface{})
+		if rowGroups[8]["index"] != 8 || rowGroups[8]["file"] != filepath.Join(dir, "part-0001.parquet") {
+			t.Errorf("unexpected last row group %v", rowGroups[8])
+		}
+	})
+}
+
+func TestUnifySchemas(t *testing.T) {
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Added optional columns", func(t *testing.T) {
+		dir := t.TempDir()
+		writePartFile(t, filepath.Join(dir, "a.parquet"), map[string]interface{}{"id": "int64"}, 0, 5)
+		writePartFile(t, filepath.Join(dir, "b.parquet"), map[string]interface{}{
+			"id":    map[string]interface{}{"type": "int64", "optional": true},
+			"extra": "string",
+		}, 5, 5)
+
+		schema, err := p.GetSchema(dir)
+		if err != nil {
+			t.Fatalf("GetSchema() error = %v", err)
+		}
+		for _, name := range []string{"id", "extra"} {
+			field := schemaField(schema, name)
+			if field["optional"] != true {
+				t.Errorf("expected %s to be optional, got %v", name, field)
+			}
+		}
+
+		rows, err := p.Read(dir, map[string]interface{}{"filter": "extra = 'x'"})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 5 || rows[0]["id"] != int64(5) {
+			t.Errorf("expected the 5 rows of b.parquet, got %v", rows)
+		}
+	})
+
+	t.Run("Conflicting types", func(t *testing.T) {
+		dir := t.TempDir()
+		writePartFile(t, filepath.Join(dir, "a.parquet"), map[string]interface{}{"id": "int64"}, 0, 5)
+		writePartFile(t, filepath.Join(dir, "b.parquet"), map[string]interface{}{"id": "int32"}, 5, 5)
+
+		_, err := p.Read(dir)
+		if err == nil || !strings.Contains(err.Error(), `schema mismatch: column "id"`) {
+			t.Errorf("expected a schema mismatch, got %v", err)
+		}
+	})
+}
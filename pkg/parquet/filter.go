@@ -0,0 +1,836 @@
+package parquet
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// Comparison operators supported by filters.
+const (
+	opEqual        = "=="
+	opNotEqual     = "!="
+	opLess         = "<"
+	opLessEqual    = "<="
+	opGreater      = ">"
+	opGreaterEqual = ">="
+	opIn           = "in"
+	opIsNull       = "isNull"
+	opNotNull      = "notNull"
+)
+
+// operatorAliases maps the accepted spellings of operators to their canonical form.
+var operatorAliases = map[string]string{
+	"==": opEqual, "=": opEqual, "eq": opEqual,
+	"!=": opNotEqual, "<>": opNotEqual, "ne": opNotEqual,
+	"<": opLess, "lt": opLess,
+	"<=": opLessEqual, "lte": opLessEqual, "le": opLessEqual,
+	">": opGreater, "gt": opGreater,
+	">=": opGreaterEqual, "gte": opGreaterEqual, "ge": opGreaterEqual,
+	"in":      opIn,
+	"isnull":  opIsNull,
+	"notnull": opNotNull,
+}
+
+// rowRange is a half-open range [start, end) of row indexes in a row group.
+type rowRange struct {
+	start, end int64
+}
+
+// predicate is a filter evaluated against decoded rows. Predicates can also
+// tell from column statistics which rows of a row group may match, so that
+// row groups and pages that cannot match are never decoded.
+type predicate interface {
+	// match reports whether a decoded row satisfies the predicate. Rows are
+	// compared after value conversion, so decimals are strings and
+	// timestamps follow the timestamps option; once the predicate has been
+	// validated against the schema, decimals compare as numbers and
+	// timestamps as instants, whatever the option.
+	match(row map[string]interface{}) bool
+
+	// rowRanges returns the ranges of rows of the row group that may match,
+	// given the statistics and page indexes of the referenced columns.
+	rowRanges(rg parquet.RowGroup, schema *parquet.Schema, opts *ConvertOptions) []rowRange
+
+	// paths returns the column paths referenced by the predicate.
+	paths() [][]string
+
+	// String returns a canonical representation of the predicate.
+	String() string
+}
+
+// comparison compares a column with a literal value.
+type comparison struct {
+	path   []string
+	op     string
+	value  interface{}
+	values []interface{} // operands of the "in" operator
+
+	// decimal is set by validateFilter when the column is a DECIMAL column,
+	// whose decoded strings compare as numbers.
+	decimal bool
+
+	// instant is set by validateFilter for TIMESTAMP, INT96 and DATE
+	// columns. It converts their values, decoded as times, ISO 8601 strings
+	// or raw values depending on the timestamps option, to times.
+	instant func(v interface{}) (time.Time, bool)
+
+	// float is set by validateFilter for FLOAT columns, whose values are
+	// compared with literals rounded to float32.
+	float bool
+}
+
+type andPredicate []predicate
+
+type orPredicate []predicate
+
+type notPredicate struct {
+	operand predicate
+}
+
+// parseFilter parses the filter option, which is either a structured
+// predicate object or an expression string.
+func parseFilter(filter interface{}) (predicate, error) {
+	switch f := filter.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return parseFilterExpression(f)
+	case map[string]interface{}:
+		return parseFilterObject(f)
+	default:
+		return nil, fmt.Errorf("invalid filter: expected an object or a string, got %T", filter)
+	}
+}
+
+// parseFilterObject parses a structured predicate such as
+// {and: [{column: "status", op: "==", value: "active"}, {column: "age", op: ">", value: 30}]}.
+func parseFilterObject(f map[string]interface{}) (predicate, error) {
+	if operands, ok := f["and"]; ok {
+		list, err := parseFilterList("and", operands)
+		if err != nil {
+			return nil, err
+		}
+		return andPredicate(list), nil
+	}
+	if operands, ok := f["or"]; ok {
+		list, err := parseFilterList("or", operands)
+		if err != nil {
+			return nil, err
+		}
+		return orPredicate(list), nil
+	}
+	if operand, ok := f["not"]; ok {
+		inner, ok := operand.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: \"not\" expects an object, got %T", operand)
+		}
+		p, err := parseFilterObject(inner)
+		if err != nil {
+			return nil, err
+		}
+		return notPredicate{p}, nil
+	}
+
+	column, ok := f["column"].(string)
+	if !ok || column == "" {
+		return nil, fmt.Errorf("invalid filter: expected \"column\", \"and\", \"or\" or \"not\"")
+	}
+	opName, _ := f["op"].(string)
+	if opName == "" {
+		opName = opEqual
+	}
+	op, ok := operatorAliases[strings.ToLower(opName)]
+	if !ok {
+		return nil, fmt.Errorf("invalid filter: unknown operator %q", opName)
+	}
+
+	return newComparison(column, op, f["value"])
+}
+
+// parseFilterList parses the operands of an "and" or "or" predicate.
+func parseFilterList(name string, operands interface{}) ([]predicate, error) {
+	list, ok := operands.([]interface{})
+	if !ok || len(list) == 0 {
+		return nil, fmt.Errorf("invalid filter: %q expects a non-empty array", name)
+	}
+
+	predicates := make([]predicate, len(list))
+	for i, operand := range list {
+		obj, ok := operand.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: %q operands must be objects, got %T", name, operand)
+		}
+		p, err := parseFilterObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		predicates[i] = p
+	}
+	return predicates, nil
+}
+
+// newComparison validates and builds a comparison predicate. Comparing with
+// null is the same as testing for null values.
+func newComparison(column, op string, value interface{}) (*comparison, error) {
+	c := &comparison{path: strings.Split(column, "."), op: op, value: value}
+
+	switch op {
+	case opIn:
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid filter: %q on %q expects an array value", op, column)
+		}
+		c.values = values
+		c.value = nil
+	case opEqual:
+		if value == nil {
+			c.op = opIsNull
+		}
+	case opNotEqual:
+		if value == nil {
+			c.op = opNotNull
+		}
+	case opIsNull, opNotNull:
+		c.value = nil
+	default:
+		if value == nil {
+			return nil, fmt.Errorf("invalid filter: %q on %q expects a non-null value", op, column)
+		}
+	}
+
+	return c, nil
+}
+
+func (c *comparison) match(row map[string]interface{}) bool {
+	field := lookupPath(row, c.path)
+
+	switch c.op {
+	case opIsNull:
+		return field == nil
+	case opNotNull:
+		return field != nil
+	}
+
+	if field == nil {
+		return false
+	}
+
+	if c.op == opIn {
+		for _, v := range c.values {
+			if cmp, ok := c.compare(field, v); ok && cmp == 0 {
+				return true
+			}
+		}
+		return false
+	}
+
+	cmp, ok := c.compare(field, c.value)
+	if !ok {
+		return c.op == opNotEqual
+	}
+	return compareResult(c.op, cmp)
+}
+
+// compare compares a value of the column with a literal.
+func (c *comparison) compare(a, b interface{}) (int, bool) {
+	switch {
+	case c.decimal:
+		return compareDecimals(a, b)
+	case c.instant != nil:
+		t, ok := c.instant(a)
+		if !ok {
+			return 0, false
+		}
+		return compareValues(t, b)
+	case c.float:
+		if f, ok := toFloat(b); ok {
+			b = float32(f)
+		}
+	}
+	return compareValues(a, b)
+}
+
+func (c *comparison) rowRanges(rg parquet.RowGroup, schema *parquet.Schema, opts *ConvertOptions) []rowRange {
+	all := []rowRange{{0, rg.NumRows()}}
+
+	leaf, ok := schema.Lookup(c.path...)
+	if !ok || leaf.Node == nil || leaf.MaxRepetitionLevel > 0 || leaf.Node.Type().Kind() == parquet.Int96 {
+		return all
+	}
+	convert := newValueConverter(leaf.Node)
+	chunk := rg.ColumnChunks()[leaf.ColumnIndex]
+
+	// Row group level statistics. The null count is optional in the file
+	// metadata and only trusted when the statistics carry bounds too;
+	// columns that are required down from the root never contain nulls,
+	// whereas a required column in an optional group is null with its group.
+	var minValue, maxValue interface{}
+	nullCount := int64(-1)
+	if bounded, ok := chunk.(interface {
+		Bounds() (parquet.Value, parquet.Value, bool)
+		NullCount() int64
+	}); ok {
+		if minV, maxV, ok := bounded.Bounds(); ok && !minV.IsNull() && !maxV.IsNull() {
+			minValue, maxValue = convert(minV, opts), convert(maxV, opts)
+			nullCount = bounded.NullCount()
+		}
+	}
+	if leaf.MaxDefinitionLevel == 0 {
+		nullCount = 0
+	}
+	allNull := nullCount > 0 && nullCount == chunk.NumValues()
+	if !c.mayMatch(minValue, maxValue, nullCount, allNull) {
+		return nil
+	}
+
+	// Page level statistics, when the file has page indexes.
+	columnIndex, err := chunk.ColumnIndex()
+	if err != nil || columnIndex == nil {
+		return all
+	}
+	offsetIndex, err := chunk.OffsetIndex()
+	if err != nil || offsetIndex == nil || offsetIndex.NumPages() != columnIndex.NumPages() {
+		return all
+	}
+
+	var ranges []rowRange
+	numPages := columnIndex.NumPages()
+	for i := 0; i < numPages; i++ {
+		start := offsetIndex.FirstRowIndex(i)
+		end := rg.NumRows()
+		if i+1 < numPages {
+			end = offsetIndex.FirstRowIndex(i + 1)
+		}
+
+		var pageMin, pageMax interface{}
+		nullPage := columnIndex.NullPage(i)
+		if !nullPage {
+			if minV, maxV := columnIndex.MinValue(i), columnIndex.MaxValue(i); !minV.IsNull() && !maxV.IsNull() {
+				pageMin, pageMax = convert(minV, opts), convert(maxV, opts)
+			}
+		}
+		pageNullCount := int64(-1)
+		if leaf.MaxDefinitionLevel == 0 {
+			pageNullCount = 0
+		}
+		if c.mayMatch(pageMin, pageMax, pageNullCount, nullPage) {
+			ranges = appendRange(ranges, rowRange{start, end})
+		}
+	}
+	return ranges
+}
+
+// mayMatch reports whether values within [minValue, maxValue] may satisfy
+// the comparison. Unknown bounds are nil and unknown null counts negative.
+func (c *comparison) mayMatch(minValue, maxValue interface{}, nullCount int64, allNull bool) bool {
+	switch c.op {
+	case opIsNull:
+		return nullCount != 0
+	case opNotNull:
+		return !allNull
+	}
+
+	if allNull {
+		return false
+	}
+	if minValue == nil || maxValue == nil {
+		return true
+	}
+
+	inBounds := func(v interface{}) bool {
+		lo, ok1 := c.compare(minValue, v)
+		hi, ok2 := c.compare(maxValue, v)
+		return !ok1 || !ok2 || (lo <= 0 && hi >= 0)
+	}
+
+	switch c.op {
+	case opEqual:
+		return inBounds(c.value)
+	case opIn:
+		for _, v := range c.values {
+			if inBounds(v) {
+				return true
+			}
+		}
+		return false
+	case opNotEqual:
+		lo, ok1 := c.compare(minValue, c.value)
+		hi, ok2 := c.compare(maxValue, c.value)
+		return !ok1 || !ok2 || lo != 0 || hi != 0
+	case opLess, opLessEqual:
+		cmp, ok := c.compare(minValue, c.value)
+		return !ok || compareResult(c.op, cmp)
+	case opGreater, opGreaterEqual:
+		cmp, ok := c.compare(maxValue, c.value)
+		return !ok || compareResult(c.op, cmp)
+	default:
+		return true
+	}
+}
+
+func (c *comparison) paths() [][]string { return [][]string{c.path} }
+
+func (c *comparison) String() string {
+	column := strings.Join(c.path, ".")
+	switch c.op {
+	case opIsNull:
+		return column + " IS NULL"
+	case opNotNull:
+		return column + " IS NOT NULL"
+	case opIn:
+		values := make([]string, len(c.values))
+		for i, v := range c.values {
+			values[i] = formatLiteral(v)
+		}
+		return column + " IN (" + strings.Join(values, ", ") + ")"
+	default:
+		return column + " " + c.op + " " + formatLiteral(c.value)
+	}
+}
+
+func (a andPredicate) match(row map[string]interface{}) bool {
+	for _, p := range a {
+		if !p.match(row) {
+			return false
+		}
+	}
+	return true
+}
+
+func (a andPredicate) rowRanges(rg parquet.RowGroup, schema *parquet.Schema, opts *ConvertOptions) []rowRange {
+	ranges := []rowRange{{0, rg.NumRows()}}
+	for _, p := range a {
+		ranges = intersectRanges(ranges, p.rowRanges(rg, schema, opts))
+		if len(ranges) == 0 {
+			break
+		}
+	}
+	return ranges
+}
+
+func (a andPredicate) paths() [][]string { return joinPaths(a) }
+
+func (a andPredicate) String() string { return joinPredicates(a, " AND ") }
+
+func (o orPredicate) match(row map[string]interface{}) bool {
+	for _, p := range o {
+		if p.match(row) {
+			return true
+		}
+	}
+	return false
+}
+
+func (o orPredicate) rowRanges(rg parquet.RowGroup, schema *parquet.Schema, opts *ConvertOptions) []rowRange {
+	var ranges []rowRange
+	for _, p := range o {
+		ranges = unionRanges(ranges, p.rowRanges(rg, schema, opts))
+	}
+	return ranges
+}
+
+func (o orPredicate) paths() [][]string { return joinPaths(o) }
+
+func (o orPredicate) String() string { return joinPredicates(o, " OR ") }
+
+func (n notPredicate) match(row map[string]interface{}) bool {
+	return !n.operand.match(row)
+}
+
+// rowRanges cannot be derived from the operand, whose ranges are only the
+// rows that may match.
+func (n notPredicate) rowRanges(rg parquet.RowGroup, _ *parquet.Schema, _ *ConvertOptions) []rowRange {
+	return []rowRange{{0, rg.NumRows()}}
+}
+
+func (n notPredicate) paths() [][]string { return n.operand.paths() }
+
+func (n notPredicate) String() string { return "NOT (" + n.operand.String() + ")" }
+
+// joinPredicates formats the operands of a logical predicate.
+func joinPredicates(predicates []predicate, separator string) string {
+	parts := make([]string, len(predicates))
+	for i, p := range predicates {
+		parts[i] = p.String()
+	}
+	return "(" + strings.Join(parts, separator) + ")"
+}
+
+// joinPaths returns the column paths referenced by the operands of a
+// logical predicate.
+func joinPaths(predicates []predicate) [][]string {
+	var paths [][]string
+	for _, p := range predicates {
+		paths = append(paths, p.paths()...)
+	}
+	return paths
+}
+
+// validateFilter checks that the columns referenced by a filter exist in
+// the schema, and sets how comparisons compare the values of DECIMAL,
+// temporal and FLOAT columns.
+func validateFilter(filter predicate, schema *parquet.Schema) error {
+	switch p := filter.(type) {
+	case *comparison:
+		var node parquet.Node = schema
+		for _, name := range p.path {
+			node = fieldByName(node, name)
+			if node == nil {
+				return fmt.Errorf("invalid filter: unknown column %q", strings.Join(p.path, "."))
+			}
+		}
+		p.decimal, p.instant, p.float = false, nil, false
+		if node.Leaf() {
+			lt := node.Type().LogicalType()
+			p.decimal = lt != nil && lt.Decimal != nil
+			p.instant = instantOf(node)
+			p.float = lt == nil && node.Type().Kind() == parquet.Float
+		}
+	case andPredicate:
+		return validateFilters(p, schema)
+	case orPredicate:
+		return validateFilters(p, schema)
+	case notPredicate:
+		return validateFilter(p.operand, schema)
+	}
+	return nil
+}
+
+// validateFilters validates the operands of a logical predicate.
+func validateFilters(predicates []predicate, schema *parquet.Schema) error {
+	for _, p := range predicates {
+		if err := validateFilter(p, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByName returns the child field of a group node with the given name.
+func fieldByName(node parquet.Node, name string) parquet.Node {
+	if node.Leaf() {
+		return nil
+	}
+	for _, field := range node.Fields() {
+		if field.Name() == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// formatLiteral formats a literal value of a predicate.
+func formatLiteral(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// appendRange appends a range, merging it with the last one when adjacent.
+func appendRange(ranges []rowRange, r rowRange) []rowRange {
+	if n := len(ranges); n > 0 && ranges[n-1].end >= r.start {
+		ranges[n-1].end = max(ranges[n-1].end, r.end)
+		return ranges
+	}
+	return append(ranges, r)
+}
+
+// intersectRanges returns the intersection of two sorted lists of ranges.
+func intersectRanges(a, b []rowRange) []rowRange {
+	var result []rowRange
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		start := max(a[i].start, b[j].start)
+		end := min(a[i].end, b[j].end)
+		if start < end {
+			result = appendRange(result, rowRange{start, end})
+		}
+		if a[i].end < b[j].end {
+			i++
+		} else {
+			j++
+		}
+	}
+	return result
+}
+
+// unionRanges returns the union of two sorted lists of ranges.
+func unionRanges(a, b []rowRange) []rowRange {
+	var result []rowRange
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		if j >= len(b) || (i < len(a) && a[i].start <= b[j].start) {
+			result = appendRange(result, a[i])
+			i++
+		} else {
+			result = appendRange(result, b[j])
+			j++
+		}
+	}
+	return result
+}
+
+// lookupPath returns the value at a dotted path in a decoded row.
+func lookupPath(row map[string]interface{}, path []string) interface{} {
+	var value interface{} = row
+	for _, name := range path {
+		group, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = group[name]
+	}
+	return value
+}
+
+// compareResult applies a comparison operator to the result of compareValues.
+func compareResult(op string, cmp int) bool {
+	switch op {
+	case opEqual:
+		return cmp == 0
+	case opNotEqual:
+		return cmp != 0
+	case opLess:
+		return cmp < 0
+	case opLessEqual:
+		return cmp <= 0
+	case opGreater:
+		return cmp > 0
+	case opGreaterEqual:
+		return cmp >= 0
+	default:
+		return false
+	}
+}
+
+// compareValues compares a decoded value with a literal, returning false if
+// they cannot be compared. Numbers compare numerically, including with the
+// decimal strings of DECIMAL columns; timestamps compare with ISO 8601
+// strings and with milliseconds since the Unix epoch; binary values compare
+// bytewise with strings.
+func compareValues(a, b interface{}) (int, bool) {
+	if t, ok := a.(time.Time); ok {
+		other, ok := toTime(b)
+		if !ok {
+			return 0, false
+		}
+		return t.Compare(other), true
+	}
+	if _, ok := b.(time.Time); ok {
+		cmp, ok := compareValues(b, a)
+		return -cmp, ok
+	}
+
+	switch x := a.(type) {
+	case string:
+		if y, ok := b.(string); ok {
+			return strings.Compare(x, y), true
+		}
+		if y, ok := toFloat(b); ok {
+			if fx, err := strconv.ParseFloat(x, 64); err == nil {
+				return compareFloats(fx, y), true
+			}
+		}
+		return 0, false
+	case []byte:
+		if y, ok := b.(string); ok {
+			return bytes.Compare(x, []byte(y)), true
+		}
+		return 0, false
+	case bool:
+		y, ok := b.(bool)
+		if !ok {
+			return 0, false
+		}
+		switch {
+		case x == y:
+			return 0, true
+		case !x:
+			return -1, true
+		default:
+			return 1, true
+		}
+	}
+
+	if x, ok := toInt64(a); ok {
+		if y, ok := toInt64(b); ok {
+			switch {
+			case x < y:
+				return -1, true
+			case x > y:
+				return 1, true
+			default:
+				return 0, true
+			}
+		}
+	}
+	if x, ok := toFloat(a); ok {
+		if y, ok := toFloat(b); ok {
+			return compareFloats(x, y), true
+		}
+		if s, ok := b.(string); ok {
+			if y, err := strconv.ParseFloat(s, 64); err == nil {
+				return compareFloats(x, y), true
+			}
+		}
+	}
+	return 0, false
+}
+
+// compareDecimals compares the decimal strings of DECIMAL columns, with each
+// other or with numbers, exactly: "10.5" equals "10.50" and is greater than
+// "9.00".
+func compareDecimals(a, b interface{}) (int, bool) {
+	x, ok := toRat(a)
+	if !ok {
+		return 0, false
+	}
+	y, ok := toRat(b)
+	if !ok {
+		return 0, false
+	}
+	return x.Cmp(y), true
+}
+
+// toRat converts decimal strings and numbers to exact rationals. Floats are
+// taken at their shortest decimal representation, so that 12.4 equals
+// "12.40".
+func toRat(v interface{}) (*big.Rat, bool) {
+	if s, ok := v.(string); ok {
+		return new(big.Rat).SetString(s)
+	}
+	if n, ok := v.(int64); ok {
+		return new(big.Rat).SetInt64(n), true
+	}
+	f, ok := toFloat(v)
+	if !ok || math.IsNaN(f) || math.IsInf(f, 0) {
+		return nil, false
+	}
+	return new(big.Rat).SetString(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+// compareFloats compares two floats, ordering NaN before other values.
+func compareFloats(x, y float64) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	case x == y:
+		return 0
+	case math.IsNaN(x) && !math.IsNaN(y):
+		return -1
+	case !math.IsNaN(x) && math.IsNaN(y):
+		return 1
+	default:
+		return 0
+	}
+}
+
+// toInt64 converts integer values to int64.
+func toInt64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int32:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint32:
+		return int64(n), true
+	case uint64:
+		if n <= math.MaxInt64 {
+			return int64(n), true
+		}
+	case float64:
+		if n == math.Trunc(n) && math.Abs(n) < 1<<63 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// instantOf returns the function converting the decoded values of a
+// temporal column to times, whatever the timestamps option, or nil when the
+// column is not a TIMESTAMP, INT96 or DATE column.
+func instantOf(node parquet.Node) func(v interface{}) (time.Time, bool) {
+	typ := node.Type()
+	lt := typ.LogicalType()
+	switch {
+	case typ.Kind() == parquet.Int96:
+		return func(v interface{}) (time.Time, bool) {
+			if i96, ok := v.(deprecated.Int96); ok {
+				return int96Time(i96), true
+			}
+			return toTime(v)
+		}
+	case lt != nil && lt.Timestamp != nil:
+		unit := timeUnitDuration(&lt.Timestamp.Unit)
+		return func(v interface{}) (time.Time, bool) {
+			if ticks, ok := v.(int64); ok {
+				return unixTime(ticks, unit), true
+			}
+			return toTime(v)
+		}
+	case lt != nil && lt.Date != nil:
+		return func(v interface{}) (time.Time, bool) {
+			if days, ok := v.(int32); ok {
+				return time.Unix(int64(days)*86400, 0).UTC(), true
+			}
+			return toTime(v)
+		}
+	default:
+		return nil
+	}
+}
+
+// toTime converts a literal to a time: ISO 8601 strings, dates and
+// milliseconds since the Unix epoch are accepted.
+func toTime(v interface{}) (time.Time, bool) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, true
+	case string:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05.999999999", time.DateOnly} {
+			if parsed, err := time.Parse(layout, t); err == nil {
+				return parsed, true
+			}
+		}
+		return time.Time{}, false
+	default:
+		if ms, ok := toFloat(v); ok {
+			return time.UnixMilli(int64(ms)).UTC(), true
+		}
+		return time.Time{}, false
+	}
+}
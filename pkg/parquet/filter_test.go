@@ -0,0 +1,400 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+func TestParseFilter(t *testing.T) {
+	t.Run("Structured predicates", func(t *testing.T) {
+		pred, err := parseFilter(map[string]interface{}{
+			"and": []interface{}{
+				map[string]interface{}{"column": "status", "op": "==", "value": "active"},
+				map[string]interface{}{"or": []interface{}{
+					map[string]interface{}{"column": "age", "op": "gt", "value": float64(30)},
+					map[string]interface{}{"column": "country", "op": "in", "value": []interface{}{"FR", "DE"}},
+				}},
+				map[string]interface{}{"not": map[string]interface{}{"column": "email", "op": "isNull"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("parseFilter() error = %v", err)
+		}
+
+		expected := `(status == "active" AND (age > 30 OR country IN ("FR", "DE")) AND NOT (email IS NULL))`
+		if pred.String() != expected {
+			t.Errorf("expected %s, got %s", expected, pred)
+		}
+	})
+
+	t.Run("Expression strings", func(t *testing.T) {
+		pred, err := parseFilter("age > 30")
+		if err != nil {
+			t.Fatalf("parseFilter() error = %v", err)
+		}
+		if pred.String() != "age > 30" {
+			t.Errorf("expected age > 30, got %s", pred)
+		}
+	})
+
+	t.Run("Invalid predicates", func(t *testing.T) {
+		invalid := []interface{}{
+			42,
+			map[string]interface{}{},
+			map[string]interface{}{"column": "age", "op": "~", "value": 1},
+			map[string]interface{}{"column": "age", "op": ">"},
+			map[string]interface{}{"column": "age", "op": "in", "value": "FR"},
+			map[string]interface{}{"and": []interface{}{}},
+			map[string]interface{}{"or": []interface{}{"age > 1"}},
+			map[string]interface{}{"not": "age > 1"},
+		}
+		for _, filter := range invalid {
+			if _, err := parseFilter(filter); err == nil {
+				t.Errorf("expected error for %v", filter)
+			}
+		}
+	})
+}
+
+func TestFilterMatch(t *testing.T) {
+	created := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	row := map[string]interface{}{
+		"id":      int64(42),
+		"score":   float64(87.5),
+		"name":    "Alice",
+		"active":  true,
+		"price":   "12.50",
+		"created": created,
+		"email":   nil,
+		"address": map[string]interface{}{"city": "Paris"},
+	}
+
+	tests := []struct {
+		expression string
+		expected   bool
+	}{
+		{"id == 42", true},
+		{"id == 42.0", true},
+		{"id != 42", false},
+		{"id < 100 AND score >= 87.5", true},
+		{"score > 90 OR name == 'Alice'", true},
+		{"name > 'Bob'", false},
+		{"active == true", true},
+		{"price > 12.4", true},
+		{"price == '12.50'", true},
+		{"created >= '2024-03-01'", true},
+		{"created < '2024-03-01T12:00:00Z'", false},
+		{"created == 1709294400000", true},
+		{"email IS NULL", true},
+		{"email == 'a@b.c'", false},
+		{"email != 'a@b.c'", false},
+		{"address.city == 'Paris'", true},
+		{"address.zip IS NULL", true},
+		{"name IN ('Bob', 'Alice')", true},
+		{"NOT (id IN (1, 2))", true},
+		{"name == 1", false},
+		{"name != 1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expression, func(t *testing.T) {
+			pred, err := parseFilterExpression(tt.expression)
+			if err != nil {
+				t.Fatalf("parseFilterExpression() error = %v", err)
+			}
+			if got := pred.match(row); got != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestFilterPruning(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	scan := func(t *testing.T, filter string) (*scanner, []map[string]interface{}) {
+		t.Helper()
+
+		opts, err := parseReadOptions(map[string]interface{}{"filter": filter})
+		if err != nil {
+			t.Fatalf("parseReadOptions() error = %v", err)
+		}
+		file, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		defer file.Close()
+
+		sc, err := newScanner(pf, &opts)
+		if err != nil {
+			t.Fatalf("newScanner() error = %v", err)
+		}
+		var rows []map[string]interface{}
+		err = sc.scan(func(row map[string]interface{}) error {
+			rows = append(rows, row)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+		return sc, rows
+	}
+
+	t.Run("Row groups outside the bounds are skipped", func(t *testing.T) {
+		sc, rows := scan(t, "id >= 2500")
+		if len(rows) != 500 {
+			t.Fatalf("expected 500 rows, got %d", len(rows))
+		}
+		// Only the row groups [2100, 2800) and [2800, 3000) may match.
+		if sc.decoded > 900 {
+			t.Errorf("expected at most 900 decoded rows, got %d", sc.decoded)
+		}
+	})
+
+	t.Run("Disjunctions keep the ranges of each operand", func(t *testing.T) {
+		sc, rows := scan(t, "id < 10 OR id == 1500")
+		if len(rows) != 11 {
+			t.Fatalf("expected 11 rows, got %d", len(rows))
+		}
+		if sc.decoded > 1400 {
+			t.Errorf("expected at most 1400 decoded rows, got %d", sc.decoded)
+		}
+	})
+
+	t.Run("No row group is decoded when none can match", func(t *testing.T) {
+		sc, rows := scan(t, "id > 5000 OR name IS NULL")
+		if len(rows) != 0 || sc.decoded != 0 {
+			t.Errorf("expected no decoded rows, got %d rows and %d decoded", len(rows), sc.decoded)
+		}
+	})
+
+	t.Run("Negations are evaluated on every row", func(t *testing.T) {
+		_, rows := scan(t, "NOT (id >= 10)")
+		if len(rows) != 10 {
+			t.Errorf("expected 10 rows, got %d", len(rows))
+		}
+	})
+
+	t.Run("Unknown columns are rejected", func(t *testing.T) {
+		opts, _ := parseReadOptions(map[string]interface{}{"filter": "missing == 1"})
+		file, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		defer file.Close()
+
+		if _, err := newScanner(pf, &opts); err == nil {
+			t.Error("expected error for unknown column")
+		}
+	})
+}
+
+func TestFilterNestedNulls(t *testing.T) {
+	type Address struct {
+		City string `parquet:"city"`
+	}
+	type Employee struct {
+		ID      int64    `parquet:"id"`
+		Manager *Address `parquet:"manager,optional"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "employees.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	writer := parquet.NewGenericWriter[Employee](file)
+	rows := []Employee{
+		{ID: 1, Manager: &Address{City: "Lyon"}},
+		{ID: 2},
+		{ID: 3, Manager: &Address{City: "Paris"}},
+	}
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	p := &Parquet{
+		cache: NewReaderCache(),
+	}
+
+	// The required city column is null whenever its optional parent is, so
+	// neither the row group nor the page statistics rule out nulls.
+	tests := []struct {
+		filter   string
+		expected []int64
+	}{
+		{"manager.city IS NULL", []int64{2}},
+		{"manager.city IS NOT NULL", []int64{1, 3}},
+		{"manager.city == 'Paris'", []int64{3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			results, err := p.Read(filename, map[string]interface{}{"filter": tt.filter})
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			if len(results) != len(tt.expected) {
+				t.Fatalf("expected %d rows, got %v", len(tt.expected), results)
+			}
+			for i, id := range tt.expected {
+				if results[i]["id"] != id {
+					t.Errorf("expected row %d to have id %d, got %v", i, id, results[i]["id"])
+				}
+			}
+		})
+	}
+}
+
+func TestFilterDecimals(t *testing.T) {
+	type Product struct {
+		ID    int64 `parquet:"id"`
+		Price int64 `parquet:"price,decimal(2:10)"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "products.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	// One row per row group, so that statistics prune with decimal bounds.
+	writer := parquet.NewGenericWriter[Product](file, parquet.MaxRowsPerRowGroup(1))
+	rows := []Product{{ID: 1, Price: 950}, {ID: 2, Price: 1050}, {ID: 3, Price: 10000}}
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	tests := []struct {
+		filter   string
+		expected []int64
+	}{
+		{"price > '9.00'", []int64{1, 2, 3}},
+		{"price == '10.5'", []int64{2}},
+		{"price IN ('9.5', '100')", []int64{1, 3}},
+		{"price < 10", []int64{1}},
+		{"price >= 10.5", []int64{2, 3}},
+	}
+
+	read := func(t *testing.T, p *Parquet, filename, filter string, expected []int64) {
+		t.Helper()
+
+		results, err := p.Read(filename, map[string]interface{}{"filter": filter})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != len(expected) {
+			t.Fatalf("expected %d rows, got %v", len(expected), results)
+		}
+		for i, id := range expected {
+			if results[i]["id"] != id {
+				t.Errorf("expected row %d to have id %d, got %v", i, id, results[i]["id"])
+			}
+		}
+	}
+
+	t.Run("Decimals compare as numbers", func(t *testing.T) {
+		p := &Parquet{cache: NewReaderCache()}
+		for _, tt := range tests {
+			read(t, p, filename, tt.filter, tt.expected)
+		}
+	})
+
+	t.Run("Decimals compare as numbers in cached rows", func(t *testing.T) {
+		// Read a copy of the file, then remove it so that only the cache can
+		// serve the filtered reads.
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("failed to read test file: %v", err)
+		}
+		copyName := filepath.Join(t.TempDir(), "copy.parquet")
+		if err := os.WriteFile(copyName, data, 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		p := &Parquet{cache: NewReaderCache()}
+		if _, err := p.Read(copyName); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if err := os.Remove(copyName); err != nil {
+			t.Fatalf("failed to remove test file: %v", err)
+		}
+		for _, tt := range tests {
+			read(t, p, copyName, tt.filter, tt.expected)
+		}
+	})
+}
+
+func TestFilterTimestampsAndFloats(t *testing.T) {
+	type Event struct {
+		ID    int64     `parquet:"id"`
+		At    time.Time `parquet:"at,timestamp(millisecond)"`
+		Score float32   `parquet:"score"`
+	}
+
+	filename := filepath.Join(t.TempDir(), "events.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	// One row per row group, so that statistics prune too.
+	writer := parquet.NewGenericWriter[Event](file, parquet.MaxRowsPerRowGroup(1))
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	rows := []Event{
+		{ID: 1, At: start, Score: 0.1},
+		{ID: 2, At: start.Add(500 * time.Millisecond), Score: 0.2},
+		{ID: 3, At: start.Add(time.Second), Score: 0.3},
+	}
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+	file.Close()
+
+	// Timestamps compare as instants whatever the timestamps option: as
+	// strings, "12:00:00Z" would sort after "12:00:00.5Z", and raw values
+	// would never equal an ISO 8601 literal.
+	tests := []struct {
+		filter   string
+		expected []int64
+	}{
+		{"at < '2024-03-01T12:00:00.5Z'", []int64{1}},
+		{"at >= '2024-03-01T12:00:00.5Z'", []int64{2, 3}},
+		{"at == '2024-03-01T12:00:01Z'", []int64{3}},
+		{"at > 1709294400000", []int64{2, 3}},
+		{"score == 0.1", []int64{1}},
+		{"score IN (0.2, 0.3)", []int64{2, 3}},
+		{"score > 0.1", []int64{2, 3}},
+	}
+	for _, mode := range []string{timestampsDate, timestampsString, timestampsRaw} {
+		p := &Parquet{cache: NewReaderCache()}
+		for _, tt := range tests {
+			t.Run(mode+"/"+tt.filter, func(t *testing.T) {
+				results, err := p.Read(filename, map[string]interface{}{"filter": tt.filter, "timestamps": mode})
+				if err != nil {
+					t.Fatalf("Read() error = %v", err)
+				}
+				if len(results) != len(tt.expected) {
+					t.Fatalf("expected %d rows, got %v", len(tt.expected), results)
+				}
+				for i, id := range tt.expected {
+					if results[i]["id"] != id {
+						t.Errorf("expected row %d to have id %d, got %v", i, id, results[i]["id"])
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,101 @@
+package parquet
+
+import (
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+	"github.com/parquet-go/parquet-go/encoding"
+)
+
+// orderedGroup is a group node whose fields keep the order in which they
+// were set, unlike parquet.Group, which sorts its fields by name. It is used
+// for the schemas built by the extension, so that columns follow the order
+// of the file or of the script.
+type orderedGroup []parquet.Field
+
+// set adds a field at the end of the group, or replaces the field with the
+// same name in place.
+func (g *orderedGroup) set(name string, node parquet.Node) {
+	field := &orderedField{Node: node, name: name}
+	for i, f := range *g {
+		if f.Name() == name {
+			(*g)[i] = field
+			return
+		}
+	}
+	*g = append(*g, field)
+}
+
+// field returns the field with the given name, or nil.
+func (g orderedGroup) field(name string) parquet.Node {
+	for _, f := range g {
+		if f.Name() == name {
+			return f
+		}
+	}
+	return nil
+}
+
+func (g orderedGroup) ID() int { return 0 }
+
+func (g orderedGroup) String() string {
+	s := new(strings.Builder)
+	_ = parquet.PrintSchema(s, "", g)
+	return s.String()
+}
+
+func (g orderedGroup) Type() parquet.Type { return parquet.Group{}.Type() }
+
+func (g orderedGroup) Optional() bool { return false }
+
+func (g orderedGroup) Repeated() bool { return false }
+
+func (g orderedGroup) Required() bool { return true }
+
+func (g orderedGroup) Leaf() bool { return false }
+
+func (g orderedGroup) Fields() []parquet.Field { return g }
+
+func (g orderedGroup) Encoding() encoding.Encoding { return nil }
+
+func (g orderedGroup) Compression() compress.Codec { return nil }
+
+// GoType returns a struct type with a field for each field of the group, as
+// parquet.Group does.
+func (g orderedGroup) GoType() reflect.Type {
+	fields := make([]reflect.StructField, len(g))
+	for i, field := range g {
+		r, size := utf8.DecodeRuneInString(field.Name())
+		fields[i] = reflect.StructField{
+			Name: string(unicode.ToUpper(r)) + field.Name()[size:],
+			Type: field.GoType(),
+		}
+	}
+	return reflect.StructOf(fields)
+}
+
+// orderedField is a named field of an orderedGroup.
+type orderedField struct {
+	parquet.Node
+	name string
+}
+
+func (f *orderedField) Name() string { return f.name }
+
+// Value returns the value of the field in a map of the parent group, like
+// the fields of parquet.Group.
+func (f *orderedField) Value(base reflect.Value) reflect.Value {
+	if base.Kind() == reflect.Interface {
+		if base.IsNil() {
+			return reflect.ValueOf(nil)
+		}
+		if base = base.Elem(); base.Kind() == reflect.Pointer && base.IsNil() {
+			return reflect.ValueOf(nil)
+		}
+	}
+	return base.MapIndex(reflect.ValueOf(&f.name).Elem())
+}
@@ -0,0 +1,190 @@
+package parquet
+
+import (
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// hiveDefaultPartition is the directory value Hive, Spark and Flink write for
+// null partition values.
+const hiveDefaultPartition = "__HIVE_DEFAULT_PARTITION__"
+
+// hivePartitions holds the partition columns of a dataset laid out in Hive
+// style directories, such as events/date=2026-01-01/region=eu/part.parquet.
+// The columns are virtual: their values come from the path of each file.
+type hivePartitions struct {
+	// keys are the partition columns, in the order of the directories.
+	keys []string
+
+	// ints tells the columns whose values are all integers, read as int64
+	// rather than strings.
+	ints map[string]bool
+
+	// values holds the values of the partition columns for each file, nil
+	// for the columns missing from its path.
+	values []map[string]interface{}
+}
+
+// parseHivePartitions returns the partition columns of the files of a
+// dataset, from the key=value directories of their paths below the root of
+// the dataset, or nil when the paths have none.
+func parseHivePartitions(root string, paths []string) *hivePartitions {
+	h := &hivePartitions{ints: make(map[string]bool)}
+	raw := make([]map[string]string, len(paths))
+	for i, path := range paths {
+		raw[i] = make(map[string]string)
+		rel, err := filepath.Rel(root, filepath.Dir(path))
+		if err != nil || rel == "." {
+			continue
+		}
+		for _, segment := range strings.Split(filepath.ToSlash(rel), "/") {
+			key, value, ok := strings.Cut(segment, "=")
+			if !ok || key == "" {
+				continue
+			}
+			if _, seen := h.ints[key]; !seen {
+				h.keys = append(h.keys, key)
+				h.ints[key] = true
+			}
+			if unescaped, err := url.PathUnescape(value); err == nil {
+				value = unescaped
+			}
+			if value == hiveDefaultPartition {
+				value = ""
+			}
+			raw[i][key] = value
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil && value != "" {
+				h.ints[key] = false
+			}
+		}
+	}
+	if len(h.keys) == 0 {
+		return nil
+	}
+
+	h.values = make([]map[string]interface{}, len(paths))
+	for i := range paths {
+		values := make(map[string]interface{}, len(h.keys))
+		for _, key := range h.keys {
+			value, ok := raw[i][key]
+			switch {
+			case !ok || value == "":
+				values[key] = nil
+			case h.ints[key]:
+				values[key], _ = strconv.ParseInt(value, 10, 64)
+			default:
+				values[key] = value
+			}
+		}
+		h.values[i] = values
+	}
+	return h
+}
+
+// datasetRoot returns the directory partition directories are relative to:
+// the directory of a dataset, or the leading directories of a glob pattern
+// without wildcards.
+func datasetRoot(path string) string {
+	if !strings.ContainsAny(path, "*?[") {
+		return path
+	}
+	dir := filepath.Dir(path)
+	for strings.ContainsAny(dir, "*?[") {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// fields returns the schema fields of the partition columns, which are
+// optional since a file may lack a partition directory.
+func (h *hivePartitions) fields() orderedGroup {
+	fields := make(orderedGroup, 0, len(h.keys))
+	for _, key := range h.keys {
+		if h.ints[key] {
+			fields.set(key, parquet.Optional(parquet.Int(64)))
+		} else {
+			fields.set(key, parquet.Optional(parquet.String()))
+		}
+	}
+	return fields
+}
+
+// prune returns the indexes of the files whose partition values may match
+// the filter, so that the directories of the other files are never read.
+func (h *hivePartitions) prune(filter predicate) []int {
+	var kept []int
+	for i, values := range h.values {
+		if matched, known := matchPartition(filter, values); matched || !known {
+			kept = append(kept, i)
+		}
+	}
+	return kept
+}
+
+// matchPartition evaluates a filter against the partition values of a file.
+// The result is only known when it does not depend on data columns.
+func matchPartition(filter predicate, values map[string]interface{}) (matched, known bool) {
+	switch p := filter.(type) {
+	case *comparison:
+		if _, ok := values[p.path[0]]; len(p.path) != 1 || !ok {
+			return false, false
+		}
+		return p.match(values), true
+	case andPredicate:
+		known = true
+		for _, operand := range p {
+			m, k := matchPartition(operand, values)
+			if k && !m {
+				return false, true
+			}
+			known = known && k
+		}
+		return known, known
+	case orPredicate:
+		known = true
+		for _, operand := range p {
+			m, k := matchPartition(operand, values)
+			if k && m {
+				return true, true
+			}
+			known = known && k
+		}
+		return false, known
+	case notPredicate:
+		m, k := matchPartition(p.operand, values)
+		return !m, k
+	default:
+		return false, false
+	}
+}
+
+// virtualColumns returns the partition values to add to the rows of a file
+// read with the given options: the requested columns and the columns the
+// filter references, or all of them without a column selection.
+func virtualColumns(values map[string]interface{}, opts *ReadOptions) map[string]interface{} {
+	if len(opts.Columns) == 0 {
+		return values
+	}
+
+	needed := make(map[string]bool)
+	for _, column := range opts.Columns {
+		needed[column] = true
+	}
+	if opts.Filter != nil {
+		for _, path := range opts.Filter.paths() {
+			needed[path[0]] = true
+		}
+	}
+
+	selected := make(map[string]interface{})
+	for key, value := range values {
+		if needed[key] {
+			selected[key] = value
+		}
+	}
+	return selected
+}
@@ -0,0 +1,167 @@
+package parquet
+
+import (
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// createHiveDataset creates a dataset partitioned by date and region, with
+// 10 rows per partition.
+func createHiveDataset(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	schema := map[string]interface{}{"id": "int64"}
+	for i, partition := range []string{
+		"date=2026-01-01/region=eu",
+		"date=2026-01-01/region=us",
+		"date=2026-01-02/region=eu",
+		"date=2026-01-02/region=" + hiveDefaultPartition,
+	} {
+		writePartFile(t, filepath.Join(dir, partition, "part-0000.parquet"), schema, i*10, 10)
+	}
+	return dir
+}
+
+func TestParseHivePartitions(t *testing.T) {
+	root := filepath.Join("data", "events")
+	h := parseHivePartitions(root, []string{
+		filepath.Join(root, "year=2026", "city=New%20York", "part.parquet"),
+		filepath.Join(root, "year=2025", "part.parquet"),
+		filepath.Join(root, "part.parquet"),
+	})
+	if h == nil {
+		t.Fatal("expected partition columns")
+	}
+	if !slices.Equal(h.keys, []string{"year", "city"}) {
+		t.Errorf("expected the year and city columns, got %v", h.keys)
+	}
+	if h.values[0]["year"] != int64(2026) || h.values[0]["city"] != "New York" {
+		t.Errorf("unexpected values %v", h.values[0])
+	}
+	if h.values[1]["city"] != nil || h.values[2]["year"] != nil {
+		t.Errorf("expected missing partitions to be null, got %v and %v", h.values[1], h.values[2])
+	}
+
+	if h := parseHivePartitions(root, []string{filepath.Join(root, "nested", "part.parquet")}); h != nil {
+		t.Errorf("expected no partition columns, got %v", h.keys)
+	}
+	if root := datasetRoot(filepath.Join(root, "year=*", "*.parquet")); root != filepath.Join("data", "events") {
+		t.Errorf("expected the glob root to be data/events, got %s", root)
+	}
+}
+
+func TestReadHivePartitions(t *testing.T) {
+	dir := createHiveDataset(t)
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Partition columns are added to rows", func(t *testing.T) {
+		rows, err := p.Read(dir)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 40 {
+			t.Fatalf("expected 40 rows, got %d", len(rows))
+		}
+		if rows[0]["date"] != "2026-01-01" || rows[0]["region"] != "eu" || rows[0]["id"] != int64(0) {
+			t.Errorf("unexpected first row %v", rows[0])
+		}
+		// Files are read in path order: the default partition sorts first.
+		if row := rows[20]; row["id"] != int64(30) || row["date"] != "2026-01-02" || row["region"] != nil {
+			t.Errorf("expected a null region for the default partition, got %v", row)
+		}
+	})
+
+	t.Run("Partition columns can be selected", func(t *testing.T) {
+		rows, err := p.Read(dir, map[string]interface{}{"columns": []interface{}{"region"}, "rowLimit": 1})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 1 || len(rows[0]) != 1 || rows[0]["region"] != "eu" {
+			t.Errorf("expected only the region column, got %v", rows)
+		}
+	})
+
+	t.Run("Filters prune partitions", func(t *testing.T) {
+		filter, err := parseFilter("date = '2026-01-02' and region = 'eu'")
+		if err != nil {
+			t.Fatalf("parseFilter() error = %v", err)
+		}
+		set, err := p.files.openSet(dir, filter)
+		if err != nil {
+			t.Fatalf("openSet() error = %v", err)
+		}
+		set.Close()
+		if len(set.paths) != 1 || !strings.Contains(set.paths[0], "date=2026-01-02") {
+			t.Errorf("expected a single file to be kept, got %v", set.paths)
+		}
+		if len(set.pfs) != 1 || len(set.schema.Fields()) != 3 {
+			t.Errorf("expected one open file and the schema of the dataset, got %d files and %v", len(set.pfs), set.schema)
+		}
+
+		rows, err := p.Read(dir, map[string]interface{}{
+			"columns": []interface{}{"id"},
+			"filter":  "region = 'eu' and id >= 5 and id < 25",
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 10 || rows[0]["id"] != int64(5) || rows[9]["id"] != int64(24) || len(rows[0]) != 1 {
+			t.Errorf("expected ids 5 to 9 and 20 to 24, got %v", rows)
+		}
+
+		rows, err = p.Read(dir, map[string]interface{}{"filter": "region = 'apac'"})
+		if err != nil || len(rows) != 0 {
+			t.Errorf("expected no rows, got %v, %v", rows, err)
+		}
+
+		// Filters are checked against every file, even when no file is left.
+		misspelled := map[string]interface{}{"filter": "region = 'apac' and idd > 1"}
+		if _, err := p.Read(dir, misspelled); err == nil || !strings.Contains(err.Error(), "idd") {
+			t.Errorf("expected an unknown column error, got %v", err)
+		}
+		err = p.ReadChunked(dir, 10, func([]map[string]interface{}) error { return nil }, misspelled)
+		if err == nil || !strings.Contains(err.Error(), "idd") {
+			t.Errorf("expected an unknown column error reading chunks, got %v", err)
+		}
+	})
+
+	t.Run("Schema and metadata", func(t *testing.T) {
+		schema, err := p.GetSchema(dir)
+		if err != nil {
+			t.Fatalf("GetSchema() error = %v", err)
+		}
+		for _, name := range []string{"date", "region"} {
+			if field := schemaField(schema, name); field == nil || field["optional"] != true {
+				t.Errorf("expected an optional %s column, got %v", name, field)
+			}
+		}
+
+		metadata, err := p.GetMetadata(dir)
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		if !slices.Equal(metadata["partitionColumns"].([]string), []string{"date", "region"}) {
+			t.Errorf("unexpected partition columns %v", metadata["partitionColumns"])
+		}
+		files := metadata["files"].([]map[string]interface{})
+		if partition := files[1]["partition"].(map[string]interface{}); partition["region"] != "us" {
+			t.Errorf("unexpected partition %v", partition)
+		}
+	})
+
+	t.Run("Conflicting columns", func(t *testing.T) {
+		dir := t.TempDir()
+		writePartFile(t, filepath.Join(dir, "extra=a", "part.parquet"), map[string]interface{}{
+			"id":    "int64",
+			"extra": "string",
+		}, 0, 5)
+
+		_, err := p.Read(dir)
+		if err == nil || !strings.Contains(err.Error(), `partition column "extra" conflicts`) {
+			t.Errorf("expected a partition conflict, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,402 @@
+package parquet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/grafana/sobek"
+	"go.k6.io/k6/js/common"
+)
+
+// defaultIteratorBatchSize is the number of rows an iterator decodes at a
+// time when no batchSize option is given.
+const defaultIteratorBatchSize = 1000
+
+// errIteratorClosed stops the reading of a closed iterator.
+var errIteratorClosed = errors.New("iterator closed")
+
+// Iterator reads the rows of a Parquet file, or of a dataset, as they are
+// consumed. A goroutine scans the files and hands the rows over in batches,
+// decoding at most one batch ahead, and keeps the files open until every row
+// has been read or the iterator is closed. Iterators are not safe for
+// concurrent use.
+type Iterator struct {
+	filename string
+	opts     ReadOptions
+	iteratorOptions
+	read func(string, int, ReadOptions, func([]map[string]interface{}, *fieldOrder) error) error
+
+	// ch carries the batches of the reading goroutine, started on the first
+	// read, and is closed once it is done with the files. The reading stops
+	// when ctx is done.
+	ctx     context.Context
+	ch      chan rowBatch
+	stop    chan struct{}
+	stopped chan struct{}
+
+	buffered []map[string]interface{}
+	order    *fieldOrder // field order of the rows
+	done     bool
+	onDone   func()
+}
+
+// rowBatch is a batch of rows with their field order, or the error that
+// ended the reading.
+type rowBatch struct {
+	rows  []map[string]interface{}
+	order *fieldOrder
+	err   error
+}
+
+// iteratorOptions are the options of iterate() besides the read options.
+type iteratorOptions struct {
+	batchSize int
+	batches   bool // yield batches rather than rows
+	resumable bool // leave the iterator open when a loop breaks
+}
+
+// parseIteratorOptions parses the batchSize, batches and resumable options
+// of iterate().
+func parseIteratorOptions(options ...map[string]interface{}) (iteratorOptions, error) {
+	o := iteratorOptions{batchSize: defaultIteratorBatchSize}
+	if len(options) == 0 || options[0] == nil {
+		return o, nil
+	}
+	if size, ok := intOption(options[0], "batchSize"); ok {
+		if size <= 0 {
+			return o, fmt.Errorf("invalid batchSize %d: must be positive", size)
+		}
+		o.batchSize = size
+	}
+	if b, ok := options[0]["batches"].(bool); ok {
+		o.batches = b
+	}
+	if r, ok := options[0]["resumable"].(bool); ok {
+		o.resumable = r
+	}
+	return o, nil
+}
+
+// Iterate returns an iterator over the rows of a Parquet file, a glob
+// pattern or a directory, selected by the same options as Read.
+func (p *Parquet) Iterate(filename string, options ...map[string]interface{}) (*Iterator, error) {
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	iterOpts, err := parseIteratorOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	it := &Iterator{
+		filename:        p.files.resolve(filename),
+		opts:            opts,
+		iteratorOptions: iterOpts,
+		read:            p.readChunked,
+	}
+	p.track(it)
+	return it, nil
+}
+
+// start starts the goroutine reading the batches, which stops when the
+// context is done. The context of a k6 VU ends with each iteration: an
+// iterator is closed with the iteration that starts reading it, unless it is
+// resumable, in which case it is only stopped by Close.
+func (it *Iterator) start(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if it.resumable {
+		ctx = context.WithoutCancel(ctx)
+	}
+	it.ctx = ctx
+	it.ch = make(chan rowBatch, 1)
+	it.stop = make(chan struct{})
+	it.stopped = make(chan struct{})
+
+	go func() {
+		defer close(it.stopped)
+		defer close(it.ch)
+
+		err := it.read(it.filename, it.batchSize, it.opts, func(rows []map[string]interface{}, order *fieldOrder) error {
+			select {
+			case it.ch <- rowBatch{rows: rows, order: order}:
+				return nil
+			case <-it.stop:
+				return errIteratorClosed
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		if err != nil && !errors.Is(err, errIteratorClosed) {
+			select {
+			case it.ch <- rowBatch{err: err}:
+			case <-it.stop:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	// The VU stops tracking the iterator once its context is done, even if
+	// the script never closes it.
+	context.AfterFunc(ctx, func() {
+		if it.onDone != nil {
+			it.onDone()
+		}
+	})
+}
+
+// expire closes an iterator whose context is done, dropping its buffered
+// rows.
+func (it *Iterator) expire() {
+	if it.ctx != nil && it.ctx.Err() != nil {
+		it.Close()
+	}
+}
+
+// needsBatch reports whether the next value requires reading a batch.
+func (it *Iterator) needsBatch() bool {
+	return len(it.buffered) == 0 && !it.done
+}
+
+// receive waits for the next batch of the reading goroutine.
+func (it *Iterator) receive() (rowBatch, bool) {
+	batch, ok := <-it.ch
+	return batch, ok
+}
+
+// apply buffers a received batch, or ends the iteration at the end of the
+// rows or on error. Batches received once the iterator is closed are
+// dropped.
+func (it *Iterator) apply(batch rowBatch, ok bool) error {
+	if it.done {
+		return nil
+	}
+	if !ok || batch.err != nil {
+		it.finish()
+		return batch.err
+	}
+	it.buffered = batch.rows
+	it.order = batch.order
+	return nil
+}
+
+// Next returns the next row, or the next batch of rows with the batches
+// option. It returns false once every row has been read, or once ctx is
+// done for iterators that are not resumable. The reading starts on the
+// first call.
+func (it *Iterator) Next(ctx context.Context) (interface{}, bool, error) {
+	it.expire()
+	if it.needsBatch() {
+		if it.ch == nil {
+			it.start(ctx)
+		}
+		if err := it.apply(it.receive()); err != nil {
+			return nil, false, err
+		}
+	}
+	return it.take()
+}
+
+// take returns the next buffered value.
+func (it *Iterator) take() (interface{}, bool, error) {
+	if len(it.buffered) == 0 {
+		return nil, false, nil
+	}
+	if it.batches {
+		batch := it.buffered
+		it.buffered = nil
+		return batch, true, nil
+	}
+	row := it.buffered[0]
+	it.buffered = it.buffered[1:]
+	return row, true, nil
+}
+
+// Close stops the reading and closes the files. Closing an iterator more
+// than once, or once every row has been read, has no effect.
+func (it *Iterator) Close() {
+	if it.ch != nil && !it.done {
+		close(it.stop)
+		<-it.stopped
+	}
+	it.buffered = nil
+	it.finish()
+}
+
+// finish marks the iteration as done.
+func (it *Iterator) finish() {
+	if it.done {
+		return
+	}
+	it.done = true
+	if it.onDone != nil {
+		it.onDone()
+	}
+}
+
+// track registers an iterator with the VU, which closes it on close().
+func (p *Parquet) track(it *Iterator) {
+	p.iteratorsMu.Lock()
+	defer p.iteratorsMu.Unlock()
+
+	if p.iterators == nil {
+		p.iterators = make(map[*Iterator]struct{})
+	}
+	p.iterators[it] = struct{}{}
+	it.onDone = func() {
+		p.iteratorsMu.Lock()
+		defer p.iteratorsMu.Unlock()
+		delete(p.iterators, it)
+	}
+}
+
+// closeIterators closes the iterators of the VU still reading.
+func (p *Parquet) closeIterators() {
+	p.iteratorsMu.Lock()
+	iterators := make([]*Iterator, 0, len(p.iterators))
+	for it := range p.iterators {
+		iterators = append(iterators, it)
+	}
+	p.iteratorsMu.Unlock()
+
+	for _, it := range iterators {
+		it.Close()
+	}
+}
+
+// iterateJS is the JS binding of Iterate.
+func (p *Parquet) iterateJS(filename string, options ...map[string]interface{}) (interface{}, error) {
+	it, err := p.Iterate(filename, options...)
+	if err != nil {
+		return nil, err
+	}
+	return p.iteratorObject(it), nil
+}
+
+// iteratorObject exposes an iterator to JS. It implements the iterator
+// protocol for for...of loops, whose break closes the iterator, or leaves it
+// open with the resumable option so that a later loop resumes at the next
+// row, and nextAsync() resolves the next result without blocking the event
+// loop while a batch is decoded. The object is also an async iterator when
+// the runtime supports them.
+func (p *Parquet) iteratorObject(it *Iterator) *sobek.Object {
+	rt := p.vu.Runtime()
+	obj := rt.NewObject()
+
+	type waiter struct {
+		resolve, reject func(interface{}) error
+	}
+	var waiting []waiter
+	pending := false
+
+	result := func(value interface{}, ok bool) *sobek.Object {
+		r := rt.NewObject()
+		if ok {
+			_ = r.Set("value", p.export(value, it.order, &it.opts.ConvertOptions))
+		} else {
+			_ = r.Set("value", sobek.Undefined())
+		}
+		_ = r.Set("done", !ok)
+		return r
+	}
+
+	next := func() *sobek.Object {
+		if pending {
+			common.Throw(rt, errors.New("iterator has a pending nextAsync() call"))
+		}
+		value, ok, err := it.Next(p.vu.Context())
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		return result(value, ok)
+	}
+
+	// serve settles the waiting promises in order from the buffered rows,
+	// reading the next batch off the JS thread when needed.
+	var serve func()
+	serve = func() {
+		it.expire()
+		for len(waiting) > 0 && !pending {
+			if it.needsBatch() {
+				if it.ch == nil {
+					it.start(p.vu.Context())
+				}
+				pending = true
+				enqueue := p.vu.RegisterCallback()
+				go func() {
+					batch, ok := it.receive()
+					enqueue(func() error {
+						pending = false
+						if err := it.apply(batch, ok); err != nil {
+							w := waiting[0]
+							waiting = waiting[1:]
+							if err := w.reject(err); err != nil {
+								return err
+							}
+						}
+						serve()
+						return nil
+					})
+				}()
+				return
+			}
+
+			w := waiting[0]
+			waiting = waiting[1:]
+			value, ok, _ := it.take()
+			if err := w.resolve(result(value, ok)); err != nil {
+				common.Throw(rt, err)
+			}
+		}
+	}
+
+	nextAsync := func() *sobek.Promise {
+		promise, resolve, reject := rt.NewPromise()
+		waiting = append(waiting, waiter{resolve: resolve, reject: reject})
+		serve()
+		return promise
+	}
+
+	returnFn := func() *sobek.Object {
+		if !it.resumable {
+			it.Close()
+		}
+		return result(nil, false)
+	}
+
+	closeFn := func() {
+		it.Close()
+	}
+
+	for name, fn := range map[string]interface{}{
+		"next":      next,
+		"nextAsync": nextAsync,
+		"return":    returnFn,
+		"close":     closeFn,
+	} {
+		if err := obj.Set(name, fn); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	if err := obj.SetSymbol(sobek.SymIterator, func() *sobek.Object { return obj }); err != nil {
+		common.Throw(rt, err)
+	}
+
+	// Symbol.asyncIterator is only defined by runtimes supporting for await.
+	if sym, ok := rt.Get("Symbol").ToObject(rt).Get("asyncIterator").(*sobek.Symbol); ok {
+		asyncObj := rt.NewObject()
+		_ = asyncObj.Set("next", nextAsync)
+		_ = asyncObj.Set("return", func() *sobek.Promise {
+			promise, resolve, _ := rt.NewPromise()
+			_ = resolve(returnFn())
+			return promise
+		})
+		if err := obj.SetSymbol(sym, func() *sobek.Object { return asyncObj }); err != nil {
+			common.Throw(rt, err)
+		}
+	}
+	return obj
+}
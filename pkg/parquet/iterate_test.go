@@ -0,0 +1,267 @@
+package parquet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+func TestIterate(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+	ctx := context.Background()
+
+	t.Run("Rows are read as consumed", func(t *testing.T) {
+		p := &Parquet{cache: NewReaderCache()}
+		it, err := p.Iterate(filename, map[string]interface{}{"batchSize": 100, "filter": "id >= 1000"})
+		if err != nil {
+			t.Fatalf("Iterate() error = %v", err)
+		}
+
+		for i := 0; i < 150; i++ {
+			row, ok, err := it.Next(ctx)
+			if err != nil || !ok {
+				t.Fatalf("Next() = %v, %v", ok, err)
+			}
+			if id := row.(map[string]interface{})["id"]; id != int64(1000+i) {
+				t.Fatalf("expected id %d, got %v", 1000+i, id)
+			}
+		}
+
+		it.Close()
+		select {
+		case <-it.stopped:
+		default:
+			t.Error("expected the reading to be stopped")
+		}
+		if _, ok, err := it.Next(ctx); ok || err != nil {
+			t.Errorf("expected no rows after Close(), got %v, %v", ok, err)
+		}
+		if len(p.iterators) != 0 {
+			t.Errorf("expected the iterator to be untracked, got %d", len(p.iterators))
+		}
+	})
+
+	t.Run("Batches", func(t *testing.T) {
+		p := &Parquet{cache: NewReaderCache()}
+		it, err := p.Iterate(filename, map[string]interface{}{"batchSize": 1000, "batches": true, "rowLimit": 2500})
+		if err != nil {
+			t.Fatalf("Iterate() error = %v", err)
+		}
+
+		var sizes []int
+		for {
+			batch, ok, err := it.Next(ctx)
+			if err != nil {
+				t.Fatalf("Next() error = %v", err)
+			}
+			if !ok {
+				break
+			}
+			sizes = append(sizes, len(batch.([]map[string]interface{})))
+		}
+		if len(sizes) != 3 || sizes[0] != 1000 || sizes[2] != 500 {
+			t.Errorf("expected batches of 1000, 1000 and 500 rows, got %v", sizes)
+		}
+		if len(p.iterators) != 0 {
+			t.Errorf("expected exhausted iterators to be untracked, got %d", len(p.iterators))
+		}
+	})
+
+	t.Run("Close() closes the iterators of the VU", func(t *testing.T) {
+		p := &Parquet{cache: NewReaderCache()}
+		it, err := p.Iterate(filename, map[string]interface{}{"batchSize": 10})
+		if err != nil {
+			t.Fatalf("Iterate() error = %v", err)
+		}
+		if _, _, err := it.Next(ctx); err != nil {
+			t.Fatalf("Next() error = %v", err)
+		}
+
+		if err := p.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if !it.done {
+			t.Error("expected the iterator to be closed")
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		p := &Parquet{cache: NewReaderCache()}
+		if _, err := p.Iterate(filename, map[string]interface{}{"batchSize": 0}); err == nil {
+			t.Error("expected an error for a batch size of 0")
+		}
+
+		it, err := p.Iterate("missing.parquet")
+		if err != nil {
+			t.Fatalf("Iterate() error = %v", err)
+		}
+		if _, _, err := it.Next(ctx); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+		if _, ok, err := it.Next(ctx); ok || err != nil {
+			t.Errorf("expected the iteration to be done, got %v, %v", ok, err)
+		}
+	})
+}
+
+func TestIterateJS(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	t.Run("for...of resumes after break", func(t *testing.T) {
+		result := runAsync(t, filename, `
+			const it = parquet.iterate(filename, { batchSize: 64, columns: ['id'], resumable: true });
+			const ids = [];
+			for (const row of it) {
+				ids.push(row.id);
+				if (ids.length === 10) break;
+			}
+			for (const row of it) {
+				ids.push(row.id);
+				if (ids.length === 20) break;
+			}
+			it.close();
+			result = [ids[10], ids.length, it.next().done].join(',');
+		`)
+		if result != "10,20,true" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+
+	t.Run("break closes the iterator", func(t *testing.T) {
+		runtime := modulestest.NewRuntime(t)
+		p := New().NewModuleInstance(runtime.VU).(*Parquet)
+		rt := runtime.VU.Runtime()
+		if err := rt.Set("parquet", p.Exports().Named); err != nil {
+			t.Fatalf("failed to set exports: %v", err)
+		}
+		if err := rt.Set("filename", filename); err != nil {
+			t.Fatalf("failed to set filename: %v", err)
+		}
+
+		if _, err := rt.RunString(`
+			var it = parquet.iterate(filename, { batchSize: 64 });
+			it.next();
+		`); err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		if len(p.iterators) != 1 {
+			t.Fatalf("expected 1 open iterator, got %d", len(p.iterators))
+		}
+		var it *Iterator
+		for open := range p.iterators {
+			it = open
+		}
+
+		v, err := rt.RunString(`
+			const ids = [];
+			for (const row of it) {
+				ids.push(row.id);
+				if (ids.length === 10) break;
+			}
+			for (const row of it) {
+				ids.push(row.id);
+			}
+			[ids.length, it.next().done].join(',');
+		`)
+		if err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		if v.String() != "10,true" {
+			t.Errorf("unexpected result: %s", v)
+		}
+
+		// The reading goroutine has returned, closing the file, and the
+		// iterator is no longer tracked by the VU.
+		select {
+		case <-it.stopped:
+		default:
+			t.Error("expected the reading to be stopped")
+		}
+		if len(p.iterators) != 0 {
+			t.Errorf("expected the iterator to be untracked, got %d", len(p.iterators))
+		}
+	})
+
+	t.Run("iteration end closes the iterator", func(t *testing.T) {
+		runtime := modulestest.NewRuntime(t)
+		p := New().NewModuleInstance(runtime.VU).(*Parquet)
+		rt := runtime.VU.Runtime()
+		if err := rt.Set("parquet", p.Exports().Named); err != nil {
+			t.Fatalf("failed to set exports: %v", err)
+		}
+		if err := rt.Set("filename", filename); err != nil {
+			t.Fatalf("failed to set filename: %v", err)
+		}
+
+		// Each iteration of a k6 VU runs with its own context.
+		iteration, end := context.WithCancel(context.Background())
+		runtime.VU.CtxField = iteration
+		if _, err := rt.RunString(`
+			var partial = parquet.iterate(filename, { batchSize: 64 });
+			var resumable = parquet.iterate(filename, { batchSize: 64, resumable: true });
+			partial.next();
+			resumable.next();
+		`); err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		var partial, resumable *Iterator
+		for it := range p.iterators {
+			if it.resumable {
+				resumable = it
+			} else {
+				partial = it
+			}
+		}
+		end()
+
+		// The partly consumed iterator is released without being closed.
+		select {
+		case <-partial.stopped:
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected the reading to stop with the iteration")
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			p.iteratorsMu.Lock()
+			_, tracked := p.iterators[partial]
+			p.iteratorsMu.Unlock()
+			if !tracked {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatal("expected the iterator to be untracked")
+			}
+			time.Sleep(time.Millisecond)
+		}
+
+		runtime.VU.CtxField = context.Background()
+		v, err := rt.RunString(`[partial.next().done, resumable.next().value.id].join(',')`)
+		if err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+		if v.String() != "true,1" {
+			t.Errorf("unexpected result: %s", v)
+		}
+		resumable.Close()
+	})
+
+	t.Run("nextAsync", func(t *testing.T) {
+		result := runAsync(t, filename, `
+			(async () => {
+				const it = parquet.iterate(filename, { batchSize: 500, batches: true, skipRows: 100 });
+				const pending = [it.nextAsync(), it.nextAsync()];
+				const [first, second] = await Promise.all(pending);
+				let count = first.value.length + second.value.length, r;
+				while (!(r = await it.nextAsync()).done) {
+					count += r.value.length;
+				}
+				result = [first.value[0].id, second.value[0].id, count].join(',');
+			})();
+		`)
+		if result != "100,600,2900" {
+			t.Errorf("unexpected result: %s", result)
+		}
+	})
+}
@@ -1,21 +1,59 @@
 package parquet
 
 import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+
 	"go.k6.io/k6/js/modules"
+	"go.k6.io/k6/output"
 )
 
+// cacheMemoryEnv is the environment variable setting the memory budget of
+// the shared reader cache, e.g. "512MB".
+const cacheMemoryEnv = "K6_PARQUET_CACHE_MEMORY"
+
 func init() {
-	modules.Register("k6/x/parquet", new(RootModule))
+	modules.Register("k6/x/parquet", New())
+	output.RegisterExtension("parquet", NewOutput)
 }
 
 // RootModule is the global module instance that will create module
-// instances for each VU.
-type RootModule struct{}
+// instances for each VU. It owns the state shared by all VUs.
+type RootModule struct {
+	cache     *ReaderCache
+	datasets  *datasetRegistry
+	cursors   *cursorRegistry
+	configure sync.Once
+}
 
 // Parquet represents an instance of the module for every VU.
 type Parquet struct {
-	vu    modules.VU
-	cache *ReaderCache
+	vu       modules.VU
+	cache    *ReaderCache
+	datasets *datasetRegistry
+	cursors  *cursorRegistry
+	files    *fileSystem
+
+	// precisionWarned is set once a precision loss of INT64 values has been
+	// reported.
+	precisionWarned atomic.Bool
+
+	// held tracks the cache entries referenced by this VU, which are
+	// released once heldCtx, the context of the VU when they were taken, is
+	// done.
+	held    map[string]struct{}
+	heldCtx context.Context
+	heldMu  sync.Mutex
+
+	// iterators tracks the iterators of this VU that are still reading.
+	iterators   map[*Iterator]struct{}
+	iteratorsMu sync.Mutex
+
+	// rands holds the generators of seeded samples of this VU.
+	rands   map[randKey]*rand.Rand
+	randsMu sync.Mutex
 }
 
 // Ensure the interfaces are implemented correctly.
@@ -24,25 +62,145 @@ var (
 	_ modules.Module   = &RootModule{}
 )
 
+// New returns a new RootModule with a reader cache shared by all VUs.
+func New() *RootModule {
+	return &RootModule{
+		cache:    NewReaderCache(),
+		datasets: newDatasetRegistry(),
+		cursors:  newCursorRegistry(),
+	}
+}
+
 // NewModuleInstance implements the modules.Module interface and returns
 // a new instance for each VU.
-func (*RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+func (r *RootModule) NewModuleInstance(vu modules.VU) modules.Instance {
+	r.configure.Do(func() {
+		r.configureCache(vu)
+	})
+
 	return &Parquet{
-		vu:    vu,
-		cache: NewReaderCache(),
+		vu:       vu,
+		cache:    r.cache,
+		datasets: r.datasets,
+		cursors:  r.cursors,
+		files:    newFileSystem(vu),
 	}
 }
 
+// configureCache applies the cache settings from the environment.
+func (r *RootModule) configureCache(vu modules.VU) {
+	env := vu.InitEnv()
+	if env == nil || env.LookupEnv == nil {
+		return
+	}
+
+	value, ok := env.LookupEnv(cacheMemoryEnv)
+	if !ok {
+		return
+	}
+
+	maxMemory, err := parseByteSize(value)
+	if err != nil {
+		if env.Logger != nil {
+			env.Logger.WithError(err).Warnf("Ignoring %s", cacheMemoryEnv)
+		}
+		return
+	}
+	r.cache.SetMaxMemory(maxMemory)
+}
+
 // Exports implements the modules.Instance interface and returns
 // the exports of the JS module.
 func (p *Parquet) Exports() modules.Exports {
 	return modules.Exports{
 		Named: map[string]interface{}{
-			"read":        p.Read,
-			"readChunked": p.ReadChunked,
-			"getSchema":   p.GetSchema,
-			"getMetadata": p.GetMetadata,
-			"close":       p.Close,
+			"read":              p.readJS,
+			"readChunked":       p.readChunkedJS,
+			"readAsync":         p.readAsync,
+			"readChunkedAsync":  p.readChunkedAsync,
+			"readBuffer":        p.readBufferJS,
+			"readColumns":       p.readColumnsJS,
+			"open":              p.Open,
+			"cursor":            p.Cursor,
+			"iterate":           p.iterateJS,
+			"sample":            p.sampleJS,
+			"writer":            p.writerJS,
+			"getSchema":         p.GetSchema,
+			"getMetadata":       p.getMetadataJS,
+			"getMetadataAsync":  p.getMetadataAsync,
+			"getBufferSchema":   p.getBufferSchemaJS,
+			"getBufferMetadata": p.getBufferMetadataJS,
+			"close":             p.Close,
 		},
 	}
 }
+
+// readJS is the JS binding of Read.
+func (p *Parquet) readJS(filename string, options ...map[string]interface{}) (interface{}, error) {
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	rows, order, err := p.read(p.files.resolve(filename), opts)
+	if err != nil {
+		return nil, err
+	}
+	return p.export(rows, order, &opts.ConvertOptions), nil
+}
+
+// readChunkedJS is the JS binding of ReadChunked.
+func (p *Parquet) readChunkedJS(
+	filename string, chunkSize int, callback func(interface{}) error, options ...map[string]interface{},
+) error {
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return err
+	}
+	return p.readChunked(p.files.resolve(filename), chunkSize, opts, func(chunk []map[string]interface{}, order *fieldOrder) error {
+		return callback(p.export(chunk, order, &opts.ConvertOptions))
+	})
+}
+
+// acquire references a cache entry on behalf of this VU, once per key.
+func (p *Parquet) acquire(key string) {
+	p.heldMu.Lock()
+	defer p.heldMu.Unlock()
+
+	if _, ok := p.held[key]; ok {
+		return
+	}
+	if p.cache.Acquire(key) {
+		if p.held == nil {
+			p.held = make(map[string]struct{})
+		}
+		p.held[key] = struct{}{}
+		p.releaseWhenDone()
+	}
+}
+
+// releaseWhenDone releases the cache references of this VU once its current
+// context is done. The context of a k6 VU ends with each iteration and when
+// the VU stops, so that references do not outlive the code using the rows
+// unless the script calls close(). Callers must hold heldMu.
+func (p *Parquet) releaseWhenDone() {
+	if p.vu == nil {
+		return
+	}
+	ctx := p.vu.Context()
+	if ctx == nil || ctx == p.heldCtx {
+		return
+	}
+	p.heldCtx = ctx
+	context.AfterFunc(ctx, p.releaseAll)
+}
+
+// releaseAll drops every cache reference held by this VU.
+func (p *Parquet) releaseAll() {
+	p.heldMu.Lock()
+	defer p.heldMu.Unlock()
+
+	for key := range p.held {
+		p.cache.Release(key)
+	}
+	p.held = nil
+}
@@ -0,0 +1,158 @@
+package parquet
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+func TestRootModuleSharesCache(t *testing.T) {
+	filename := createTestParquetFile(t)
+	root := New()
+
+	first, ok := root.NewModuleInstance(modulestest.NewRuntime(t).VU).(*Parquet)
+	if !ok {
+		t.Fatal("expected a *Parquet instance")
+	}
+	second, ok := root.NewModuleInstance(modulestest.NewRuntime(t).VU).(*Parquet)
+	if !ok {
+		t.Fatal("expected a *Parquet instance")
+	}
+
+	if first.cache != second.cache {
+		t.Fatal("expected VUs to share the same cache")
+	}
+
+	rows1, err := first.Read(filename)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	rows2, err := second.Read(filename)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+
+	// Both VUs get the same decoded rows.
+	if &rows1[0] != &rows2[0] {
+		t.Error("expected the second VU to be served from the shared cache")
+	}
+
+	opts, _ := parseReadOptions()
+	key := opts.cacheKey(filename)
+
+	// Closing one VU keeps the entry referenced by the other one.
+	if err := first.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, found := root.cache.Get(key); !found {
+		t.Error("expected entry still referenced by a VU to be kept")
+	}
+
+	if err := second.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if _, found := root.cache.Get(key); found {
+		t.Error("expected unreferenced entry to be cleared")
+	}
+}
+
+func TestRootModuleReleasesCacheWhenContextEnds(t *testing.T) {
+	filename := createTestParquetFile(t)
+	root := New()
+	runtime := modulestest.NewRuntime(t)
+	p, ok := root.NewModuleInstance(runtime.VU).(*Parquet)
+	if !ok {
+		t.Fatal("expected a *Parquet instance")
+	}
+
+	opts, _ := parseReadOptions()
+	key := opts.cacheKey(filename)
+	refs := func() int {
+		root.cache.mu.RLock()
+		defer root.cache.mu.RUnlock()
+		return root.cache.cache[key].refs
+	}
+
+	// Each iteration of a k6 VU runs with its own context, and the script
+	// never calls close().
+	for i := 0; i < 2; i++ {
+		iteration, end := context.WithCancel(context.Background())
+		runtime.VU.CtxField = iteration
+		if _, err := p.Read(filename); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if refs() != 1 {
+			t.Fatalf("expected the entry to be referenced once, got %d", refs())
+		}
+		end()
+
+		deadline := time.Now().Add(5 * time.Second)
+		for refs() != 0 {
+			if time.Now().After(deadline) {
+				t.Fatalf("expected the reference to be released, got %d", refs())
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	root.cache.ClearUnused()
+	if _, found := root.cache.Get(key); found {
+		t.Error("expected the released entry to be cleared")
+	}
+}
+
+func TestRootModuleCacheEvictsWithoutClose(t *testing.T) {
+	root := New()
+	p, ok := root.NewModuleInstance(modulestest.NewRuntime(t).VU).(*Parquet)
+	if !ok {
+		t.Fatal("expected a *Parquet instance")
+	}
+
+	opts, _ := parseReadOptions()
+	filenames := []string{createTestParquetFile(t), createTestParquetFile(t), createTestParquetFile(t)}
+	rows, err := p.Read(filenames[0])
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	budget := 2 * estimateSize(rows)
+	root.cache.SetMaxMemory(budget)
+
+	// The VU reads more files than fit in the budget and never calls close():
+	// the entries it references are evicted to make room for new reads.
+	for _, filename := range filenames[1:] {
+		if _, err := p.Read(filename); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if _, found := root.cache.Get(opts.cacheKey(filename)); !found {
+			t.Errorf("expected %s to be cached", filename)
+		}
+		if root.cache.MemoryUsage() > budget {
+			t.Errorf("expected memory usage within %d, got %d", budget, root.cache.MemoryUsage())
+		}
+	}
+	if _, found := root.cache.Get(opts.cacheKey(filenames[0])); found {
+		t.Error("expected the least recently used entry to be evicted")
+	}
+	if rows[0]["id"] == nil {
+		t.Error("expected evicted rows to remain usable")
+	}
+}
+
+func TestRootModuleCacheMemoryEnv(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	runtime.VU.InitEnvField.LookupEnv = func(key string) (string, bool) {
+		if key == cacheMemoryEnv {
+			return "64MB", true
+		}
+		return "", false
+	}
+
+	root := New()
+	root.NewModuleInstance(runtime.VU)
+
+	if root.cache.maxMemory != 64<<20 {
+		t.Errorf("expected memory budget of 64MB, got %d", root.cache.maxMemory)
+	}
+}
@@ -0,0 +1,354 @@
+package parquet
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/sirupsen/logrus"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// Environment variables configuring the output, overridden by the
+// --out parquet=... argument.
+var outputEnv = map[string]string{
+	"K6_PARQUET_FILENAME":       "fileName",
+	"K6_PARQUET_COMPRESSION":    "compression",
+	"K6_PARQUET_ROLL_SIZE":      "rollSize",
+	"K6_PARQUET_ROLL_INTERVAL":  "rollInterval",
+	"K6_PARQUET_FLUSH_INTERVAL": "flushInterval",
+	"K6_PARQUET_ROW_GROUP_SIZE": "rowGroupSize",
+}
+
+// OutputConfig defines the options of the Parquet output.
+type OutputConfig struct {
+	FileName      string        // Path of the output file
+	Compression   string        // Compression codec of the column chunks
+	RollSize      int64         // Size after which a new file is started (0 to disable)
+	RollInterval  time.Duration // Age after which a new file is started (0 to disable)
+	FlushInterval time.Duration // Interval between writes of the buffered samples
+	RowGroupSize  int64         // Maximum number of samples per row group
+}
+
+// newOutputConfig returns the default output configuration.
+func newOutputConfig() OutputConfig {
+	return OutputConfig{
+		FileName:      "results.parquet",
+		Compression:   "zstd",
+		FlushInterval: time.Second,
+		RowGroupSize:  100000,
+	}
+}
+
+// set sets an option of the configuration from its string value.
+func (c *OutputConfig) set(key, value string) error {
+	var err error
+	switch key {
+	case "fileName", "file":
+		c.FileName = value
+	case "compression":
+		if _, err = compressionCodec(value); err == nil {
+			c.Compression = value
+		}
+	case "rollSize":
+		c.RollSize, err = parseByteSize(value)
+	case "rollInterval":
+		c.RollInterval, err = time.ParseDuration(value)
+	case "flushInterval":
+		c.FlushInterval, err = time.ParseDuration(value)
+	case "rowGroupSize":
+		c.RowGroupSize, err = strconv.ParseInt(value, 10, 64)
+	default:
+		return fmt.Errorf("unknown parquet output option %q", key)
+	}
+	if err != nil {
+		return fmt.Errorf("invalid parquet output option %s=%q: %w", key, value, err)
+	}
+	return nil
+}
+
+// isOutputOption reports whether key is the name of an output option.
+func isOutputOption(key string) bool {
+	switch key {
+	case "fileName", "file", "compression", "rollSize", "rollInterval", "flushInterval", "rowGroupSize":
+		return true
+	}
+	return false
+}
+
+// parseOutputConfig combines the defaults, the JSON configuration, the
+// environment and the --out argument, each overriding the previous ones. The
+// argument is comma-separated key=value options when it starts with the name
+// of an option, and a file name otherwise, which may contain "=".
+func parseOutputConfig(params output.Params) (OutputConfig, error) {
+	c := newOutputConfig()
+
+	if len(params.JSONConfig) > 0 {
+		var options map[string]interface{}
+		if err := json.Unmarshal(params.JSONConfig, &options); err != nil {
+			return c, fmt.Errorf("failed to parse parquet output config: %w", err)
+		}
+		for key, value := range options {
+			text := fmt.Sprint(value)
+			// JSON numbers decode as float64, which fmt.Sprint formats
+			// with an exponent from 1e6 on, such as 1e+06.
+			if number, ok := value.(float64); ok {
+				text = strconv.FormatFloat(number, 'f', -1, 64)
+			}
+			if err := c.set(key, text); err != nil {
+				return c, err
+			}
+		}
+	}
+
+	for env, key := range outputEnv {
+		if value, ok := params.Environment[env]; ok {
+			if err := c.set(key, value); err != nil {
+				return c, err
+			}
+		}
+	}
+
+	if arg := params.ConfigArgument; arg != "" {
+		if key, _, ok := strings.Cut(arg, "="); !ok || !isOutputOption(key) {
+			c.FileName = arg
+		} else {
+			for _, pair := range strings.Split(arg, ",") {
+				key, value, _ := strings.Cut(pair, "=")
+				if err := c.set(key, value); err != nil {
+					return c, err
+				}
+			}
+		}
+	}
+
+	if c.FlushInterval <= 0 {
+		return c, fmt.Errorf("invalid parquet output flushInterval %s", c.FlushInterval)
+	}
+	if c.RowGroupSize <= 0 {
+		return c, fmt.Errorf("invalid parquet output rowGroupSize %d", c.RowGroupSize)
+	}
+	return c, nil
+}
+
+// sampleRow is the row written for each metric sample.
+type sampleRow struct {
+	Metric    string            `parquet:"metric,dict"`
+	Type      string            `parquet:"type,dict"`
+	Timestamp time.Time         `parquet:"timestamp,timestamp(microsecond)"`
+	Value     float64           `parquet:"value"`
+	Tags      map[string]string `parquet:"tags"`
+	Metadata  map[string]string `parquet:"metadata"`
+}
+
+// countingWriter counts the bytes written to the current file.
+type countingWriter struct {
+	io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.Writer.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+// Output is a k6 output writing metric samples to Parquet files. Samples are
+// buffered and written periodically; with rolling enabled, a new numbered
+// file is started once the current one reaches the roll size or age.
+type Output struct {
+	output.SampleBuffer
+
+	config  OutputConfig
+	fs      fsext.Fs
+	logger  logrus.FieldLogger
+	flusher *output.PeriodicFlusher
+
+	// Current file, its writer, when it was created and the number of
+	// samples not yet flushed as a row group.
+	file     io.WriteCloser
+	counter  *countingWriter
+	writer   *parquet.GenericWriter[sampleRow]
+	opened   time.Time
+	buffered int64
+	files    int
+}
+
+// Ensure the interface is implemented correctly.
+var _ output.Output = &Output{}
+
+// NewOutput creates the Parquet output from the k6 output parameters.
+func NewOutput(params output.Params) (output.Output, error) {
+	config, err := parseOutputConfig(params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Output{
+		config: config,
+		fs:     params.FS,
+		logger: params.Logger.WithFields(logrus.Fields{"output": "parquet", "filename": config.FileName}),
+	}, nil
+}
+
+// Description returns a human-readable description of the output.
+func (o *Output) Description() string {
+	return fmt.Sprintf("parquet (%s)", o.config.FileName)
+}
+
+// Start creates the first file and starts flushing samples periodically.
+func (o *Output) Start() error {
+	if err := o.openFile(); err != nil {
+		return err
+	}
+
+	flusher, err := output.NewPeriodicFlusher(o.config.FlushInterval, o.flushMetrics)
+	if err != nil {
+		return err
+	}
+	o.flusher = flusher
+	return nil
+}
+
+// Stop writes the remaining samples and closes the current file.
+func (o *Output) Stop() error {
+	if o.flusher != nil {
+		o.flusher.Stop()
+	}
+	return o.closeFile()
+}
+
+// flushMetrics writes the buffered samples, then closes the current file if
+// it is due for rolling. The next file is created with the next samples.
+func (o *Output) flushMetrics() {
+	containers := o.GetBufferedSamples()
+	if len(containers) == 0 {
+		return
+	}
+
+	if o.writer == nil {
+		if err := o.openFile(); err != nil {
+			o.logger.WithError(err).Error("Failed to roll the output file")
+			return
+		}
+	}
+
+	var rows []sampleRow
+	for _, container := range containers {
+		for _, sample := range container.GetSamples() {
+			rows = append(rows, sampleToRow(sample))
+		}
+	}
+	if _, err := o.writer.Write(rows); err != nil {
+		o.logger.WithError(err).Error("Failed to write metric samples")
+		return
+	}
+
+	// Full row groups are written out right away so that the file size
+	// reflects the samples written so far.
+	o.buffered += int64(len(rows))
+	if o.buffered >= o.config.RowGroupSize {
+		if err := o.writer.Flush(); err != nil {
+			o.logger.WithError(err).Error("Failed to flush metric samples")
+			return
+		}
+		o.buffered = 0
+	}
+
+	if o.shouldRoll() {
+		if err := o.closeFile(); err != nil {
+			o.logger.WithError(err).Error("Failed to close the output file")
+		}
+	}
+}
+
+// sampleToRow converts a metric sample to its row.
+func sampleToRow(sample metrics.Sample) sampleRow {
+	row := sampleRow{
+		Timestamp: sample.Time,
+		Value:     sample.Value,
+		Metadata:  sample.Metadata,
+	}
+	if sample.Metric != nil {
+		row.Metric = sample.Metric.Name
+		row.Type = sample.Metric.Type.String()
+	}
+	if sample.Tags != nil {
+		row.Tags = sample.Tags.Map()
+	}
+	return row
+}
+
+// shouldRoll reports whether the current file reached the roll size or age.
+// The size only accounts for the row groups already written to the file.
+func (o *Output) shouldRoll() bool {
+	if o.config.RollSize > 0 && o.counter.n >= o.config.RollSize {
+		return true
+	}
+	return o.config.RollInterval > 0 && time.Since(o.opened) >= o.config.RollInterval
+}
+
+// rolling reports whether samples are spread over numbered files.
+func (o *Output) rolling() bool {
+	return o.config.RollSize > 0 || o.config.RollInterval > 0
+}
+
+// openFile creates the next file.
+func (o *Output) openFile() error {
+	filename := o.config.FileName
+	if o.rolling() {
+		filename = rolledFileName(filename, o.files)
+	}
+	file, err := o.fs.Create(filename)
+	if err != nil {
+		return fmt.Errorf("failed to create file: %w", err)
+	}
+
+	codec, _ := compressionCodec(o.config.Compression)
+	o.file = file
+	o.counter = &countingWriter{Writer: file}
+	// Row groups are written unbuffered so that the counted size is the size
+	// of the file.
+	o.writer = parquet.NewGenericWriter[sampleRow](o.counter,
+		parquet.Compression(codec),
+		parquet.MaxRowsPerRowGroup(o.config.RowGroupSize),
+		parquet.WriteBufferSize(0),
+	)
+	o.opened = time.Now()
+	o.buffered = 0
+	o.files++
+	return nil
+}
+
+// closeFile writes the footer of the current file and closes it.
+func (o *Output) closeFile() error {
+	if o.writer == nil {
+		return nil
+	}
+	defer func() {
+		o.writer, o.file, o.counter = nil, nil, nil
+	}()
+
+	if err := o.writer.Close(); err != nil {
+		o.file.Close()
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	if err := o.file.Close(); err != nil {
+		return fmt.Errorf("failed to close file: %w", err)
+	}
+	return nil
+}
+
+// rolledFileName returns the name of the n-th rolled file, numbered before
+// the extension: results.parquet becomes results-0000.parquet.
+func rolledFileName(filename string, n int) string {
+	ext := filepath.Ext(filename)
+	return fmt.Sprintf("%s-%04d%s", strings.TrimSuffix(filename, ext), n, ext)
+}
@@ -0,0 +1,215 @@
+package parquet
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.k6.io/k6/lib/fsext"
+	"go.k6.io/k6/metrics"
+	"go.k6.io/k6/output"
+)
+
+// newTestOutput creates a Parquet output writing to a temporary directory.
+func newTestOutput(t *testing.T, arg string) (*Output, string) {
+	t.Helper()
+
+	dir := t.TempDir()
+	out, err := NewOutput(output.Params{
+		ConfigArgument: arg,
+		Environment:    map[string]string{"K6_PARQUET_FILENAME": filepath.Join(dir, "results.parquet")},
+		FS:             fsext.NewOsFs(),
+		Logger:         logrus.New(),
+	})
+	if err != nil {
+		t.Fatalf("NewOutput() error = %v", err)
+	}
+	return out.(*Output), dir
+}
+
+// testSamples returns n samples of a trend metric with tags and metadata.
+func testSamples(t *testing.T, n int) []metrics.SampleContainer {
+	t.Helper()
+
+	registry := metrics.NewRegistry()
+	metric, err := registry.NewMetric("http_req_duration", metrics.Trend, metrics.Time)
+	if err != nil {
+		t.Fatalf("NewMetric() error = %v", err)
+	}
+	tags := registry.RootTagSet().With("method", "GET")
+
+	samples := make([]metrics.SampleContainer, n)
+	for i := range samples {
+		samples[i] = metrics.Sample{
+			TimeSeries: metrics.TimeSeries{Metric: metric, Tags: tags},
+			Time:       time.UnixMilli(int64(i)),
+			Value:      float64(i),
+			Metadata:   map[string]string{"trace_id": "abc"},
+		}
+	}
+	return samples
+}
+
+func TestParseOutputConfig(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		c, err := parseOutputConfig(output.Params{})
+		if err != nil {
+			t.Fatalf("parseOutputConfig() error = %v", err)
+		}
+		if c != newOutputConfig() {
+			t.Errorf("expected defaults, got %+v", c)
+		}
+	})
+
+	t.Run("Argument overrides environment and JSON", func(t *testing.T) {
+		c, err := parseOutputConfig(output.Params{
+			JSONConfig:     json.RawMessage(`{"fileName": "json.parquet", "rollInterval": "1h"}`),
+			Environment:    map[string]string{"K6_PARQUET_FILENAME": "env.parquet", "K6_PARQUET_COMPRESSION": "gzip"},
+			ConfigArgument: "fileName=arg.parquet,rollSize=10MB,compression=snappy",
+		})
+		if err != nil {
+			t.Fatalf("parseOutputConfig() error = %v", err)
+		}
+		if c.FileName != "arg.parquet" || c.Compression != "snappy" || c.RollSize != 10<<20 || c.RollInterval != time.Hour {
+			t.Errorf("unexpected config %+v", c)
+		}
+	})
+
+	t.Run("JSON numbers", func(t *testing.T) {
+		c, err := parseOutputConfig(output.Params{
+			JSONConfig: json.RawMessage(`{"rowGroupSize": 1000000, "rollSize": 1048576}`),
+		})
+		if err != nil {
+			t.Fatalf("parseOutputConfig() error = %v", err)
+		}
+		if c.RowGroupSize != 1000000 || c.RollSize != 1<<20 {
+			t.Errorf("unexpected config %+v", c)
+		}
+	})
+
+	t.Run("File name argument", func(t *testing.T) {
+		c, err := parseOutputConfig(output.Params{ConfigArgument: "out.parquet"})
+		if err != nil {
+			t.Fatalf("parseOutputConfig() error = %v", err)
+		}
+		if c.FileName != "out.parquet" {
+			t.Errorf("expected out.parquet, got %s", c.FileName)
+		}
+
+		c, err = parseOutputConfig(output.Params{ConfigArgument: "run=1.parquet"})
+		if err != nil {
+			t.Fatalf("parseOutputConfig() error = %v", err)
+		}
+		if c.FileName != "run=1.parquet" {
+			t.Errorf("expected run=1.parquet, got %s", c.FileName)
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		for _, arg := range []string{
+			"compression=rar",
+			"rollSize=big",
+			"rollInterval=soon",
+			"flushInterval=0s",
+			"rowGroupSize=0",
+			"compression=gzip,unknown=1",
+		} {
+			if _, err := parseOutputConfig(output.Params{ConfigArgument: arg}); err == nil {
+				t.Errorf("%s: expected an error", arg)
+			}
+		}
+	})
+}
+
+func TestOutput(t *testing.T) {
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Samples are written on stop", func(t *testing.T) {
+		out, dir := newTestOutput(t, "flushInterval=1h")
+		if err := out.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		out.AddMetricSamples(testSamples(t, 3))
+		if err := out.Stop(); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+
+		rows, err := p.Read(filepath.Join(dir, "results.parquet"))
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("expected 3 rows, got %d", len(rows))
+		}
+		row := rows[2]
+		if row["metric"] != "http_req_duration" || row["type"] != "trend" || row["value"] != 2.0 {
+			t.Errorf("unexpected row %v", row)
+		}
+		if ts, ok := row["timestamp"].(time.Time); !ok || !ts.Equal(time.UnixMilli(2)) {
+			t.Errorf("expected timestamp %v, got %v", time.UnixMilli(2), row["timestamp"])
+		}
+		if tags, _ := row["tags"].(map[string]interface{}); tags["method"] != "GET" {
+			t.Errorf("expected method tag, got %v", row["tags"])
+		}
+		if metadata, _ := row["metadata"].(map[string]interface{}); metadata["trace_id"] != "abc" {
+			t.Errorf("expected trace_id metadata, got %v", row["metadata"])
+		}
+	})
+
+	t.Run("Files are rolled by size", func(t *testing.T) {
+		out, dir := newTestOutput(t, "flushInterval=1h,rollSize=1B,rowGroupSize=2")
+		if err := out.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		// Each flush writes a full row group, exceeding the roll size.
+		for i := 0; i < 3; i++ {
+			out.AddMetricSamples(testSamples(t, 2))
+			out.flushMetrics()
+		}
+		if err := out.Stop(); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+
+		total := 0
+		for i := 0; i < 3; i++ {
+			rows, err := p.Read(filepath.Join(dir, rolledFileName("results.parquet", i)))
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			total += len(rows)
+		}
+		if total != 6 {
+			t.Errorf("expected 6 rows, got %d", total)
+		}
+		// The next file is only created with the next samples.
+		if _, err := os.Stat(filepath.Join(dir, rolledFileName("results.parquet", 3))); !os.IsNotExist(err) {
+			t.Error("expected no fourth file")
+		}
+	})
+
+	t.Run("Files are rolled by age", func(t *testing.T) {
+		out, dir := newTestOutput(t, "flushInterval=1h,rollInterval=1ns")
+		if err := out.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		out.AddMetricSamples(testSamples(t, 2))
+		out.flushMetrics()
+		if err := out.Stop(); err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+
+		rows, err := p.Read(filepath.Join(dir, rolledFileName("results.parquet", 0)))
+		if err != nil || len(rows) != 2 {
+			t.Errorf("expected 2 rows in the first file, got %d (%v)", len(rows), err)
+		}
+	})
+}
+
+func TestRolledFileName(t *testing.T) {
+	if got := rolledFileName("out/results.parquet", 12); got != "out/results-0012.parquet" {
+		t.Errorf("unexpected file name %s", got)
+	}
+}
@@ -0,0 +1,237 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+	"go.k6.io/k6/lib"
+)
+
+// Partition modes.
+const (
+	partitionContiguous = "contiguous"
+	partitionRoundRobin = "roundRobin"
+	partitionHash       = "hash"
+)
+
+// Partition consumers, used when the index and count are not given.
+const (
+	partitionByVU       = "vu"
+	partitionByInstance = "instance"
+)
+
+// partition selects the share of the rows of a file read by one of several
+// consumers, typically VUs. Rows are assigned by contiguous ranges, in turn,
+// or by hash of a key column. Contiguous and round-robin partitions assign
+// whole row groups when the file has at least as many row groups as
+// consumers, so that no consumer decodes the data of another one; otherwise
+// they assign individual rows.
+type partition struct {
+	mode   string
+	column string // key column of hash partitions
+	by     string // consumers the rows are shared by, when index and count are not given
+	index  int    // index of the consumer reading, in [0, count)
+	count  int    // number of consumers, 0 until resolved
+}
+
+// parsePartition parses the partition option: a mode name, or an object with
+// the mode, the key column of hash partitions, and either the consumers the
+// rows are shared by or an explicit index and count.
+func parsePartition(v interface{}) (*partition, error) {
+	p := &partition{mode: partitionContiguous, by: partitionByVU}
+
+	switch x := v.(type) {
+	case string:
+		p.mode = x
+	case map[string]interface{}:
+		if mode, ok := x["mode"].(string); ok {
+			p.mode = mode
+		}
+		if column, ok := x["column"].(string); ok {
+			p.column = column
+		}
+		if by, ok := x["by"].(string); ok {
+			p.by = by
+		}
+		index, hasIndex := intOption(x, "index")
+		count, hasCount := intOption(x, "count")
+		if hasIndex != hasCount {
+			return nil, errors.New("invalid partition: index and count must be given together")
+		}
+		if hasCount {
+			if count <= 0 || index < 0 || index >= count {
+				return nil, fmt.Errorf("invalid partition: index %d out of range for count %d", index, count)
+			}
+			p.index, p.count = index, count
+		}
+	default:
+		return nil, fmt.Errorf("invalid partition: expected a mode or an object, got %T", v)
+	}
+
+	switch p.mode {
+	case partitionContiguous, partitionRoundRobin:
+	case partitionHash:
+		if p.column == "" {
+			return nil, errors.New(`invalid partition: hash partitions require a "column"`)
+		}
+	default:
+		return nil, fmt.Errorf("invalid partition mode %q: expected %q, %q or %q",
+			p.mode, partitionContiguous, partitionRoundRobin, partitionHash)
+	}
+	if p.by != partitionByVU && p.by != partitionByInstance {
+		return nil, fmt.Errorf("invalid partition: expected by %q or %q, got %q",
+			partitionByVU, partitionByInstance, p.by)
+	}
+
+	return p, nil
+}
+
+// resolvePartition sets the index and count of a partition from the
+// execution state of the VU: VUs are numbered across all k6 instances, and
+// instances by their execution segment.
+func (p *Parquet) resolvePartition(part *partition) error {
+	if part == nil || part.count > 0 {
+		return nil
+	}
+
+	var state *lib.State
+	var es *lib.ExecutionState
+	if p.vu != nil {
+		state = p.vu.State()
+		es = lib.GetExecutionState(p.vu.Context())
+	}
+	if state == nil || es == nil {
+		return errors.New("partition requires a VU context: read from the default function, " +
+			"or give the partition index and count")
+	}
+
+	switch part.by {
+	case partitionByInstance:
+		part.index = es.ExecutionTuple.SegmentIndex
+		part.count = max(len(es.ExecutionTuple.Sequence.ExecutionSegmentSequence), 1)
+	default:
+		full, err := lib.NewExecutionTuple(nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to resolve partition: %w", err)
+		}
+		steps := es.Test.Options.Scenarios.GetFullExecutionRequirements(full)
+		part.count = int(lib.GetMaxPossibleVUs(steps)) //nolint:gosec
+		part.index = int(state.VUIDGlobal) - 1         //nolint:gosec
+	}
+
+	if part.index < 0 || part.index >= part.count {
+		return fmt.Errorf("failed to resolve partition: index %d out of range for count %d", part.index, part.count)
+	}
+	return nil
+}
+
+// validate checks the key column of hash partitions against the schema.
+func (part *partition) validate(schema *parquet.Schema) error {
+	if part.mode == partitionHash {
+		if _, ok := schema.Lookup(strings.Split(part.column, ".")...); !ok {
+			return fmt.Errorf("invalid partition: unknown column %q", part.column)
+		}
+	}
+	return nil
+}
+
+// String returns the canonical form of a resolved partition.
+func (part *partition) String() string {
+	mode := part.mode
+	if mode == partitionHash {
+		mode += "(" + part.column + ")"
+	}
+	return fmt.Sprintf("%s:%d/%d", mode, part.index, part.count)
+}
+
+// rowRanges returns the rows of the g-th row group of a file, starting at
+// the given row of the file, that belong to the partition.
+func (part *partition) rowRanges(pf *parquet.File, g int, start int64) ([]rowRange, error) {
+	rowGroups := pf.RowGroups()
+	numRows := rowGroups[g].NumRows()
+	count := int64(part.count)
+	index := int64(part.index)
+	aligned := len(rowGroups) >= part.count
+
+	switch part.mode {
+	case partitionContiguous:
+		if aligned {
+			if int64(g)*count/int64(len(rowGroups)) != index {
+				return nil, nil
+			}
+			return []rowRange{{0, numRows}}, nil
+		}
+		total := pf.NumRows()
+		r := rowRange{max(index*total/count-start, 0), min((index+1)*total/count-start, numRows)}
+		if r.start >= r.end {
+			return nil, nil
+		}
+		return []rowRange{r}, nil
+
+	case partitionRoundRobin:
+		if aligned {
+			if int64(g)%count != index {
+				return nil, nil
+			}
+			return []rowRange{{0, numRows}}, nil
+		}
+		var ranges []rowRange
+		for i := ((index-start)%count + count) % count; i < numRows; i += count {
+			ranges = append(ranges, rowRange{i, i + 1})
+		}
+		return ranges, nil
+
+	default:
+		return part.hashRanges(pf, rowGroups[g])
+	}
+}
+
+// hashRanges reads the key column of a row group and returns the rows whose
+// key hashes to the partition.
+func (part *partition) hashRanges(pf *parquet.File, rg parquet.RowGroup) ([]rowRange, error) {
+	proj := newProjection(pf.Schema(), []string{part.column})
+	decoder := newRecordDecoder(proj.schema)
+	path := strings.Split(part.column, ".")
+	// Keys are hashed from their raw values, whatever the read options.
+	opts := &ConvertOptions{Timestamps: timestampsRaw}
+
+	rows := proj.rowGroup(rg).Rows()
+	defer rows.Close()
+
+	var ranges []rowRange
+	buf := make([]parquet.Row, 1000)
+	for pos := int64(0); ; {
+		n, err := rows.ReadRows(buf)
+		for _, row := range buf[:n] {
+			if hashKey(lookupPath(decoder.decode(row, opts), path))%uint64(part.count) == uint64(part.index) {
+				ranges = appendRange(ranges, rowRange{pos, pos + 1})
+			}
+			pos++
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return ranges, nil
+			}
+			return nil, fmt.Errorf("failed to read partition keys: %w", err)
+		}
+		if n == 0 {
+			return ranges, nil
+		}
+	}
+}
+
+// hashKey hashes the textual form of a key value, or the bytes of a binary
+// key.
+func hashKey(v interface{}) uint64 {
+	h := fnv.New64a()
+	if b, ok := v.([]byte); ok {
+		_, _ = h.Write(b)
+	} else {
+		fmt.Fprint(h, v)
+	}
+	return h.Sum64()
+}
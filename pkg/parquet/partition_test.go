@@ -0,0 +1,241 @@
+package parquet
+
+import (
+	"testing"
+
+	"go.k6.io/k6/js/modulestest"
+	"go.k6.io/k6/lib"
+	"go.k6.io/k6/lib/executor"
+	"gopkg.in/guregu/null.v3"
+)
+
+func TestParsePartition(t *testing.T) {
+	t.Run("Valid partitions", func(t *testing.T) {
+		tests := []struct {
+			option   interface{}
+			expected partition
+		}{
+			{"roundRobin", partition{mode: partitionRoundRobin, by: partitionByVU}},
+			{map[string]interface{}{}, partition{mode: partitionContiguous, by: partitionByVU}},
+			{
+				map[string]interface{}{"mode": "hash", "column": "user.id", "by": "instance"},
+				partition{mode: partitionHash, column: "user.id", by: partitionByInstance},
+			},
+			{
+				map[string]interface{}{"index": int64(2), "count": float64(4)},
+				partition{mode: partitionContiguous, by: partitionByVU, index: 2, count: 4},
+			},
+		}
+		for _, tt := range tests {
+			got, err := parsePartition(tt.option)
+			if err != nil {
+				t.Errorf("%v: parsePartition() error = %v", tt.option, err)
+				continue
+			}
+			if *got != tt.expected {
+				t.Errorf("%v: expected %+v, got %+v", tt.option, tt.expected, *got)
+			}
+		}
+	})
+
+	t.Run("Invalid partitions", func(t *testing.T) {
+		for _, option := range []interface{}{
+			"random",
+			42,
+			map[string]interface{}{"mode": "hash"},
+			map[string]interface{}{"by": "scenario"},
+			map[string]interface{}{"index": 1},
+			map[string]interface{}{"index": 4, "count": 4},
+			map[string]interface{}{"index": 0, "count": 0},
+		} {
+			if _, err := parsePartition(option); err == nil {
+				t.Errorf("%v: expected an error", option)
+			}
+		}
+	})
+}
+
+func TestPartitionRead(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+	p := &Parquet{cache: NewReaderCache()}
+
+	readAll := func(t *testing.T, partition map[string]interface{}, count int) [][]int64 {
+		t.Helper()
+		ids := make([][]int64, count)
+		for index := 0; index < count; index++ {
+			partition["index"], partition["count"] = index, count
+			rows, err := p.Read(filename, map[string]interface{}{"partition": partition})
+			if err != nil {
+				t.Fatalf("Read() error = %v", err)
+			}
+			for _, row := range rows {
+				ids[index] = append(ids[index], row["id"].(int64))
+			}
+		}
+		return ids
+	}
+
+	checkDisjoint := func(t *testing.T, ids [][]int64) {
+		t.Helper()
+		seen := make(map[int64]bool)
+		for _, partition := range ids {
+			for _, id := range partition {
+				if seen[id] {
+					t.Fatalf("row %d read by several partitions", id)
+				}
+				seen[id] = true
+			}
+		}
+		if len(seen) != 3000 {
+			t.Errorf("expected 3000 rows over all partitions, got %d", len(seen))
+		}
+	}
+
+	t.Run("Contiguous partitions of row groups", func(t *testing.T) {
+		ids := readAll(t, map[string]interface{}{"mode": "contiguous"}, 3)
+		checkDisjoint(t, ids)
+		// Five row groups of 700 rows over three partitions: 2, 2 and 1.
+		for i, expected := range []int64{0, 1400, 2800} {
+			if ids[i][0] != expected {
+				t.Errorf("partition %d: expected first id %d, got %d", i, expected, ids[i][0])
+			}
+		}
+	})
+
+	t.Run("Contiguous partitions of rows", func(t *testing.T) {
+		ids := readAll(t, map[string]interface{}{"mode": "contiguous"}, 8)
+		checkDisjoint(t, ids)
+		for i, partition := range ids {
+			if len(partition) != 375 || partition[0] != int64(i*375) {
+				t.Errorf("partition %d: expected 375 rows from %d, got %d from %d", i, i*375, len(partition), partition[0])
+			}
+		}
+	})
+
+	t.Run("Round-robin partitions", func(t *testing.T) {
+		ids := readAll(t, map[string]interface{}{"mode": "roundRobin"}, 8)
+		checkDisjoint(t, ids)
+		for i, partition := range ids {
+			for _, id := range partition {
+				if id%8 != int64(i) {
+					t.Fatalf("partition %d: unexpected id %d", i, id)
+				}
+			}
+		}
+
+		ids = readAll(t, map[string]interface{}{"mode": "roundRobin"}, 2)
+		checkDisjoint(t, ids)
+		if ids[1][0] != 700 {
+			t.Errorf("expected the second partition to start with the second row group, got %d", ids[1][0])
+		}
+	})
+
+	t.Run("Hash partitions", func(t *testing.T) {
+		ids := readAll(t, map[string]interface{}{"mode": "hash", "column": "id"}, 4)
+		checkDisjoint(t, ids)
+		for i, partition := range ids {
+			for _, id := range partition {
+				if hashKey(id)%4 != uint64(i) {
+					t.Fatalf("partition %d: unexpected id %d", i, id)
+				}
+			}
+		}
+	})
+
+	t.Run("Options apply within the partition", func(t *testing.T) {
+		rows, err := p.Read(filename, map[string]interface{}{
+			"partition": map[string]interface{}{"mode": "roundRobin", "index": 1, "count": 8},
+			"skipRows":  2,
+			"rowLimit":  3,
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		var ids []int64
+		for _, row := range rows {
+			ids = append(ids, row["id"].(int64))
+		}
+		if len(ids) != 3 || ids[0] != 17 || ids[2] != 33 {
+			t.Errorf("expected ids 17, 25, 33, got %v", ids)
+		}
+
+		rows, err = p.Read(filename, map[string]interface{}{
+			"partition": map[string]interface{}{"mode": "contiguous", "index": 1, "count": 3},
+			"filter":    "id >= 2000",
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(rows) != 800 {
+			t.Errorf("expected 800 rows, got %d", len(rows))
+		}
+	})
+
+	t.Run("Row groups of other partitions are not decoded", func(t *testing.T) {
+		file, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		defer file.Close()
+
+		opts, _ := parseReadOptions(map[string]interface{}{
+			"partition": map[string]interface{}{"index": 2, "count": 3},
+		})
+		sc, err := newScanner(pf, &opts)
+		if err != nil {
+			t.Fatalf("newScanner() error = %v", err)
+		}
+		if err := sc.scan(func(map[string]interface{}) error { return nil }); err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+		if sc.decoded != 200 {
+			t.Errorf("expected 200 decoded rows, got %d", sc.decoded)
+		}
+	})
+
+	t.Run("Unknown hash column", func(t *testing.T) {
+		_, err := p.Read(filename, map[string]interface{}{
+			"partition": map[string]interface{}{"mode": "hash", "column": "missing", "index": 0, "count": 2},
+		})
+		if err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestResolvePartition(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	p := &Parquet{vu: runtime.VU, cache: NewReaderCache()}
+
+	if err := p.resolvePartition(&partition{mode: partitionContiguous, by: partitionByVU}); err == nil {
+		t.Error("expected an error outside of the VU context")
+	}
+
+	config := executor.NewPerVUIterationsConfig("default")
+	config.VUs = null.IntFrom(4)
+	et, err := lib.NewExecutionTuple(nil, nil)
+	if err != nil {
+		t.Fatalf("NewExecutionTuple() error = %v", err)
+	}
+	es := lib.NewExecutionState(&lib.TestRunState{
+		Options: lib.Options{Scenarios: lib.ScenarioConfigs{"default": config}},
+	}, et, 4, 4)
+	runtime.VU.CtxField = lib.WithExecutionState(runtime.VU.CtxField, es)
+	runtime.MoveToVUContext(&lib.State{VUID: 3, VUIDGlobal: 3})
+
+	part := &partition{mode: partitionContiguous, by: partitionByVU}
+	if err := p.resolvePartition(part); err != nil {
+		t.Fatalf("resolvePartition() error = %v", err)
+	}
+	if part.index != 2 || part.count != 4 {
+		t.Errorf("expected partition 2/4, got %d/%d", part.index, part.count)
+	}
+
+	part = &partition{mode: partitionContiguous, by: partitionByInstance}
+	if err := p.resolvePartition(part); err != nil {
+		t.Fatalf("resolvePartition() error = %v", err)
+	}
+	if part.index != 0 || part.count != 1 {
+		t.Errorf("expected partition 0/1, got %d/%d", part.index, part.count)
+	}
+}
@@ -0,0 +1,223 @@
+package parquet
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// projection restricts a read to the column chunks of the requested
+// columns, so that the other columns are neither read from disk nor decoded.
+// Columns are top-level fields or dotted paths into nested groups, such as
+// "address.city"; unknown columns are ignored.
+type projection struct {
+	schema  *parquet.Schema // schema of the projected rows
+	columns []int           // indexes in the file of the projected leaf columns
+}
+
+// newProjection returns the projection of a file schema on the given
+// columns, or nil when all columns are read.
+func newProjection(schema *parquet.Schema, columns []string) *projection {
+	if len(columns) == 0 {
+		return nil
+	}
+
+	selected := &selection{}
+	for _, column := range normalizeColumns(columns) {
+		selected.add(schema, strings.Split(column, "."))
+	}
+
+	projected := parquet.NewSchema(schema.Name(), selected.project(schema))
+
+	p := &projection{schema: projected}
+	for _, path := range projected.Columns() {
+		leaf, _ := schema.Lookup(path...)
+		p.columns = append(p.columns, leaf.ColumnIndex)
+	}
+	return p
+}
+
+// rowGroup returns the projection of a row group of the file.
+func (p *projection) rowGroup(rg parquet.RowGroup) parquet.RowGroup {
+	chunks := rg.ColumnChunks()
+	columns := make([]parquet.ColumnChunk, len(p.columns))
+	for i, column := range p.columns {
+		columns[i] = chunks[column]
+	}
+	return &projectedRowGroup{RowGroup: rg, schema: p.schema, columns: columns}
+}
+
+// selection is the tree of fields selected by a projection.
+type selection struct {
+	all      bool // the whole field is selected
+	children map[string]*selection
+}
+
+// add selects the field at path, if it exists. Paths can only reach into
+// plain groups; lists and maps are selected as a whole.
+func (s *selection) add(node parquet.Node, path []string) {
+	child := fieldByName(node, path[0])
+	if child == nil {
+		return
+	}
+	if len(path) > 1 && (child.Leaf() || isListNode(child) || isMapNode(child)) {
+		return
+	}
+	if len(path) > 1 && !hasField(child, path[1:]) {
+		return
+	}
+
+	if s.children == nil {
+		s.children = make(map[string]*selection)
+	}
+	sub, ok := s.children[path[0]]
+	if !ok {
+		sub = &selection{}
+		s.children[path[0]] = sub
+	}
+	if len(path) == 1 {
+		sub.all = true
+		return
+	}
+	sub.add(child, path[1:])
+}
+
+// project returns the node restricted to the selected fields, keeping its
+// repetition.
+func (s *selection) project(node parquet.Node) parquet.Node {
+	if s.all {
+		return node
+	}
+
+	// Fields are projected in the order of the file, whatever the order of
+	// the columns.
+	group := orderedGroup{}
+	for _, field := range node.Fields() {
+		if child, ok := s.children[field.Name()]; ok {
+			group.set(field.Name(), child.project(field))
+		}
+	}
+
+	var projected parquet.Node = group
+	switch {
+	case node.Repeated():
+		projected = parquet.Repeated(projected)
+	case node.Optional():
+		projected = parquet.Optional(projected)
+	}
+	return projected
+}
+
+// hasField reports whether a dotted path exists under a group node.
+func hasField(node parquet.Node, path []string) bool {
+	for _, name := range path {
+		node = fieldByName(node, name)
+		if node == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeColumns sorts and deduplicates columns, dropping the paths
+// already covered by a selected parent group.
+func normalizeColumns(columns []string) []string {
+	sorted := append([]string(nil), columns...)
+	sort.Strings(sorted)
+
+	// Parents sort before their children, so they are kept first.
+	kept := make(map[string]bool, len(sorted))
+	normalized := make([]string, 0, len(sorted))
+	for _, column := range sorted {
+		if kept[column] || coveredBy(column, kept) {
+			continue
+		}
+		kept[column] = true
+		normalized = append(normalized, column)
+	}
+	return normalized
+}
+
+// coveredBy reports whether a parent group of a dotted path is in columns.
+func coveredBy(column string, columns map[string]bool) bool {
+	for i := range column {
+		if column[i] == '.' && columns[column[:i]] {
+			return true
+		}
+	}
+	return false
+}
+
+// projectedRowGroup is a row group restricted to the column chunks of a
+// projection.
+type projectedRowGroup struct {
+	parquet.RowGroup
+	schema  *parquet.Schema
+	columns []parquet.ColumnChunk
+}
+
+func (g *projectedRowGroup) Schema() *parquet.Schema { return g.schema }
+
+func (g *projectedRowGroup) ColumnChunks() []parquet.ColumnChunk { return g.columns }
+
+func (g *projectedRowGroup) SortingColumns() []parquet.SortingColumn { return nil }
+
+func (g *projectedRowGroup) Rows() parquet.Rows { return parquet.NewRowGroupRowReader(g) }
+
+// projectColumns returns a copy of a decoded row restricted to the given
+// columns, sharing the values of the row.
+func projectColumns(row map[string]interface{}, columns []string) map[string]interface{} {
+	filtered := make(map[string]interface{}, len(columns))
+	for _, column := range normalizeColumns(columns) {
+		projectPath(filtered, row, strings.Split(column, "."))
+	}
+	return filtered
+}
+
+// projectPath copies the value at path from src to dst, creating the
+// enclosing groups in dst.
+func projectPath(dst, src map[string]interface{}, path []string) {
+	value, ok := src[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		dst[path[0]] = value
+		return
+	}
+
+	group, ok := value.(map[string]interface{})
+	if !ok {
+		// A null group yields a null value, as when decoding a projection.
+		if value == nil {
+			dst[path[0]] = nil
+		}
+		return
+	}
+	if !hasKey(group, path[1:]) {
+		return
+	}
+
+	sub, ok := dst[path[0]].(map[string]interface{})
+	if !ok {
+		sub = make(map[string]interface{})
+		dst[path[0]] = sub
+	}
+	projectPath(sub, group, path[1:])
+}
+
+// hasKey reports whether a dotted path exists in a decoded group, stopping
+// at null groups.
+func hasKey(group map[string]interface{}, path []string) bool {
+	for _, name := range path {
+		value, ok := group[name]
+		if !ok {
+			return false
+		}
+		if group, ok = value.(map[string]interface{}); !ok {
+			return true
+		}
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package parquet
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// createNestedParquetFile creates a Parquet file with nested groups, lists
+// and maps.
+func createNestedParquetFile(t *testing.T) string {
+	t.Helper()
+
+	type Geo struct {
+		Lat float64 `parquet:"lat"`
+		Lon float64 `parquet:"lon"`
+	}
+	type Address struct {
+		City string `parquet:"city"`
+		Zip  string `parquet:"zip,optional"`
+		Geo  Geo    `parquet:"geo"`
+	}
+	type NestedRow struct {
+		ID      int64            `parquet:"id"`
+		Name    string           `parquet:"name"`
+		Address *Address         `parquet:"address,optional"`
+		Tags    []string         `parquet:"tags,list"`
+		Attrs   map[string]int64 `parquet:"attrs"`
+	}
+
+	rows := []NestedRow{
+		{ID: 1, Name: "a", Address: &Address{City: "Paris", Zip: "75001", Geo: Geo{48.8, 2.3}}, Tags: []string{"x"}},
+		{ID: 2, Name: "b", Tags: []string{"y", "z"}, Attrs: map[string]int64{"k": 1}},
+		{ID: 3, Name: "c", Address: &Address{City: "Berlin", Geo: Geo{52.5, 13.4}}},
+	}
+
+	filename := filepath.Join(t.TempDir(), "nested.parquet")
+	file, err := os.Create(filename)
+	if err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+	defer file.Close()
+
+	writer := parquet.NewGenericWriter[NestedRow](file)
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test data: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	return filename
+}
+
+func TestProjection(t *testing.T) {
+	filename := createNestedParquetFile(t)
+
+	file, pf, err := openParquetFile(osFS, filename)
+	if err != nil {
+		t.Fatalf("openParquetFile() error = %v", err)
+	}
+	defer file.Close()
+
+	t.Run("Only the requested column chunks are read", func(t *testing.T) {
+		p := newProjection(pf.Schema(), []string{"address.geo.lat", "id", "missing", "address.nope"})
+
+		expected := []int{}
+		for _, path := range [][]string{{"address", "geo", "lat"}, {"id"}} {
+			leaf, _ := pf.Schema().Lookup(path...)
+			expected = append(expected, leaf.ColumnIndex)
+		}
+		got := slices.Clone(p.columns)
+		slices.Sort(got)
+		slices.Sort(expected)
+		if !slices.Equal(got, expected) {
+			t.Errorf("expected columns %v, got %v", expected, got)
+		}
+
+		rg := p.rowGroup(pf.RowGroups()[0])
+		if len(rg.ColumnChunks()) != 2 {
+			t.Errorf("expected 2 column chunks, got %d", len(rg.ColumnChunks()))
+		}
+	})
+
+	t.Run("All columns are read without projection", func(t *testing.T) {
+		if p := newProjection(pf.Schema(), nil); p != nil {
+			t.Errorf("expected nil projection, got %v", p.schema)
+		}
+	})
+
+	t.Run("Decoded projections match projected rows", func(t *testing.T) {
+		full, err := scanAll(pf, ReadOptions{BufferSize: 10, ConvertOptions: defaultConvertOptions()})
+		if err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+
+		for _, columns := range [][]string{
+			{"id"},
+			{"address.city", "name"},
+			{"address.geo.lon", "address.zip"},
+			{"address", "address.city"},
+			{"tags", "attrs"},
+			{"missing"},
+		} {
+			rows, err := scanAll(pf, ReadOptions{Columns: columns, BufferSize: 10, ConvertOptions: defaultConvertOptions()})
+			if err != nil {
+				t.Fatalf("%v: scan() error = %v", columns, err)
+			}
+			if len(rows) != len(full) {
+				t.Fatalf("%v: expected %d rows, got %d", columns, len(full), len(rows))
+			}
+			for i, row := range rows {
+				if expected := projectColumns(full[i], columns); !reflect.DeepEqual(row, expected) {
+					t.Errorf("%v: row %d = %v, expected %v", columns, i, row, expected)
+				}
+			}
+		}
+	})
+
+	t.Run("Dotted paths keep the enclosing groups", func(t *testing.T) {
+		rows, err := scanAll(pf, ReadOptions{Columns: []string{"address.city"}, BufferSize: 10})
+		if err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+
+		expected := []map[string]interface{}{
+			{"address": map[string]interface{}{"city": "Paris"}},
+			{"address": nil},
+			{"address": map[string]interface{}{"city": "Berlin"}},
+		}
+		if !reflect.DeepEqual(rows, expected) {
+			t.Errorf("expected %v, got %v", expected, rows)
+		}
+	})
+
+	t.Run("Filter columns are not returned", func(t *testing.T) {
+		opts, _ := parseReadOptions(map[string]interface{}{
+			"columns": []interface{}{"name"},
+			"filter":  "address.city == 'Berlin'",
+		})
+		rows, err := scanAll(pf, opts)
+		if err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+		if len(rows) != 1 || !reflect.DeepEqual(rows[0], map[string]interface{}{"name": "c"}) {
+			t.Errorf("unexpected rows: %v", rows)
+		}
+	})
+}
+
+func TestNormalizeColumns(t *testing.T) {
+	got := normalizeColumns([]string{"b", "a.x", "a-b", "a", "a.y.z", "b", "c.d", "c.d.e"})
+	expected := []string{"a", "a-b", "b", "c.d"}
+	if !slices.Equal(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// scanAll returns the rows of a file selected by the given options.
+func scanAll(pf *parquet.File, opts ReadOptions) ([]map[string]interface{}, error) {
+	sc, err := newScanner(pf, &opts)
+	if err != nil {
+		return nil, err
+	}
+	var rows []map[string]interface{}
+	err = sc.scan(func(row map[string]interface{}) error {
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
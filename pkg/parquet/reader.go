@@ -1,13 +1,16 @@
 package parquet
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
-	"os"
 
 	"github.com/parquet-go/parquet-go"
 )
 
-// valueToInterface converts a parquet.Value to a Go interface{} based on its kind
+// valueToInterface converts a parquet.Value to a Go interface{} based on its
+// kind. Byte arrays are copied, as values may reference the buffers of the
+// page they were read from.
 func valueToInterface(value parquet.Value) interface{} {
 	if value.IsNull() {
 		return nil
@@ -26,223 +29,360 @@ func valueToInterface(value parquet.Value) interface{} {
 		return value.Float()
 	case parquet.Double:
 		return value.Double()
-	case parquet.ByteArray:
-		return string(value.ByteArray())
-	case parquet.FixedLenByteArray:
-		return value.ByteArray()
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return bytes.Clone(value.ByteArray())
 	default:
 		return value.String()
 	}
 }
 
-// rowToMap converts a parquet.Row to a map[string]interface{}
-func rowToMap(row parquet.Row, schema *parquet.Schema) map[string]interface{} {
-	result := make(map[string]interface{})
-	fields := schema.Fields()
-
-	// Group values by column index
-	for _, value := range row {
-		columnIndex := value.Column()
-		if columnIndex >= 0 && columnIndex < len(fields) {
-			field := fields[columnIndex]
-			result[field.Name()] = valueToInterface(value)
-		}
-	}
-
-	return result
-}
-
 // ReadOptions defines options for reading Parquet files.
 type ReadOptions struct {
-	Columns    []string `json:"columns"`    // Specific columns to read
-	RowLimit   int      `json:"rowLimit"`   // Maximum number of rows to read (-1 for all)
-	SkipRows   int      `json:"skipRows"`   // Number of rows to skip
-	BufferSize int      `json:"bufferSize"` // Buffer size for reading
+	Columns    []string   `json:"columns"`    // Specific columns to read
+	RowLimit   int        `json:"rowLimit"`   // Maximum number of rows to read (-1 for all)
+	SkipRows   int        `json:"skipRows"`   // Number of rows to skip
+	BufferSize int        `json:"bufferSize"` // Buffer size for reading
+	Filter     predicate  `json:"-"`          // Rows to read, evaluated against column statistics first
+	Partition  *partition `json:"-"`          // Share of the rows read by this VU
+
+	ConvertOptions
 }
 
-// Read reads an entire Parquet file and returns the data as a slice of maps.
-// It supports optional filtering by columns, limiting rows, and skipping rows.
-func (p *Parquet) Read(filename string, options ...map[string]interface{}) ([]map[string]interface{}, error) {
-	// Check cache first
-	if cached, ok := p.cache.Get(filename); ok {
-		return cached, nil
+// parseReadOptions parses the options object passed from JS.
+func parseReadOptions(options ...map[string]interface{}) (ReadOptions, error) {
+	opts := ReadOptions{
+		RowLimit:       -1, // Default: read all rows
+		BufferSize:     1000,
+		ConvertOptions: defaultConvertOptions(),
 	}
 
-	// Parse options
-	opts := ReadOptions{
-		RowLimit:   -1, // Default: read all rows
-		BufferSize: 1000,
+	if len(options) == 0 || options[0] == nil {
+		return opts, nil
 	}
+	o := options[0]
 
-	if len(options) > 0 {
-		if columns, ok := options[0]["columns"].([]interface{}); ok {
-			opts.Columns = make([]string, len(columns))
-			for i, col := range columns {
-				if colStr, ok := col.(string); ok {
-					opts.Columns[i] = colStr
-				}
+	if columns, ok := o["columns"].([]interface{}); ok {
+		opts.Columns = make([]string, len(columns))
+		for i, col := range columns {
+			if colStr, ok := col.(string); ok {
+				opts.Columns[i] = colStr
 			}
 		}
-		if rowLimit, ok := options[0]["rowLimit"].(int); ok {
-			opts.RowLimit = rowLimit
-		} else if rowLimit, ok := options[0]["rowLimit"].(float64); ok {
-			opts.RowLimit = int(rowLimit)
+	}
+	if rowLimit, ok := intOption(o, "rowLimit"); ok {
+		opts.RowLimit = rowLimit
+	}
+	if skipRows, ok := intOption(o, "skipRows"); ok {
+		opts.SkipRows = skipRows
+	}
+	if filter, ok := o["filter"]; ok {
+		pred, err := parseFilter(filter)
+		if err != nil {
+			return opts, err
+		}
+		opts.Filter = pred
+	}
+	if part, ok := o["partition"]; ok && part != nil {
+		parsed, err := parsePartition(part)
+		if err != nil {
+			return opts, err
 		}
-		if skipRows, ok := options[0]["skipRows"].(int); ok {
-			opts.SkipRows = skipRows
-		} else if skipRows, ok := options[0]["skipRows"].(float64); ok {
-			opts.SkipRows = int(skipRows)
+		opts.Partition = parsed
+	}
+	if timestamps, ok := o["timestamps"].(string); ok {
+		switch timestamps {
+		case timestampsDate, timestampsString, timestampsRaw:
+			opts.Timestamps = timestamps
+		default:
+			return opts, fmt.Errorf("invalid timestamps option %q: expected %q, %q or %q",
+				timestamps, timestampsDate, timestampsString, timestampsRaw)
 		}
 	}
+	if parseJSON, ok := o["parseJSON"].(bool); ok {
+		opts.ParseJSON = parseJSON
+	}
+	if err := parseExportOptions(&opts.ConvertOptions, o); err != nil {
+		return opts, err
+	}
 
-	// Open the file
-	// #nosec G304 -- Users need to open files specified in k6 scripts
-	file, err := os.Open(filename)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+	return opts, nil
+}
+
+// intOption returns an integer option, which JS may pass as int or float64.
+func intOption(options map[string]interface{}, key string) (int, bool) {
+	switch v := options[key].(type) {
+	case int:
+		return v, true
+	case int64:
+		return int(v), true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
 	}
-	defer file.Close()
+}
 
-	// Get file info
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
+// cacheKey returns the key identifying the result of reading filename with
+// these options. Options are normalized so that equivalent reads share a key.
+func (o ReadOptions) cacheKey(filename string) string {
+	var columns []string
+	if len(o.Columns) > 0 {
+		columns = normalizeColumns(o.Columns)
 	}
 
-	// Create Parquet file reader
-	pf, err := parquet.OpenFile(file, stat.Size())
-	if err != nil {
-		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+	rowLimit := o.RowLimit
+	if rowLimit <= 0 {
+		rowLimit = -1
 	}
 
-	// Read data using row-based reader
-	results := make([]map[string]interface{}, 0)
-	rowsRead := 0
-	rowsSkipped := 0
+	var filter, partition string
+	if o.Filter != nil {
+		filter = o.Filter.String()
+	}
+	if o.Partition != nil {
+		partition = o.Partition.String()
+	}
+
+	key, _ := json.Marshal(struct {
+		Columns    []string       `json:"c,omitempty"`
+		RowLimit   int            `json:"l"`
+		SkipRows   int            `json:"s"`
+		Filter     string         `json:"f,omitempty"`
+		Partition  string         `json:"p,omitempty"`
+		Conversion ConvertOptions `json:"v"`
+	}{columns, rowLimit, o.SkipRows, filter, partition, o.ConvertOptions})
+
+	return filename + "?" + string(key)
+}
 
-	// Seek back to start for row reader
-	if _, err := file.Seek(0, 0); err != nil {
-		return nil, fmt.Errorf("failed to seek to beginning: %w", err)
+// widerOptions returns the options of reads whose results contain the result
+// of this one, from the narrowest to the widest: all columns, all rows, both,
+// or all columns and rows without the filter. The filter is only dropped
+// along with the projection and the row window, which apply to the rows
+// matching it and may exclude the columns it references. Wider reads keep the
+// partition, which applies before the other options.
+func (o ReadOptions) widerOptions() []ReadOptions {
+	var wider []ReadOptions
+
+	allColumns := o
+	allColumns.Columns = nil
+	if len(o.Columns) > 0 {
+		wider = append(wider, allColumns)
 	}
 
-	// Create reader
-	reader := parquet.NewReader(file)
-	defer reader.Close()
+	if o.SkipRows > 0 || o.RowLimit > 0 {
+		allRows := o
+		allRows.SkipRows = 0
+		allRows.RowLimit = -1
+		wider = append(wider, allRows)
 
-	// Read rows in batches
-	rowBuffer := make([]parquet.Row, opts.BufferSize)
-	for {
-		n, err := reader.ReadRows(rowBuffer)
-		if n == 0 {
-			break
+		if len(o.Columns) > 0 {
+			allRows.Columns = nil
+			wider = append(wider, allRows)
 		}
+	}
 
-		for i := 0; i < n; i++ {
-			if opts.RowLimit > 0 && rowsRead >= opts.RowLimit {
-				break
-			}
+	if o.Filter != nil {
+		unfiltered := o
+		unfiltered.Columns = nil
+		unfiltered.SkipRows = 0
+		unfiltered.RowLimit = -1
+		unfiltered.Filter = nil
+		wider = append(wider, unfiltered)
+	}
 
-			// Skip specified rows
-			if rowsSkipped < opts.SkipRows {
-				rowsSkipped++
-				continue
-			}
+	return wider
+}
 
-			// Convert row to map
-			row := rowToMap(rowBuffer[i], pf.Schema())
-
-			// Filter columns if specified
-			if len(opts.Columns) > 0 {
-				filtered := make(map[string]interface{})
-				for _, col := range opts.Columns {
-					if val, ok := row[col]; ok {
-						filtered[col] = val
-					}
-				}
-				results = append(results, filtered)
-			} else {
-				results = append(results, row)
+// narrow derives the result of a read with these options from the cached
+// result of a wider read.
+func (o ReadOptions) narrow(rows []map[string]interface{}, wider ReadOptions) []map[string]interface{} {
+	if o.Filter != nil && wider.Filter == nil {
+		matched := make([]map[string]interface{}, 0, len(rows))
+		for _, row := range rows {
+			if o.Filter.match(row) {
+				matched = append(matched, row)
 			}
+		}
+		rows = matched
+	}
 
-			rowsRead++
+	if wider.SkipRows == 0 && wider.RowLimit <= 0 {
+		start := min(o.SkipRows, len(rows))
+		end := len(rows)
+		if o.RowLimit > 0 {
+			end = min(start+o.RowLimit, end)
 		}
+		rows = rows[start:end]
+	}
+
+	if len(o.Columns) == 0 || len(wider.Columns) > 0 {
+		return rows
+	}
+
+	projected := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		projected[i] = projectColumns(row, o.Columns)
+	}
+	return projected
+}
 
-		if opts.RowLimit > 0 && rowsRead >= opts.RowLimit {
-			break
+// cached returns the result of a read from the cache, either from an entry
+// for the same options or derived from the entry of a wider read, with the
+// field order of the rows.
+func (p *Parquet) cached(filename string, opts ReadOptions) ([]map[string]interface{}, *fieldOrder, bool) {
+	key := opts.cacheKey(filename)
+	if entry, ok := p.cache.get(key); ok {
+		p.acquire(key)
+		return entry.data, entry.order, true
+	}
+
+	for _, wider := range opts.widerOptions() {
+		key := wider.cacheKey(filename)
+		entry, ok := p.cache.get(key)
+		if !ok {
+			continue
+		}
+		// The filter is validated against the schema of the rows it matches,
+		// which tells how to compare their values.
+		if opts.Filter != nil && wider.Filter == nil &&
+			(entry.schema == nil || validateFilter(opts.Filter, entry.schema) != nil) {
+			continue
+		}
+		p.acquire(key)
+		return opts.narrow(entry.data, wider), entry.order, true
+	}
+
+	return nil, nil, false
+}
+
+// Read reads an entire Parquet file and returns the data as a slice of maps.
+// It supports optional filtering by columns, limiting rows, and skipping rows.
+// A glob pattern or a directory reads all matching files as one dataset.
+func (p *Parquet) Read(filename string, options ...map[string]interface{}) ([]map[string]interface{}, error) {
+	opts, err := p.readOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+	rows, _, err := p.read(p.files.resolve(filename), opts)
+	return rows, err
+}
+
+// readOptions parses read options and resolves their partition, which
+// depends on the VU and must be done from the JS thread.
+func (p *Parquet) readOptions(options ...map[string]interface{}) (ReadOptions, error) {
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return opts, err
+	}
+	if err := p.resolvePartition(opts.Partition); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+// read reads the rows of a resolved path, through the cache, and returns
+// them with their field order.
+func (p *Parquet) read(filename string, opts ReadOptions) ([]map[string]interface{}, *fieldOrder, error) {
+	// Check cache first
+	if cached, order, ok := p.cached(filename, opts); ok {
+		return cached, order, nil
+	}
+
+	// Concurrent reads of the same file and options decode it once.
+	key := opts.cacheKey(filename)
+	entry, err := p.cache.load(key, func() ([]map[string]interface{}, *parquet.Schema, error) {
+		set, err := p.files.openSet(filename, opts.Filter)
+		if err != nil {
+			return nil, nil, err
 		}
+		defer set.Close()
 
-		// Break on error after processing rows
+		results, err := readRows(set, &opts)
 		if err != nil {
-			break
+			return nil, nil, err
 		}
+		return results, set.schema, nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	p.acquire(key)
 
-	// Cache results
-	p.cache.Set(filename, results)
+	return entry.data, entry.order, nil
+}
 
+// readRows reads the rows of a set of files selected by read options.
+func readRows(set *fileSet, opts *ReadOptions) ([]map[string]interface{}, error) {
+	results := make([]map[string]interface{}, 0)
+	err := set.scan(opts, func(row map[string]interface{}) error {
+		results = append(results, row)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
 	return results, nil
 }
 
 // ReadChunked reads a Parquet file in chunks, calling the provided callback for each chunk.
 // This is useful for processing large files without loading them entirely into memory.
-func (p *Parquet) ReadChunked(filename string, chunkSize int, callback func([]map[string]interface{}) error) error {
-	// #nosec G304 -- Users need to open files specified in k6 scripts
-	file, err := os.Open(filename)
+func (p *Parquet) ReadChunked(
+	filename string, chunkSize int, callback func([]map[string]interface{}) error, options ...map[string]interface{},
+) error {
+	opts, err := p.readOptions(options...)
 	if err != nil {
-		return fmt.Errorf("failed to open file: %w", err)
+		return err
 	}
-	defer file.Close()
+	return p.readChunked(p.files.resolve(filename), chunkSize, opts, func(chunk []map[string]interface{}, _ *fieldOrder) error {
+		return callback(chunk)
+	})
+}
 
-	stat, err := file.Stat()
-	if err != nil {
-		return fmt.Errorf("failed to stat file: %w", err)
+// checkChunkSize returns an error if chunkSize is not a valid chunk size.
+func checkChunkSize(chunkSize int) error {
+	if chunkSize <= 0 {
+		return fmt.Errorf("invalid chunkSize %d: must be positive", chunkSize)
 	}
+	return nil
+}
 
-	pf, err := parquet.OpenFile(file, stat.Size())
-	if err != nil {
-		return fmt.Errorf("failed to open parquet file: %w", err)
+// readChunked reads the rows of a resolved path in chunks, passed to the
+// callback with their field order.
+func (p *Parquet) readChunked(
+	filename string, chunkSize int, opts ReadOptions, callback func([]map[string]interface{}, *fieldOrder) error,
+) error {
+	if err := checkChunkSize(chunkSize); err != nil {
+		return err
 	}
-
-	// Seek back to start for row reader
-	if _, err := file.Seek(0, 0); err != nil {
-		return fmt.Errorf("failed to seek to beginning: %w", err)
+	set, err := p.files.openSet(filename, opts.Filter)
+	if err != nil {
+		return err
 	}
-
-	reader := parquet.NewReader(file)
-	defer reader.Close()
+	defer set.Close()
+	order := schemaOrder(set.schema)
 
 	chunk := make([]map[string]interface{}, 0, chunkSize)
-	rowBuffer := make([]parquet.Row, 100) // Read in small batches
-
-	for {
-		n, err := reader.ReadRows(rowBuffer)
-		if n == 0 {
-			break
-		}
-
-		for i := 0; i < n; i++ {
-			// Convert row to map
-			row := rowToMap(rowBuffer[i], pf.Schema())
-			chunk = append(chunk, row)
-
-			// Call callback when chunk size is reached
-			if len(chunk) >= chunkSize {
-				if cbErr := callback(chunk); cbErr != nil {
-					return cbErr
-				}
-				chunk = make([]map[string]interface{}, 0, chunkSize)
+	opts.BufferSize = 100 // Read in small batches
+	err = set.scan(&opts, func(row map[string]interface{}) error {
+		chunk = append(chunk, row)
+
+		// Call callback when chunk size is reached
+		if len(chunk) >= chunkSize {
+			if err := callback(chunk, order); err != nil {
+				return err
 			}
+			chunk = make([]map[string]interface{}, 0, chunkSize)
 		}
-
-		// Break on error after processing rows
-		if err != nil {
-			break
-		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
 	// Process remaining data
 	if len(chunk) > 0 {
-		if err := callback(chunk); err != nil {
+		if err := callback(chunk, order); err != nil {
 			return err
 		}
 	}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/parquet-go/parquet-go"
@@ -98,21 +100,26 @@ func TestValueToInterface(t *testing.T) {
 		{
 			name:     "ByteArray value",
 			value:    parquet.ByteArrayValue([]byte("test")),
-			expected: "test",
+			expected: []byte("test"),
+		},
+		{
+			name:     "FixedLenByteArray value",
+			value:    parquet.FixedLenByteArrayValue([]byte{0xff, 0x00}),
+			expected: []byte{0xff, 0x00},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			result := valueToInterface(tt.value)
-			if result != tt.expected {
+			if !reflect.DeepEqual(result, tt.expected) {
 				t.Errorf("valueToInterface() = %v (%T), want %v (%T)", result, result, tt.expected, tt.expected)
 			}
 		})
 	}
 }
 
-func TestRowToMap(t *testing.T) {
+func TestDecodeRow(t *testing.T) {
 	// Create a simple schema
 	type SimpleRow struct {
 		ID   int64  `parquet:"id"`
@@ -130,7 +137,7 @@ func TestRowToMap(t *testing.T) {
 	}
 	writer.Close()
 
-	// Now read as Row to test rowToMap
+	// Now read as Row to test the decoder
 	rowReader := parquet.NewReader(bytes.NewReader(buf.Bytes()))
 	defer rowReader.Close()
 
@@ -140,7 +147,8 @@ func TestRowToMap(t *testing.T) {
 		t.Fatalf("failed to read row: n=0, err=%v", err)
 	}
 
-	result := rowToMap(rowBuf[0], schema)
+	opts := defaultConvertOptions()
+	result := newRecordDecoder(schema).decode(rowBuf[0], &opts)
 
 	if result["id"] != int64(123) {
 		t.Errorf("expected id=123, got %v", result["id"])
@@ -401,6 +409,17 @@ func TestReadChunked(t *testing.T) {
 		}
 	})
 
+	t.Run("ReadChunked invalid chunk size", func(t *testing.T) {
+		for _, size := range []int{0, -1} {
+			err := p.ReadChunked(filename, size, func(chunk []map[string]interface{}) error {
+				return nil
+			})
+			if err == nil {
+				t.Errorf("expected an error for chunk size %d", size)
+			}
+		}
+	})
+
 	t.Run("ReadChunked non-existent file", func(t *testing.T) {
 		err := p.ReadChunked("/non/existent/file.parquet", 10, func(chunk []map[string]interface{}) error {
 			return nil
@@ -437,3 +456,312 @@ func TestReadChunked(t *testing.T) {
 		}
 	})
 }
+
+func TestDecodeRowNested(t *testing.T) {
+	type Address struct {
+		City string `parquet:"city"`
+		Zip  string `parquet:"zip,optional"`
+	}
+	type Item struct {
+		SKU string `parquet:"sku"`
+		Qty int32  `parquet:"qty"`
+	}
+	type NestedRow struct {
+		ID      int64            `parquet:"id"`
+		Address Address          `parquet:"address"`
+		Manager *Address         `parquet:"manager,optional"`
+		Items   []Item           `parquet:"items,list"`
+		Tags    []string         `parquet:"tags"`
+		Attrs   map[string]int64 `parquet:"attrs"`
+	}
+
+	rows := []NestedRow{
+		{
+			ID:      1,
+			Address: Address{City: "Paris", Zip: "75001"},
+			Manager: &Address{City: "Lyon"},
+			Items:   []Item{{SKU: "a", Qty: 1}, {SKU: "b", Qty: 2}},
+			Tags:    []string{"x", "y", "z"},
+			Attrs:   map[string]int64{"k": 7},
+		},
+		{
+			ID:      2,
+			Address: Address{City: "Berlin"},
+		},
+	}
+
+	var buf bytes.Buffer
+	writer := parquet.NewGenericWriter[NestedRow](&buf)
+	if _, err := writer.Write(rows); err != nil {
+		t.Fatalf("failed to write test rows: %v", err)
+	}
+	writer.Close()
+
+	reader := parquet.NewReader(bytes.NewReader(buf.Bytes()))
+	defer reader.Close()
+
+	rowBuf := make([]parquet.Row, 2)
+	n, _ := reader.ReadRows(rowBuf)
+	if n != 2 {
+		t.Fatalf("expected 2 rows, got %d", n)
+	}
+
+	opts := defaultConvertOptions()
+	first := newRecordDecoder(reader.Schema()).decode(rowBuf[0], &opts)
+
+	address, ok := first["address"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("expected address to be an object, got %T", first["address"])
+	}
+	if address["city"] != "Paris" || address["zip"] != "75001" {
+		t.Errorf("unexpected address: %v", address)
+	}
+
+	manager, ok := first["manager"].(map[string]interface{})
+	if !ok || manager["city"] != "Lyon" {
+		t.Errorf("unexpected manager: %v", first["manager"])
+	}
+
+	items, ok := first["items"].([]interface{})
+	if !ok || len(items) != 2 {
+		t.Fatalf("expected 2 items, got %v", first["items"])
+	}
+	if item, ok := items[1].(map[string]interface{}); !ok || item["sku"] != "b" || item["qty"] != int32(2) {
+		t.Errorf("unexpected second item: %v", items[1])
+	}
+
+	tags, ok := first["tags"].([]interface{})
+	if !ok || len(tags) != 3 || tags[2] != "z" {
+		t.Errorf("unexpected tags: %v", first["tags"])
+	}
+
+	attrs, ok := first["attrs"].(map[string]interface{})
+	if !ok || attrs["k"] != int64(7) {
+		t.Errorf("unexpected attrs: %v", first["attrs"])
+	}
+
+	second := newRecordDecoder(reader.Schema()).decode(rowBuf[1], &opts)
+
+	if second["manager"] != nil {
+		t.Errorf("expected null manager, got %v", second["manager"])
+	}
+	if address, ok := second["address"].(map[string]interface{}); !ok || address["zip"] != nil {
+		t.Errorf("expected null zip, got %v", second["address"])
+	}
+	if items, ok := second["items"].([]interface{}); !ok || len(items) != 0 {
+		t.Errorf("expected empty items, got %v", second["items"])
+	}
+	if tags, ok := second["tags"].([]interface{}); !ok || len(tags) != 0 {
+		t.Errorf("expected empty tags, got %v", second["tags"])
+	}
+}
+
+func TestReadCacheKeys(t *testing.T) {
+	filename := createTestParquetFile(t)
+
+	p := &Parquet{
+		cache: NewReaderCache(),
+	}
+
+	t.Run("Different options are cached separately", func(t *testing.T) {
+		p.cache.Clear()
+
+		results, err := p.Read(filename, map[string]interface{}{"columns": []interface{}{"id"}})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != 5 || len(results[0]) != 1 {
+			t.Fatalf("expected 5 rows with 1 column, got %d rows with %d columns", len(results), len(results[0]))
+		}
+
+		results, err = p.Read(filename, map[string]interface{}{"rowLimit": 2})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("expected 2 rows, got %d", len(results))
+		}
+		if len(results[0]) != 6 {
+			t.Errorf("expected all 6 columns, got %d", len(results[0]))
+		}
+	})
+
+	t.Run("Equivalent options share a key", func(t *testing.T) {
+		a, _ := parseReadOptions(map[string]interface{}{"columns": []interface{}{"name", "id"}, "rowLimit": 0})
+		b, _ := parseReadOptions(map[string]interface{}{"columns": []interface{}{"id", "name", "id"}})
+		if a.cacheKey(filename) != b.cacheKey(filename) {
+			t.Errorf("expected equal keys, got %q and %q", a.cacheKey(filename), b.cacheKey(filename))
+		}
+
+		c, _ := parseReadOptions(map[string]interface{}{"timestamps": "string"})
+		d, _ := parseReadOptions()
+		if c.cacheKey(filename) == d.cacheKey(filename) {
+			t.Error("expected conversion options to be part of the key")
+		}
+	})
+
+	t.Run("Concurrent reads share one decoded copy", func(t *testing.T) {
+		p.cache.Clear()
+
+		const readers = 8
+		results := make([][]map[string]interface{}, readers)
+		var wg sync.WaitGroup
+		for i := 0; i < readers; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				rows, err := p.Read(filename)
+				if err != nil {
+					t.Errorf("Read() error = %v", err)
+				}
+				results[i] = rows
+			}(i)
+		}
+		wg.Wait()
+
+		for i, rows := range results {
+			if len(rows) != 5 || &rows[0] != &results[0][0] {
+				t.Errorf("reader %d did not get the shared rows", i)
+			}
+		}
+	})
+
+	t.Run("Narrower reads are served from a wider cached read", func(t *testing.T) {
+		p.cache.Clear()
+
+		// Read into a copy of the file, then remove it so that only the cache
+		// can serve subsequent reads.
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("failed to read test file: %v", err)
+		}
+		copyName := filepath.Join(t.TempDir(), "copy.parquet")
+		if err := os.WriteFile(copyName, data, 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		if _, err := p.Read(copyName); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if err := os.Remove(copyName); err != nil {
+			t.Fatalf("failed to remove test file: %v", err)
+		}
+
+		results, err := p.Read(copyName, map[string]interface{}{
+			"columns":  []interface{}{"id", "name"},
+			"skipRows": 1,
+			"rowLimit": 2,
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != 2 {
+			t.Fatalf("expected 2 rows, got %d", len(results))
+		}
+		if len(results[0]) != 2 {
+			t.Errorf("expected 2 columns, got %d", len(results[0]))
+		}
+		if results[0]["id"] != int64(2) || results[1]["name"] != "Charlie" {
+			t.Errorf("unexpected rows: %v", results)
+		}
+
+		if _, err := p.Read(copyName, map[string]interface{}{"timestamps": "string"}); err == nil {
+			t.Error("expected read with different conversion options not to be served from cache")
+		}
+	})
+}
+
+func TestReadFilter(t *testing.T) {
+	filename := createTestParquetFile(t)
+
+	p := &Parquet{
+		cache: NewReaderCache(),
+	}
+
+	t.Run("Filter with row window and projection", func(t *testing.T) {
+		results, err := p.Read(filename, map[string]interface{}{
+			"filter":   "active == true AND age > 25",
+			"columns":  []interface{}{"name"},
+			"skipRows": 1,
+			"rowLimit": 1,
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		// Alice, Charlie and Eve match; the window selects Charlie.
+		if len(results) != 1 || results[0]["name"] != "Charlie" || len(results[0]) != 1 {
+			t.Errorf("unexpected rows: %v", results)
+		}
+	})
+
+	t.Run("Structured filter", func(t *testing.T) {
+		results, err := p.Read(filename, map[string]interface{}{
+			"filter": map[string]interface{}{"column": "score", "op": "<", "value": float64(88)},
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != 2 {
+			t.Errorf("expected 2 rows, got %d", len(results))
+		}
+	})
+
+	t.Run("Filtered reads are served from an unfiltered cached read", func(t *testing.T) {
+		p.cache.Clear()
+
+		data, err := os.ReadFile(filename)
+		if err != nil {
+			t.Fatalf("failed to read test file: %v", err)
+		}
+		copyName := filepath.Join(t.TempDir(), "copy.parquet")
+		if err := os.WriteFile(copyName, data, 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+		if _, err := p.Read(copyName); err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if err := os.Remove(copyName); err != nil {
+			t.Fatalf("failed to remove test file: %v", err)
+		}
+
+		results, err := p.Read(copyName, map[string]interface{}{
+			"filter":   "name IN ('Bob', 'David', 'Eve')",
+			"columns":  []interface{}{"id"},
+			"rowLimit": 2,
+		})
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if len(results) != 2 || results[0]["id"] != int64(2) || results[1]["id"] != int64(4) {
+			t.Errorf("unexpected rows: %v", results)
+		}
+	})
+
+	t.Run("ReadChunked with filter", func(t *testing.T) {
+		totalRows := 0
+		err := p.ReadChunked(filename, 2, func(chunk []map[string]interface{}) error {
+			for _, row := range chunk {
+				if row["active"] != true {
+					t.Errorf("unexpected row: %v", row)
+				}
+			}
+			totalRows += len(chunk)
+			return nil
+		}, map[string]interface{}{"filter": "active == true"})
+		if err != nil {
+			t.Fatalf("ReadChunked() error = %v", err)
+		}
+		if totalRows != 3 {
+			t.Errorf("expected 3 rows, got %d", totalRows)
+		}
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		if _, err := p.Read(filename, map[string]interface{}{"filter": "age >"}); err == nil {
+			t.Error("expected error for invalid filter")
+		}
+		if _, err := p.Read(filename, map[string]interface{}{"filter": "unknown == 1"}); err == nil {
+			t.Error("expected error for unknown column")
+		}
+	})
+}
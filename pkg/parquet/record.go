@@ -0,0 +1,312 @@
+package parquet
+
+import (
+	"fmt"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// fieldKind identifies how a schema node is materialized into a JS value.
+type fieldKind int
+
+const (
+	leafField  fieldKind = iota // primitive column value
+	groupField                  // struct, decoded as an object
+	listField                   // LIST annotated group, decoded as an array
+	mapField                    // MAP annotated group, decoded as an object
+)
+
+// fieldDecoder reconstructs the value of one schema node from the leaf column
+// values of a row, using the repetition and definition levels of each value.
+type fieldDecoder struct {
+	name     string
+	node     parquet.Node
+	kind     fieldKind
+	children []*fieldDecoder
+
+	// Index of the first leaf column under this node and number of leaf
+	// columns it spans. For leaf nodes this is the column itself.
+	column     int
+	numColumns int
+
+	// Repetition and definition levels reached when this node is present.
+	repetitionLevel int
+	definitionLevel int
+
+	// convert converts the values of leaf fields according to their logical
+	// type.
+	convert valueConverter
+
+	// unwrapElement is set on LIST fields following the three-level layout,
+	// where each repeated entry wraps the actual element in a single field.
+	unwrapElement bool
+}
+
+// recordDecoder converts flat parquet rows into nested Go values. Decoders
+// are compiled for the schema of each read and live as long as it, so
+// callers decoding many rows should keep the decoder rather than compile it
+// for each row.
+type recordDecoder struct {
+	fields     []*fieldDecoder
+	numColumns int
+
+	// order orders the keys of the decoded rows like the fields of the
+	// schema.
+	order *fieldOrder
+}
+
+// newRecordDecoder compiles a decoder for every top-level field of the schema.
+func newRecordDecoder(schema *parquet.Schema) *recordDecoder {
+	d := &recordDecoder{}
+	for _, field := range schema.Fields() {
+		d.fields = append(d.fields, d.compile(field.Name(), field, 0, 0))
+	}
+	d.order = newFieldOrder(d.fields)
+	return d
+}
+
+// schemaOrder returns the order of the fields of a schema, nil without a
+// schema.
+func schemaOrder(schema *parquet.Schema) *fieldOrder {
+	if schema == nil {
+		return nil
+	}
+	return newRecordDecoder(schema).order
+}
+
+// compile builds the decoder of a node given the levels of its parent.
+func (d *recordDecoder) compile(name string, node parquet.Node, repetitionLevel, definitionLevel int) *fieldDecoder {
+	if node.Repeated() {
+		repetitionLevel++
+	}
+	if !node.Required() {
+		definitionLevel++
+	}
+
+	f := &fieldDecoder{
+		name:            name,
+		node:            node,
+		column:          d.numColumns,
+		repetitionLevel: repetitionLevel,
+		definitionLevel: definitionLevel,
+	}
+
+	if node.Leaf() {
+		f.kind = leafField
+		f.convert = newValueConverter(node)
+		f.numColumns = 1
+		d.numColumns++
+		return f
+	}
+
+	for _, child := range node.Fields() {
+		f.children = append(f.children, d.compile(child.Name(), child, repetitionLevel, definitionLevel))
+	}
+	f.numColumns = d.numColumns - f.column
+
+	switch {
+	case isListNode(node) && len(f.children) == 1 && f.children[0].node.Repeated():
+		f.kind = listField
+		entry := f.children[0]
+		// Backward compatibility rules of the LIST specification: a repeated
+		// group with a single field is a wrapper around the element, unless it
+		// is named "array" or "<list>_tuple", in which case it is the element.
+		f.unwrapElement = entry.kind == groupField &&
+			len(entry.children) == 1 &&
+			entry.name != "array" &&
+			entry.name != name+"_tuple"
+	case isMapNode(node) && len(f.children) == 1 && f.children[0].node.Repeated() &&
+		len(f.children[0].children) == 2:
+		f.kind = mapField
+	default:
+		f.kind = groupField
+	}
+
+	return f
+}
+
+// newFieldOrder returns the order of the fields of a group, and of the
+// values nested in them.
+func newFieldOrder(fields []*fieldDecoder) *fieldOrder {
+	o := &fieldOrder{ranks: make(map[string]int, len(fields))}
+	for i, f := range fields {
+		o.ranks[f.name] = i
+		if child := f.order(); child != nil {
+			if o.children == nil {
+				o.children = make(map[string]*fieldOrder)
+			}
+			o.children[f.name] = child
+		}
+	}
+	return o
+}
+
+// order returns the order of the keys of the objects decoded by the field,
+// or by the elements of its arrays, nil for leaf values.
+func (f *fieldDecoder) order() *fieldOrder {
+	switch f.kind {
+	case groupField:
+		return newFieldOrder(f.children)
+	case listField:
+		element := f.children[0]
+		if f.unwrapElement {
+			element = element.children[0]
+		}
+		return element.order()
+	case mapField:
+		if values := f.children[0].children[1].order(); values != nil {
+			return &fieldOrder{values: values}
+		}
+		return nil
+	default:
+		return nil
+	}
+}
+
+// isListNode reports whether a group node carries the LIST annotation.
+func isListNode(node parquet.Node) bool {
+	typ := node.Type()
+	if lt := typ.LogicalType(); lt != nil && lt.List != nil {
+		return true
+	}
+	ct := typ.ConvertedType()
+	return ct != nil && *ct == deprecated.List
+}
+
+// isMapNode reports whether a group node carries the MAP annotation.
+func isMapNode(node parquet.Node) bool {
+	typ := node.Type()
+	if lt := typ.LogicalType(); lt != nil && lt.Map != nil {
+		return true
+	}
+	ct := typ.ConvertedType()
+	return ct != nil && (*ct == deprecated.Map || *ct == deprecated.MapKeyValue)
+}
+
+// decode reconstructs a full record from a row.
+func (d *recordDecoder) decode(row parquet.Row, opts *ConvertOptions) map[string]interface{} {
+	// Values are grouped by column in schema order. The column indexes of the
+	// values are those of the file, which differ from the positions of the
+	// columns in a projected schema, so columns are split on index changes.
+	columns := make([][]parquet.Value, d.numColumns)
+	for i, n := 0, 0; i < len(row) && n < len(columns); n++ {
+		j := i + 1
+		for j < len(row) && row[j].Column() == row[i].Column() {
+			j++
+		}
+		columns[n] = row[i:j:j]
+		i = j
+	}
+
+	result := make(map[string]interface{}, len(d.fields))
+	for _, f := range d.fields {
+		result[f.name] = f.decode(columns, opts)
+	}
+	return result
+}
+
+// decode returns the value of the field from the column values belonging to
+// the enclosing record or repeated entry.
+func (f *fieldDecoder) decode(columns [][]parquet.Value, opts *ConvertOptions) interface{} {
+	values := columns[f.column]
+	if len(values) == 0 {
+		return nil
+	}
+
+	if f.node.Repeated() {
+		// A definition level below the one of this node means that no entry is
+		// present; the parent was already checked for nulls.
+		if values[0].DefinitionLevel() < f.definitionLevel {
+			return []interface{}{}
+		}
+		entries := f.splitEntries(columns)
+		elements := make([]interface{}, len(entries))
+		for i, entry := range entries {
+			elements[i] = f.decodeValue(entry, opts)
+		}
+		return elements
+	}
+
+	if f.node.Optional() && values[0].DefinitionLevel() < f.definitionLevel {
+		return nil
+	}
+	return f.decodeValue(columns, opts)
+}
+
+// decodeValue converts a single present occurrence of the field.
+func (f *fieldDecoder) decodeValue(columns [][]parquet.Value, opts *ConvertOptions) interface{} {
+	switch f.kind {
+	case leafField:
+		return f.convert(columns[f.column][0], opts)
+
+	case listField:
+		elements, _ := f.children[0].decode(columns, opts).([]interface{})
+		if f.unwrapElement {
+			for i, element := range elements {
+				if m, ok := element.(map[string]interface{}); ok {
+					elements[i] = m[f.children[0].children[0].name]
+				}
+			}
+		}
+		return elements
+
+	case mapField:
+		entry := f.children[0]
+		entries, _ := entry.decode(columns, opts).([]interface{})
+		result := make(map[string]interface{}, len(entries))
+		for _, e := range entries {
+			if m, ok := e.(map[string]interface{}); ok {
+				result[mapKey(m[entry.children[0].name])] = m[entry.children[1].name]
+			}
+		}
+		return result
+
+	default:
+		result := make(map[string]interface{}, len(f.children))
+		for _, child := range f.children {
+			result[child.name] = child.decode(columns, opts)
+		}
+		return result
+	}
+}
+
+// splitEntries partitions the column values of a repeated field into one
+// set of columns per entry. A new entry starts at every value whose
+// repetition level is at most the repetition level of the field.
+func (f *fieldDecoder) splitEntries(columns [][]parquet.Value) [][][]parquet.Value {
+	var entries [][][]parquet.Value
+
+	for c := f.column; c < f.column+f.numColumns; c++ {
+		values := columns[c]
+		entry := 0
+		start := 0
+		for i := 1; i <= len(values); i++ {
+			if i < len(values) && values[i].RepetitionLevel() > f.repetitionLevel {
+				continue
+			}
+			if entry == len(entries) {
+				entries = append(entries, make([][]parquet.Value, len(columns)))
+			}
+			entries[entry][c] = values[start:i]
+			entry++
+			start = i
+		}
+	}
+
+	return entries
+}
+
+// mapKey formats a decoded MAP key as a JS object property name.
+func mapKey(key interface{}) string {
+	switch k := key.(type) {
+	case string:
+		return k
+	case []byte:
+		return string(k)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(k)
+	}
+}
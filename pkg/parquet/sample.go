@@ -0,0 +1,520 @@
+package parquet
+
+import (
+	"container/heap"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"go.k6.io/k6/js/common"
+)
+
+// SampleOptions defines the options of random row sampling.
+type SampleOptions struct {
+	// Weight is the numeric column rows are weighted by, empty for uniform
+	// sampling. Rows with a missing, null or non-positive weight are never
+	// sampled.
+	Weight string
+
+	// Seed makes the sample reproducible when HasSeed is set.
+	Seed    int64
+	HasSeed bool
+}
+
+// parseSampleOptions parses the sampling options from the options object
+// passed from JS.
+func parseSampleOptions(options ...map[string]interface{}) (SampleOptions, error) {
+	var opts SampleOptions
+	if len(options) == 0 || options[0] == nil {
+		return opts, nil
+	}
+
+	if weight, ok := options[0]["weight"]; ok && weight != nil {
+		column, ok := weight.(string)
+		if !ok || column == "" {
+			return opts, fmt.Errorf("invalid weight option: expected a column name, got %v", weight)
+		}
+		opts.Weight = column
+	}
+	if seed, ok := options[0]["seed"]; ok && seed != nil {
+		n, ok := toInt64(seed)
+		if !ok {
+			return opts, fmt.Errorf("invalid seed option: expected an integer, got %v", seed)
+		}
+		opts.Seed, opts.HasSeed = n, true
+	}
+	return opts, nil
+}
+
+// randKey identifies the generator of seeded samples of a dataset, or of
+// files when ds is nil.
+type randKey struct {
+	ds   *Dataset
+	seed int64
+}
+
+// rand returns the random source of a sample of ds, or of a file when ds is
+// nil. Seeded samples share a generator per VU, dataset and seed, so that
+// successive samples with a seed continue one reproducible sequence. Other
+// samples are randomly seeded.
+func (p *Parquet) rand(ds *Dataset, opts SampleOptions) *rand.Rand {
+	if !opts.HasSeed {
+		return rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())) //nolint:gosec
+	}
+
+	p.randsMu.Lock()
+	defer p.randsMu.Unlock()
+
+	key := randKey{ds: ds, seed: opts.Seed}
+	rng, ok := p.rands[key]
+	if !ok {
+		if p.rands == nil {
+			p.rands = make(map[randKey]*rand.Rand)
+		}
+		rng = rand.New(rand.NewPCG(uint64(opts.Seed), 0)) //nolint:gosec
+		p.rands[key] = rng
+	}
+	return rng
+}
+
+// weightPath returns the path of a weight column, or an error unless it is
+// a numeric column: an integer, floating point or DECIMAL column.
+func weightPath(schema *parquet.Schema, column string) ([]string, error) {
+	path := strings.Split(column, ".")
+	leaf, ok := schema.Lookup(path...)
+	if !ok {
+		return nil, fmt.Errorf("invalid weight option: unknown column %q", column)
+	}
+
+	typ := leaf.Node.Type()
+	lt := typ.LogicalType()
+	if lt != nil && lt.Decimal != nil {
+		return path, nil
+	}
+	switch typ.Kind() {
+	case parquet.Int32, parquet.Int64, parquet.Float, parquet.Double:
+		if lt == nil || lt.Integer != nil {
+			return path, nil
+		}
+	}
+	return nil, fmt.Errorf("invalid weight option: column %q is not numeric", column)
+}
+
+// weightOf returns the weight of a row, and false when the row cannot be
+// sampled. DECIMAL weights are decoded as strings.
+func weightOf(row map[string]interface{}, path []string) (float64, bool) {
+	v := lookupPath(row, path)
+	w, ok := toFloat(v)
+	if s, isString := v.(string); isString {
+		var err error
+		w, err = strconv.ParseFloat(s, 64)
+		ok = err == nil
+	}
+	if !ok || !(w > 0) || math.IsInf(w, 1) {
+		return 0, false
+	}
+	return w, true
+}
+
+// sampleKey returns the key of an item of a weighted random sample, following
+// Efraimidis and Spirakis: keeping the items with the n largest keys samples
+// n items without replacement, with probabilities proportional to their
+// weights. A weight of 1 samples uniformly.
+func sampleKey(rng *rand.Rand, weight float64) float64 {
+	return math.Log(1-rng.Float64()) / weight
+}
+
+// reservoirItem is an item of a reservoir, at the given index of the stream.
+type reservoirItem struct {
+	key   float64
+	index int64
+	row   map[string]interface{}
+}
+
+// reservoir keeps the items with the largest keys seen so far, in a min-heap
+// of at most n items.
+type reservoir struct {
+	n     int
+	items []reservoirItem
+}
+
+func (r *reservoir) Len() int           { return len(r.items) }
+func (r *reservoir) Less(i, j int) bool { return r.items[i].key < r.items[j].key }
+func (r *reservoir) Swap(i, j int)      { r.items[i], r.items[j] = r.items[j], r.items[i] }
+func (r *reservoir) Push(x interface{}) { r.items = append(r.items, x.(reservoirItem)) }
+func (r *reservoir) Pop() interface{} {
+	item := r.items[len(r.items)-1]
+	r.items = r.items[:len(r.items)-1]
+	return item
+}
+
+// offer adds an item to the reservoir if its key is among the n largest.
+func (r *reservoir) offer(item reservoirItem) {
+	switch {
+	case len(r.items) < r.n:
+		heap.Push(r, item)
+	case item.key > r.items[0].key:
+		r.items[0] = item
+		heap.Fix(r, 0)
+	}
+}
+
+// sorted returns the items of the reservoir in stream order.
+func (r *reservoir) sorted() []reservoirItem {
+	items := slices.Clone(r.items)
+	sort.Slice(items, func(i, j int) bool { return items[i].index < items[j].index })
+	return items
+}
+
+// sampleIndexes returns n distinct indexes drawn uniformly from [0, total),
+// in the order they were drawn, following Floyd's algorithm.
+func sampleIndexes(rng *rand.Rand, n int, total int64) []int64 {
+	n = int(min(int64(n), total))
+	indexes := make([]int64, 0, n)
+	seen := make(map[int64]struct{}, n)
+	for j := total - int64(n); j < total; j++ {
+		i := rng.Int64N(j + 1)
+		if _, ok := seen[i]; ok {
+			i = j
+		}
+		seen[i] = struct{}{}
+		indexes = append(indexes, i)
+	}
+	return indexes
+}
+
+// Sample returns n random rows of a Parquet file, without replacement and in
+// file order, among the rows selected by the read options. Uniform samples of
+// files read without a filter or partition only decode the sampled rows;
+// otherwise rows are streamed through a reservoir, so that the file is never
+// loaded in full.
+func (p *Parquet) Sample(filename string, n int, options ...map[string]interface{}) ([]map[string]interface{}, error) {
+	rows, _, err := p.sample(filename, n, options...)
+	return rows, err
+}
+
+// sample returns n random rows of a Parquet file with their field order.
+func (p *Parquet) sample(
+	filename string, n int, options ...map[string]interface{},
+) ([]map[string]interface{}, *fieldOrder, error) {
+	if n < 0 {
+		return nil, nil, fmt.Errorf("invalid sample size %d", n)
+	}
+	if n == 0 {
+		return []map[string]interface{}{}, nil, nil
+	}
+	opts, err := parseReadOptions(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	sampleOpts, err := parseSampleOptions(options...)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := p.resolvePartition(opts.Partition); err != nil {
+		return nil, nil, err
+	}
+
+	file, pf, err := p.files.open(p.files.resolve(filename))
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+	order := schemaOrder(pf.Schema())
+
+	var weight []string
+	if sampleOpts.Weight != "" {
+		if weight, err = weightPath(pf.Schema(), sampleOpts.Weight); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	rng := p.rand(nil, sampleOpts)
+	scanOpts := opts
+	var selected []int64
+
+	if weight == nil && opts.Filter == nil && opts.Partition == nil {
+		// Every row of the window is a candidate: draw the rows up front and
+		// only decode them.
+		offset := min(int64(opts.SkipRows), pf.NumRows())
+		total := pf.NumRows() - offset
+		if opts.RowLimit > 0 {
+			total = min(total, int64(opts.RowLimit))
+		}
+		selected = sampleIndexes(rng, n, total)
+		for i := range selected {
+			selected[i] += offset
+		}
+		slices.Sort(selected)
+		scanOpts.SkipRows = 0
+		scanOpts.RowLimit = -1
+	} else if weight != nil && len(opts.Columns) > 0 {
+		scanOpts.Columns = append(slices.Clone(opts.Columns), sampleOpts.Weight)
+	}
+
+	sc, err := newScanner(pf, &scanOpts)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if selected != nil {
+		sc.selected = selected
+		results := make([]map[string]interface{}, 0, len(selected))
+		err := sc.scan(func(row map[string]interface{}) error {
+			results = append(results, row)
+			return nil
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return results, order, nil
+	}
+
+	r := &reservoir{n: n}
+	var index int64
+	err = sc.scan(func(row map[string]interface{}) error {
+		w := 1.0
+		if weight != nil {
+			var ok bool
+			if w, ok = weightOf(row, weight); !ok {
+				index++
+				return nil
+			}
+		}
+		r.offer(reservoirItem{key: sampleKey(rng, w), index: index, row: row})
+		index++
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items := r.sorted()
+	results := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		results[i] = item.row
+		if len(scanOpts.Columns) != len(opts.Columns) {
+			results[i] = projectColumns(item.row, opts.Columns)
+		}
+	}
+	return results, order, nil
+}
+
+// sampleJS is the JS binding of Sample.
+func (p *Parquet) sampleJS(filename string, n int, options ...map[string]interface{}) (interface{}, error) {
+	rows, order, err := p.sample(filename, n, options...)
+	if err != nil {
+		return nil, err
+	}
+	return p.export(rows, order, exportOptions(options...)), nil
+}
+
+// datasetWeights holds the cumulative weights of the rows of a dataset.
+type datasetWeights struct {
+	cumulative []float64
+	positive   int64 // number of rows with a positive weight
+}
+
+// weightsLoad is a load of the weights of a column in progress, whose
+// result is shared by the VUs sampling by that column.
+type weightsLoad struct {
+	done    chan struct{}
+	weights *datasetWeights
+	err     error
+}
+
+// weights returns the cumulative weights of the rows of the dataset by the
+// given column, reading only that column the first time. The column is read
+// outside of the lock, so that other VUs keep reading rows meanwhile, and
+// once for concurrent samples by the same column.
+func (ds *Dataset) weights(column string) (*datasetWeights, error) {
+	ds.mu.Lock()
+	if w, ok := ds.weightsByColumn[column]; ok {
+		ds.mu.Unlock()
+		return w, nil
+	}
+	if l, ok := ds.weightsLoading[column]; ok {
+		ds.mu.Unlock()
+		<-l.done
+		return l.weights, l.err
+	}
+	l := &weightsLoad{done: make(chan struct{})}
+	if ds.weightsLoading == nil {
+		ds.weightsLoading = make(map[string]*weightsLoad)
+	}
+	ds.weightsLoading[column] = l
+	ds.mu.Unlock()
+
+	l.weights, l.err = ds.loadWeights(column)
+
+	ds.mu.Lock()
+	delete(ds.weightsLoading, column)
+	if l.err == nil {
+		if ds.weightsByColumn == nil {
+			ds.weightsByColumn = make(map[string]*datasetWeights)
+		}
+		ds.weightsByColumn[column] = l.weights
+	}
+	ds.mu.Unlock()
+	close(l.done)
+
+	return l.weights, l.err
+}
+
+// loadWeights reads the cumulative weights of the rows of the dataset by the
+// given column.
+func (ds *Dataset) loadWeights(column string) (*datasetWeights, error) {
+	path, err := weightPath(ds.pf.Schema(), column)
+	if err != nil {
+		return nil, err
+	}
+
+	opts := ReadOptions{
+		Columns:        []string{column},
+		SkipRows:       int(ds.offset),
+		RowLimit:       int(ds.length),
+		BufferSize:     1000,
+		ConvertOptions: ConvertOptions{Timestamps: timestampsRaw},
+	}
+	sc, err := newScanner(ds.pf, &opts)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &datasetWeights{cumulative: make([]float64, 0, ds.length)}
+	var total float64
+	if ds.length > 0 {
+		err = sc.scan(func(row map[string]interface{}) error {
+			if weight, ok := weightOf(row, path); ok {
+				total += weight
+				w.positive++
+			}
+			w.cumulative = append(w.cumulative, total)
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// Random returns n distinct random rows of the dataset drawn with rng, in
+// random order, uniformly or weighted by a column when weight is not empty.
+// The returned rows are shared and must not be modified.
+func (ds *Dataset) Random(rng *rand.Rand, n int, weight string) ([]map[string]interface{}, error) {
+	var indexes []int64
+	if weight == "" {
+		// Floyd's algorithm draws distinct indexes, but later draws are
+		// biased towards the last indexes: shuffle them.
+		indexes = sampleIndexes(rng, n, ds.length)
+		rng.Shuffle(len(indexes), func(i, j int) { indexes[i], indexes[j] = indexes[j], indexes[i] })
+	} else {
+		w, err := ds.weights(weight)
+		if err != nil {
+			return nil, err
+		}
+		indexes = w.sample(rng, n)
+	}
+
+	rows := make([]map[string]interface{}, len(indexes))
+	for i, index := range indexes {
+		row, err := ds.Row(index)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = row
+	}
+	return rows, nil
+}
+
+// sample draws n distinct row indexes with probabilities proportional to
+// their weights, in random order.
+func (w *datasetWeights) sample(rng *rand.Rand, n int) []int64 {
+	n = int(min(int64(n), w.positive))
+	if n == 0 {
+		return nil
+	}
+	total := w.cumulative[len(w.cumulative)-1]
+
+	// A single row is found by bisecting the cumulative weights.
+	if n == 1 {
+		target := rng.Float64() * total
+		i := sort.Search(len(w.cumulative), func(i int) bool { return w.cumulative[i] > target })
+		return []int64{int64(min(i, len(w.cumulative)-1))}
+	}
+
+	r := &reservoir{n: n}
+	previous := 0.0
+	for i, c := range w.cumulative {
+		if weight := c - previous; weight > 0 {
+			r.offer(reservoirItem{key: sampleKey(rng, weight), index: int64(i)})
+		}
+		previous = c
+	}
+	indexes := make([]int64, len(r.items))
+	for i, item := range r.items {
+		indexes[i] = item.index
+	}
+	rng.Shuffle(len(indexes), func(i, j int) { indexes[i], indexes[j] = indexes[j], indexes[i] })
+	return indexes
+}
+
+// datasetPrototype returns the prototype of the JS views of datasets: an
+// Array prototype extended with the random() method.
+func (p *Parquet) datasetPrototype(e *exporter, ds *Dataset) *sobek.Object {
+	rt := e.rt
+	proto := rt.NewObject()
+	if err := proto.SetPrototype(rt.NewArray().Prototype()); err != nil {
+		common.Throw(rt, err)
+	}
+
+	// random(n?, options?) returns a random row, or an array of n distinct
+	// random rows when n is given.
+	random := func(call sobek.FunctionCall) sobek.Value {
+		n, single := 1, true
+		var options map[string]interface{}
+		for _, arg := range call.Arguments {
+			if sobek.IsUndefined(arg) || sobek.IsNull(arg) {
+				continue
+			}
+			switch v := arg.Export().(type) {
+			case map[string]interface{}:
+				options = v
+			default:
+				count, ok := toInt64(v)
+				if !ok || count < 0 {
+					common.Throw(rt, fmt.Errorf("invalid sample size %v", v))
+				}
+				n, single = int(count), false
+			}
+		}
+
+		opts, err := parseSampleOptions(options)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		rows, err := ds.Random(p.rand(ds, opts), n, opts.Weight)
+		if err != nil {
+			common.Throw(rt, err)
+		}
+		if !single {
+			return e.value(rows)
+		}
+		if len(rows) == 0 {
+			return sobek.Null()
+		}
+		return e.value(rows[0])
+	}
+
+	if err := proto.Set("random", random); err != nil {
+		common.Throw(rt, err)
+	}
+	return proto
+}
@@ -0,0 +1,317 @@
+package parquet
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"go.k6.io/k6/js/modulestest"
+)
+
+// createWeightedParquetFile creates a Parquet file of ten rows whose weight
+// is their id, except for the last row which has no weight.
+func createWeightedParquetFile(t *testing.T) string {
+	t.Helper()
+
+	rows := make([]map[string]interface{}, 10)
+	for i := range rows {
+		rows[i] = map[string]interface{}{"id": int64(i), "weight": float64(i)}
+	}
+	rows[9]["weight"] = nil
+	return writeTestFile(t, map[string]interface{}{
+		"id":     "int64",
+		"weight": map[string]interface{}{"type": "double", "optional": true},
+	}, rows)
+}
+
+// sampleIDs returns the ids of sampled rows.
+func sampleIDs(rows []map[string]interface{}) []int64 {
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row["id"].(int64)
+	}
+	return ids
+}
+
+func TestSample(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Uniform sample", func(t *testing.T) {
+		rows, err := p.Sample(filename, 50)
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		ids := sampleIDs(rows)
+		if len(ids) != 50 || !slices.IsSorted(ids) || len(slices.Compact(slices.Clone(ids))) != 50 {
+			t.Errorf("expected 50 distinct ids in file order, got %v", ids)
+		}
+	})
+
+	t.Run("Seeded samples are reproducible sequences", func(t *testing.T) {
+		for _, options := range []map[string]interface{}{
+			{"seed": 42},
+			{"seed": 42, "filter": "id >= 1000"},
+		} {
+			vu := &Parquet{cache: p.cache}
+			first, _ := vu.Sample(filename, 20, options)
+			second, _ := vu.Sample(filename, 20, options)
+			if slices.Equal(sampleIDs(first), sampleIDs(second)) {
+				t.Errorf("%v: expected successive samples to differ, got %v twice", options, sampleIDs(first))
+			}
+
+			other := &Parquet{cache: p.cache}
+			replayed, _ := other.Sample(filename, 20, options)
+			replayedSecond, _ := other.Sample(filename, 20, options)
+			if !slices.Equal(sampleIDs(first), sampleIDs(replayed)) ||
+				!slices.Equal(sampleIDs(second), sampleIDs(replayedSecond)) {
+				t.Errorf("%v: expected the same sequence of samples, got %v, %v and %v, %v", options,
+					sampleIDs(first), sampleIDs(second), sampleIDs(replayed), sampleIDs(replayedSecond))
+			}
+
+			options["seed"] = 43
+			third, _ := other.Sample(filename, 20, options)
+			if slices.Equal(sampleIDs(first), sampleIDs(third)) {
+				t.Errorf("%v: expected different samples for different seeds", options)
+			}
+		}
+	})
+
+	t.Run("Read options select the candidates", func(t *testing.T) {
+		rows, err := p.Sample(filename, 10, map[string]interface{}{
+			"skipRows": 100, "rowLimit": 20, "columns": []interface{}{"id"},
+		})
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		for _, row := range rows {
+			if id := row["id"].(int64); id < 100 || id >= 120 || len(row) != 1 {
+				t.Errorf("unexpected row %v", row)
+			}
+		}
+
+		rows, err = p.Sample(filename, 10, map[string]interface{}{"filter": "id < 5"})
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		if ids := sampleIDs(rows); !slices.Equal(ids, []int64{0, 1, 2, 3, 4}) {
+			t.Errorf("expected every matching row, got %v", ids)
+		}
+
+		rows, err = p.Sample(filename, 10, map[string]interface{}{
+			"partition": map[string]interface{}{"mode": "roundRobin", "index": 1, "count": 8},
+		})
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		for _, id := range sampleIDs(rows) {
+			if id%8 != 1 {
+				t.Errorf("row %d is not in the partition", id)
+			}
+		}
+	})
+
+	t.Run("Only sampled rows are decoded", func(t *testing.T) {
+		file, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		defer file.Close()
+
+		opts, _ := parseReadOptions()
+		sc, err := newScanner(pf, &opts)
+		if err != nil {
+			t.Fatalf("newScanner() error = %v", err)
+		}
+		sc.selected = []int64{3, 699, 700, 2999}
+
+		var ids []int64
+		if err := sc.scan(func(row map[string]interface{}) error {
+			ids = append(ids, row["id"].(int64))
+			return nil
+		}); err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+		if !slices.Equal(ids, []int64{3, 699, 700, 2999}) || sc.decoded != 4 {
+			t.Errorf("expected the 4 selected rows to be decoded, got %v (%d decoded)", ids, sc.decoded)
+		}
+	})
+
+	t.Run("Weighted sample", func(t *testing.T) {
+		weighted := createWeightedParquetFile(t)
+		counts := make(map[int64]int)
+		for seed := 0; seed < 2000; seed++ {
+			rows, err := p.Sample(weighted, 1, map[string]interface{}{
+				"weight": "weight", "seed": seed, "columns": []interface{}{"id"},
+			})
+			if err != nil {
+				t.Fatalf("Sample() error = %v", err)
+			}
+			if len(rows) != 1 || len(rows[0]) != 1 {
+				t.Fatalf("expected a single projected row, got %v", rows)
+			}
+			counts[rows[0]["id"].(int64)]++
+		}
+		if counts[0] != 0 || counts[9] != 0 {
+			t.Errorf("rows without a positive weight were sampled: %v", counts)
+		}
+		if counts[8] < 4*counts[1] {
+			t.Errorf("expected row 8 to be sampled far more often than row 1: %v", counts)
+		}
+
+		rows, _ := p.Sample(weighted, 20, map[string]interface{}{"weight": "weight"})
+		if len(rows) != 8 {
+			t.Errorf("expected the 8 rows with a positive weight, got %d", len(rows))
+		}
+	})
+
+	t.Run("Decimal weights", func(t *testing.T) {
+		weighted := writeTestFile(t, map[string]interface{}{
+			"id":     "int64",
+			"name":   "string",
+			"weight": map[string]interface{}{"type": "decimal", "precision": 9, "scale": 2},
+		}, []map[string]interface{}{
+			{"id": int64(0), "name": "a", "weight": "0"},
+			{"id": int64(1), "name": "b", "weight": "0.25"},
+			{"id": int64(2), "name": "c", "weight": "-1.5"},
+			{"id": int64(3), "name": "d", "weight": "12.5"},
+		})
+
+		rows, err := p.Sample(weighted, 4, map[string]interface{}{"weight": "weight"})
+		if err != nil {
+			t.Fatalf("Sample() error = %v", err)
+		}
+		ids := sampleIDs(rows)
+		slices.Sort(ids)
+		if !slices.Equal(ids, []int64{1, 3}) {
+			t.Errorf("expected the rows with a positive DECIMAL weight, got %v", ids)
+		}
+
+		if _, err := p.Sample(weighted, 1, map[string]interface{}{"weight": "name"}); err == nil {
+			t.Error("expected an error for a non-numeric weight column")
+		}
+	})
+
+	t.Run("Invalid options", func(t *testing.T) {
+		if _, err := p.Sample(filename, -1); err == nil {
+			t.Error("expected an error for a negative size")
+		}
+		for _, options := range []map[string]interface{}{
+			{"weight": "missing"},
+			{"weight": 42},
+			{"seed": "abc"},
+		} {
+			if _, err := p.Sample(filename, 1, options); err == nil {
+				t.Errorf("%v: expected an error", options)
+			}
+		}
+	})
+}
+
+func TestDatasetRandom(t *testing.T) {
+	filename := createWeightedParquetFile(t)
+
+	t.Run("Uniform and weighted rows", func(t *testing.T) {
+		opts, _ := parseReadOptions(map[string]interface{}{"rowLimit": 9})
+		ds, err := openDataset(nil, "weighted", filename, opts)
+		if err != nil {
+			t.Fatalf("openDataset() error = %v", err)
+		}
+
+		p := &Parquet{}
+		rows, err := ds.Random(p.rand(ds, SampleOptions{}), 20, "")
+		if err != nil {
+			t.Fatalf("Random() error = %v", err)
+		}
+		ids := sampleIDs(rows)
+		slices.Sort(ids)
+		if !slices.Equal(ids, []int64{0, 1, 2, 3, 4, 5, 6, 7, 8}) {
+			t.Errorf("expected every row once, got %v", ids)
+		}
+
+		// Every row is sampled, in an order that depends on the seed.
+		firsts := make(map[int64]bool)
+		shuffled := &Parquet{}
+		for seed := int64(0); seed < 50; seed++ {
+			rows, _ := ds.Random(shuffled.rand(ds, SampleOptions{Seed: seed, HasSeed: true}), 9, "")
+			firsts[rows[0]["id"].(int64)] = true
+		}
+		if len(firsts) < 5 {
+			t.Errorf("expected shuffled rows, got first rows %v", firsts)
+		}
+
+		seeded := SampleOptions{Seed: 7, HasSeed: true}
+		first, _ := ds.Random(p.rand(ds, seeded), 3, "")
+		second, _ := ds.Random(p.rand(ds, seeded), 3, "")
+		other := &Parquet{}
+		replayed, _ := ds.Random(other.rand(ds, seeded), 3, "")
+		replayedSecond, _ := ds.Random(other.rand(ds, seeded), 3, "")
+		if !slices.Equal(sampleIDs(first), sampleIDs(replayed)) || !slices.Equal(sampleIDs(second), sampleIDs(replayedSecond)) {
+			t.Errorf("expected the same sequence of samples, got %v, %v and %v, %v",
+				sampleIDs(first), sampleIDs(second), sampleIDs(replayed), sampleIDs(replayedSecond))
+		}
+
+		for seed := int64(0); seed < 200; seed++ {
+			rows, err := ds.Random(p.rand(ds, SampleOptions{Seed: seed, HasSeed: true}), 2, "weight")
+			if err != nil {
+				t.Fatalf("Random() error = %v", err)
+			}
+			if len(rows) != 2 || rows[0]["id"] == rows[1]["id"] {
+				t.Fatalf("expected 2 distinct rows, got %v", rows)
+			}
+			for _, row := range rows {
+				if row["id"].(int64) == 0 {
+					t.Fatalf("row without a positive weight was sampled")
+				}
+			}
+		}
+		if _, err := ds.Random(p.rand(ds, SampleOptions{}), 1, "missing"); err == nil {
+			t.Error("expected an error for an unknown weight column")
+		}
+	})
+
+	t.Run("JS random()", func(t *testing.T) {
+		runtime := modulestest.NewRuntime(t)
+		p := New().NewModuleInstance(runtime.VU)
+		if err := runtime.VU.Runtime().Set("parquet", p.Exports().Named); err != nil {
+			t.Fatalf("failed to set exports: %v", err)
+		}
+		if err := runtime.VU.Runtime().Set("filename", filename); err != nil {
+			t.Fatalf("failed to set filename: %v", err)
+		}
+
+		v, err := runtime.VU.Runtime().RunString(`
+			const ds = parquet.open('weighted', filename);
+			const row = ds.random();
+			const weighted = ds.random({ weight: 'weight', seed: 1 });
+			[
+				typeof row.id,
+				weighted.id > 0 && weighted.id < 9,
+				ds.random(4).length,
+				ds.length,
+				Array.isArray(ds),
+				ds.random(2, { seed: 3 }).map((r) => r.id).join('|'),
+				ds.random(2, { seed: 3 }).map((r) => r.id).join('|'),
+			].join(',');
+		`)
+		if err != nil {
+			t.Fatalf("script error: %v", err)
+		}
+
+		// Seeded calls continue the sequence of a generator seeded once.
+		opts, _ := parseReadOptions()
+		ds, err := openDataset(nil, "weighted", filename, opts)
+		if err != nil {
+			t.Fatalf("openDataset() error = %v", err)
+		}
+		rng := (&Parquet{}).rand(ds, SampleOptions{Seed: 3, HasSeed: true})
+		expected := "number,true,4,10,true"
+		for range 2 {
+			rows, _ := ds.Random(rng, 2, "")
+			expected += fmt.Sprintf(",%d|%d", rows[0]["id"], rows[1]["id"])
+		}
+		if v.String() != expected {
+			t.Errorf("expected %s, got %s", expected, v.String())
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// scanner reads the rows of a Parquet file selected by read options. Only
+// the column chunks of the requested columns, and of the columns referenced
+// by the filter, are read. Without a filter, skipped rows are seeked over
+// rather than decoded. When a filter is set, the row groups and pages whose
+// statistics rule it out are skipped without being decoded, and skipRows and
+// rowLimit apply to the rows matching the filter. With a partition, only the
+// rows of the partition are read, and the other options apply to them.
+type scanner struct {
+	pf   *parquet.File
+	opts *ReadOptions
+
+	// projection selects the decoded columns, nil for all columns. When the
+	// filter references columns that were not requested, decoded rows are
+	// projected again once the filter is applied.
+	projection *projection
+	reproject  bool
+	decoder    *recordDecoder
+
+	// selected holds the rows of the file to read, in ascending order, nil
+	// to read all rows.
+	selected []int64
+
+	// virtual holds the values of partition columns added to each row
+	// before the filter applies.
+	virtual map[string]interface{}
+
+	decoded int64 // number of rows decoded so far
+	skipped int
+	emitted int
+}
+
+// newScanner creates a scanner, checking the filter against the schema.
+func newScanner(pf *parquet.File, opts *ReadOptions) (*scanner, error) {
+	if err := opts.validate(pf.Schema()); err != nil {
+		return nil, err
+	}
+	return newFileScanner(pf, opts), nil
+}
+
+// validate checks the filter and the partition against a schema.
+func (o *ReadOptions) validate(schema *parquet.Schema) error {
+	if o.Partition != nil {
+		if err := o.Partition.validate(schema); err != nil {
+			return err
+		}
+	}
+	if o.Filter != nil {
+		if err := validateFilter(o.Filter, schema); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newFileScanner creates a scanner whose options have been validated, for
+// files of a dataset against the unified schema. Columns missing from the
+// file are read as missing values.
+func newFileScanner(pf *parquet.File, opts *ReadOptions) *scanner {
+	s := &scanner{pf: pf, opts: opts}
+
+	columns := opts.Columns
+	if opts.Filter != nil {
+		if len(columns) > 0 {
+			columns = append([]string(nil), columns...)
+			for _, path := range opts.Filter.paths() {
+				columns = append(columns, strings.Join(path, "."))
+			}
+			s.reproject = !slices.Equal(normalizeColumns(columns), normalizeColumns(opts.Columns))
+		}
+	}
+
+	s.projection = newProjection(pf.Schema(), columns)
+	if s.projection != nil {
+		s.decoder = newRecordDecoder(s.projection.schema)
+	} else {
+		s.decoder = newRecordDecoder(pf.Schema())
+	}
+	return s
+}
+
+// scan calls emit with each selected row, in file order, until all rows are
+// read, the row limit is reached or emit returns an error.
+func (s *scanner) scan(emit func(map[string]interface{}) error) error {
+	buf := make([]parquet.Row, max(s.opts.BufferSize, 1))
+
+	var start int64
+	for g, rg := range s.pf.RowGroups() {
+		ranges, err := s.rowRanges(g, rg, start)
+		start += rg.NumRows()
+		if err != nil {
+			return err
+		}
+		if len(ranges) == 0 {
+			continue
+		}
+
+		if s.projection != nil {
+			rg = s.projection.rowGroup(rg)
+		}
+		done, err := s.scanRowGroup(rg, ranges, buf, emit)
+		if err != nil || done {
+			return err
+		}
+	}
+	return nil
+}
+
+// rowRanges returns the ranges of rows to read in the g-th row group of the
+// file, which starts at the given row: the rows that the filter statistics
+// do not rule out and that belong to the partition, less the rows still to
+// be skipped when there is no filter.
+func (s *scanner) rowRanges(g int, rg parquet.RowGroup, start int64) ([]rowRange, error) {
+	ranges := []rowRange{{0, rg.NumRows()}}
+	if s.opts.Filter != nil {
+		ranges = s.opts.Filter.rowRanges(rg, s.pf.Schema(), &s.opts.ConvertOptions)
+	}
+
+	if s.opts.Partition != nil && len(ranges) > 0 {
+		partition, err := s.opts.Partition.rowRanges(s.pf, g, start)
+		if err != nil {
+			return nil, err
+		}
+		ranges = intersectRanges(ranges, partition)
+	}
+
+	if s.selected != nil && len(ranges) > 0 {
+		ranges = intersectRanges(ranges, s.selectedRanges(start, start+rg.NumRows()))
+	}
+
+	if s.opts.Filter == nil && s.skipped < s.opts.SkipRows {
+		ranges = s.skipRows(ranges)
+	}
+	return ranges, nil
+}
+
+// selectedRanges consumes the selected rows of the file before end, and
+// returns them as ranges of rows of the row group starting at start.
+func (s *scanner) selectedRanges(start, end int64) []rowRange {
+	var ranges []rowRange
+	for len(s.selected) > 0 && s.selected[0] < end {
+		if row := s.selected[0]; row >= start {
+			ranges = appendRange(ranges, rowRange{row - start, row - start + 1})
+		}
+		s.selected = s.selected[1:]
+	}
+	return ranges
+}
+
+// skipRows skips the rows still to be skipped at the start of the ranges of
+// a row group without reading them, and returns the remaining ranges.
+// Without a filter, every row counts, so whole row groups are skipped from
+// their row counts and the first remaining row is reached by seeking,
+// through the page offset index when the file has one.
+func (s *scanner) skipRows(ranges []rowRange) []rowRange {
+	for len(ranges) > 0 && s.skipped < s.opts.SkipRows {
+		n := min(int64(s.opts.SkipRows-s.skipped), ranges[0].end-ranges[0].start)
+		s.skipped += int(n)
+		ranges[0].start += n
+		if ranges[0].start == ranges[0].end {
+			ranges = ranges[1:]
+		}
+	}
+	return ranges
+}
+
+// scanRowGroup reads the given ranges of rows of a row group. It reports
+// whether the row limit has been reached.
+func (s *scanner) scanRowGroup(
+	rg parquet.RowGroup, ranges []rowRange, buf []parquet.Row, emit func(map[string]interface{}) error,
+) (bool, error) {
+	// None of the requested columns exist: rows are empty.
+	if len(rg.ColumnChunks()) == 0 {
+		for _, r := range ranges {
+			for i := r.start; i < r.end; i++ {
+				if done, err := s.emit(map[string]interface{}{}, emit); err != nil || done {
+					return done, err
+				}
+			}
+		}
+		return false, nil
+	}
+
+	rows := rg.Rows()
+	defer rows.Close()
+
+	var pos int64
+	for _, r := range ranges {
+		// Short gaps between ranges are read through without decoding the
+		// rows, rather than seeking, which decodes the current page again.
+		if r.start < pos || r.start-pos > int64(len(buf)) {
+			if err := rows.SeekToRow(r.start); err != nil {
+				return false, fmt.Errorf("failed to seek to row: %w", err)
+			}
+			pos = r.start
+		}
+		for pos < r.start {
+			n, err := rows.ReadRows(buf[:r.start-pos])
+			pos += int64(n)
+			if err != nil || n == 0 {
+				return false, readError(err)
+			}
+		}
+
+		for pos < r.end {
+			n, err := rows.ReadRows(buf[:min(int64(len(buf)), r.end-pos)])
+			pos += int64(n)
+
+			for _, row := range buf[:n] {
+				s.decoded++
+				if done, err := s.emit(s.decoder.decode(row, &s.opts.ConvertOptions), emit); err != nil || done {
+					return done, err
+				}
+			}
+
+			if err != nil || n == 0 {
+				if err := readError(err); err != nil {
+					return false, err
+				}
+				break
+			}
+		}
+	}
+	return false, nil
+}
+
+// readError wraps the errors of reading rows, ignoring the end of the rows.
+func readError(err error) error {
+	if err == nil || errors.Is(err, io.EOF) {
+		return nil
+	}
+	return fmt.Errorf("failed to read rows: %w", err)
+}
+
+// emit applies the filter, the row window and the projection to a decoded
+// row before passing it to the callback. It reports whether the row limit
+// has been reached.
+func (s *scanner) emit(row map[string]interface{}, emit func(map[string]interface{}) error) (bool, error) {
+	for key, value := range s.virtual {
+		row[key] = value
+	}
+	if s.opts.Filter != nil && !s.opts.Filter.match(row) {
+		return false, nil
+	}
+	if s.skipped < s.opts.SkipRows {
+		s.skipped++
+		return false, nil
+	}
+	if s.reproject {
+		row = projectColumns(row, s.opts.Columns)
+	}
+	if err := emit(row); err != nil {
+		return false, err
+	}
+	s.emitted++
+	return s.opts.RowLimit > 0 && s.emitted >= s.opts.RowLimit, nil
+}
@@ -0,0 +1,63 @@
+package parquet
+
+import (
+	"testing"
+)
+
+func TestScannerSkipRows(t *testing.T) {
+	filename := createRowGroupsParquetFile(t, 3000, 700)
+
+	file, pf, err := openParquetFile(osFS, filename)
+	if err != nil {
+		t.Fatalf("openParquetFile() error = %v", err)
+	}
+	defer file.Close()
+
+	tests := []struct {
+		skipRows int
+		rowLimit int
+		expected int
+	}{
+		{0, 10, 10},
+		{699, 10, 10},
+		{700, 10, 10},
+		{2500, 10, 10},
+		{2995, 10, 5},
+		{3000, 10, 0},
+		{5000, 10, 0},
+		{1400, -1, 1600},
+	}
+
+	for _, tt := range tests {
+		opts, _ := parseReadOptions(map[string]interface{}{
+			"skipRows": tt.skipRows,
+			"rowLimit": tt.rowLimit,
+		})
+		opts.BufferSize = 4
+
+		sc, err := newScanner(pf, &opts)
+		if err != nil {
+			t.Fatalf("newScanner() error = %v", err)
+		}
+		var rows []map[string]interface{}
+		err = sc.scan(func(row map[string]interface{}) error {
+			rows = append(rows, row)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("scan() error = %v", err)
+		}
+
+		if len(rows) != tt.expected {
+			t.Errorf("skipRows %d: expected %d rows, got %d", tt.skipRows, tt.expected, len(rows))
+			continue
+		}
+		if len(rows) > 0 && rows[0]["id"] != int64(tt.skipRows) {
+			t.Errorf("skipRows %d: expected first id %d, got %v", tt.skipRows, tt.skipRows, rows[0]["id"])
+		}
+		// Skipped rows are seeked over: only the returned rows are decoded.
+		if sc.decoded != int64(tt.expected) {
+			t.Errorf("skipRows %d: expected %d decoded rows, got %d", tt.skipRows, tt.expected, sc.decoded)
+		}
+	}
+}
@@ -1,81 +1,208 @@
 package parquet
 
 import (
-	"fmt"
-	"os"
+	"strings"
 
 	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
 )
 
-// GetSchema retrieves and returns the schema of a Parquet file.
+// GetSchema retrieves and returns the schema of a Parquet file, or the
+// unified schema of the files matching a glob pattern or in a directory.
 func (p *Parquet) GetSchema(filename string) (map[string]interface{}, error) {
-	// #nosec G304 -- Users need to open files specified in k6 scripts
-	file, err := os.Open(filename)
+	set, err := p.files.openSet(p.files.resolve(filename), nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	defer set.Close()
 
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
-	}
+	return ConvertSchema(set.schema), nil
+}
 
-	pf, err := parquet.OpenFile(file, stat.Size())
+// GetMetadata retrieves and returns metadata about a Parquet file, or the
+// aggregated metadata of the files matching a glob pattern or in a
+// directory. The timestamps option applies to the column statistics.
+func (p *Parquet) GetMetadata(filename string, options ...map[string]interface{}) (map[string]interface{}, error) {
+	opts, err := parseReadOptions(options...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+		return nil, err
 	}
-
-	return ConvertSchema(pf.Schema()), nil
+	return p.metadata(p.files.resolve(filename), &opts.ConvertOptions)
 }
 
-// GetMetadata retrieves and returns metadata about a Parquet file.
-func (p *Parquet) GetMetadata(filename string) (map[string]interface{}, error) {
-	// #nosec G304 -- Users need to open files specified in k6 scripts
-	file, err := os.Open(filename)
+// metadata returns the metadata of a resolved path, with column statistics
+// converted as set by opts.
+func (p *Parquet) metadata(filename string, opts *ConvertOptions) (map[string]interface{}, error) {
+	set, err := p.files.openSet(filename, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
+	defer set.Close()
 
-	stat, err := file.Stat()
-	if err != nil {
-		return nil, fmt.Errorf("failed to stat file: %w", err)
-	}
+	return set.metadata(opts), nil
+}
 
-	pf, err := parquet.OpenFile(file, stat.Size())
+// getMetadataJS is the JS binding of GetMetadata, converting the column
+// statistics as set by the int64Mode and binary options.
+func (p *Parquet) getMetadataJS(filename string, options ...map[string]interface{}) (interface{}, error) {
+	metadata, err := p.GetMetadata(filename, options...)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open parquet file: %w", err)
+		return nil, err
 	}
+	return p.export(metadata, nil, exportOptions(options...)), nil
+}
 
+// metadataOf returns the metadata of a Parquet file, with column statistics
+// converted as set by opts.
+func metadataOf(pf *parquet.File, opts *ConvertOptions) map[string]interface{} {
 	metadata := make(map[string]interface{})
 
 	// File-level information
 	metadata["numRows"] = pf.NumRows()
 	metadata["numRowGroups"] = len(pf.RowGroups())
 	metadata["numColumns"] = len(pf.Schema().Fields())
-	metadata["size"] = stat.Size()
+	metadata["size"] = pf.Size()
+	metadata["createdBy"] = pf.Metadata().CreatedBy
+	metadata["keyValueMetadata"] = keyValueMetadata(pf)
 
 	// Row group information
 	rowGroups := make([]map[string]interface{}, 0)
-	for i, rg := range pf.RowGroups() {
-		rgInfo := map[string]interface{}{
-			"index":      i,
-			"numRows":    rg.NumRows(),
-			"numColumns": len(rg.ColumnChunks()),
-		}
-		rowGroups = append(rowGroups, rgInfo)
+	for i := range pf.RowGroups() {
+		rowGroups = append(rowGroups, rowGroupMetadata(pf, i, opts))
 	}
 	metadata["rowGroups"] = rowGroups
 
 	// Schema information
 	metadata["schema"] = ConvertSchema(pf.Schema())
 
-	return metadata, nil
+	return metadata
+}
+
+// keyValueMetadata returns the key-value metadata of the footer of a file,
+// such as the Arrow schema or the Spark row metadata. Later entries of a
+// duplicated key win.
+func keyValueMetadata(pf *parquet.File) map[string]interface{} {
+	entries := pf.Metadata().KeyValueMetadata
+	metadata := make(map[string]interface{}, len(entries))
+	for _, kv := range entries {
+		metadata[kv.Key] = kv.Value
+	}
+	return metadata
+}
+
+// rowGroupMetadata returns the metadata of a row group of a file, with the
+// details of its column chunks.
+func rowGroupMetadata(pf *parquet.File, index int, opts *ConvertOptions) map[string]interface{} {
+	rg := pf.RowGroups()[index]
+	chunks := rg.ColumnChunks()
+	columns := make([]map[string]interface{}, len(chunks))
+	for i, chunk := range chunks {
+		columns[i] = columnChunkMetadata(pf, index, chunk, opts)
+	}
+	return map[string]interface{}{
+		"index":      index,
+		"numRows":    rg.NumRows(),
+		"numColumns": len(chunks),
+		"columns":    columns,
+	}
+}
+
+// columnChunkMetadata returns the details of a column chunk from the file
+// metadata: sizes, codec, encodings, statistics converted like the values of
+// the column, and whether it has a dictionary page and a bloom filter. The
+// number of pages comes from the offset index, or from the page encoding
+// statistics, and is omitted when the file has neither.
+func columnChunkMetadata(pf *parquet.File, rowGroup int, chunk parquet.ColumnChunk, opts *ConvertOptions) map[string]interface{} {
+	md := &pf.Metadata().RowGroups[rowGroup].Columns[chunk.Column()].MetaData
+	path := pf.Schema().Columns()[chunk.Column()]
+
+	encodings := make([]string, len(md.Encoding))
+	for i, encoding := range md.Encoding {
+		encodings[i] = encoding.String()
+	}
+	info := map[string]interface{}{
+		"column":            strings.Join(path, "."),
+		"type":              md.Type.String(),
+		"codec":             md.Codec.String(),
+		"encodings":         encodings,
+		"numValues":         md.NumValues,
+		"compressedSize":    md.TotalCompressedSize,
+		"uncompressedSize":  md.TotalUncompressedSize,
+		"hasDictionaryPage": md.DictionaryPageOffset > 0,
+		"hasBloomFilter":    md.BloomFilterOffset > 0,
+	}
+
+	leaf, _ := pf.Schema().Lookup(path...)
+	if stats := columnStatistics(leaf, &md.Statistics, opts); len(stats) > 0 {
+		info["statistics"] = stats
+	}
+
+	if offsetIndex, err := chunk.OffsetIndex(); err == nil && offsetIndex != nil {
+		info["numPages"] = offsetIndex.NumPages()
+	} else if len(md.EncodingStats) > 0 {
+		numPages := 0
+		for _, stats := range md.EncodingStats {
+			if stats.PageType == format.DataPage || stats.PageType == format.DataPageV2 {
+				numPages += int(stats.Count)
+			}
+		}
+		info["numPages"] = numPages
+	}
+	return info
+}
+
+// columnStatistics returns the statistics of a column chunk: its min and max
+// values, converted like the values of the column, and its null and distinct
+// counts. The legacy min and max fields are only used for signed numeric
+// columns, since they are in signed order, which is wrong for unsigned
+// integers, and their order is undefined for byte arrays. Null counts are
+// only reported with bounds, as writers may leave them unset, and distinct
+// counts when set.
+func columnStatistics(leaf parquet.LeafColumn, stats *format.Statistics, opts *ConvertOptions) map[string]interface{} {
+	minBytes, maxBytes := stats.MinValue, stats.MaxValue
+	kind := leaf.Node.Type().Kind()
+	lt := leaf.Node.Type().LogicalType()
+	unsigned := lt != nil && lt.Integer != nil && !lt.Integer.IsSigned
+	if (minBytes == nil || maxBytes == nil) && !unsigned && kind != parquet.ByteArray && kind != parquet.FixedLenByteArray {
+		minBytes, maxBytes = stats.Min, stats.Max
+	}
+
+	result := make(map[string]interface{})
+	if validStatistic(kind, minBytes) && validStatistic(kind, maxBytes) {
+		convert := newValueConverter(leaf.Node)
+		result["min"] = convert(kind.Value(minBytes), opts)
+		result["max"] = convert(kind.Value(maxBytes), opts)
+		result["nullCount"] = stats.NullCount
+	}
+	if stats.DistinctCount > 0 {
+		result["distinctCount"] = stats.DistinctCount
+	}
+	return result
+}
+
+// validStatistic reports whether b is the plain encoding of a value of the
+// kind, which parquet.Kind.Value requires. INT96 statistics are deprecated
+// and ignored.
+func validStatistic(kind parquet.Kind, b []byte) bool {
+	switch kind {
+	case parquet.Boolean:
+		return len(b) == 1
+	case parquet.Int32, parquet.Float:
+		return len(b) == 4
+	case parquet.Int64, parquet.Double:
+		return len(b) == 8
+	case parquet.ByteArray, parquet.FixedLenByteArray:
+		return b != nil
+	default:
+		return false
+	}
 }
 
-// Close cleans up resources and clears the cache.
+// Close releases the cache entries referenced by this VU, clears the entries
+// that are no longer used by any VU and closes the iterators of the VU.
 func (p *Parquet) Close() error {
-	p.cache.Clear()
+	p.closeIterators()
+	p.releaseAll()
+	p.cache.ClearUnused()
 	return nil
 }
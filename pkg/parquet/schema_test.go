@@ -1,11 +1,15 @@
 package parquet
 
 import (
+	"encoding/binary"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/format"
 )
 
 func TestGetSchema(t *testing.T) {
@@ -52,20 +56,20 @@ func TestGetSchema(t *testing.T) {
 			t.Fatal("expected non-nil schema")
 		}
 
-		if len(schema) == 0 {
+		if len(schema["fields"].([]interface{})) == 0 {
 			t.Error("expected at least one field in schema")
 		}
 
 		// Verify some expected fields exist
 		expectedFields := []string{"id", "name", "active", "score", "age", "metadata"}
 		for _, expectedField := range expectedFields {
-			if _, ok := schema[expectedField]; !ok {
+			if schemaField(schema, expectedField) == nil {
 				t.Errorf("expected field %s not found in schema", expectedField)
 			}
 		}
 
 		// Verify field has expected properties
-		if idField, ok := schema["id"].(map[string]interface{}); ok {
+		if idField := schemaField(schema, "id"); idField != nil {
 			if _, ok := idField["type"]; !ok {
 				t.Error("expected id field to have 'type' property")
 			}
@@ -215,6 +219,105 @@ func TestGetMetadata(t *testing.T) {
 		}
 	})
 
+	t.Run("Column chunk details", func(t *testing.T) {
+		metadata, err := p.GetMetadata(filename)
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		rg := metadata["rowGroups"].([]map[string]interface{})[0]
+		columns := rg["columns"].([]map[string]interface{})
+		if len(columns) != 3 {
+			t.Fatalf("expected 3 column chunks, got %d", len(columns))
+		}
+
+		id := columns[0]
+		if id["column"] != "id" || id["type"] != "INT64" || id["numValues"] != int64(100) {
+			t.Errorf("unexpected id chunk %v", id)
+		}
+		if id["codec"] == "" || len(id["encodings"].([]string)) == 0 || id["numPages"] != 1 {
+			t.Errorf("expected codec, encodings and page count, got %v", id)
+		}
+		if id["compressedSize"].(int64) <= 0 || id["uncompressedSize"].(int64) <= 0 {
+			t.Errorf("expected chunk sizes, got %v", id)
+		}
+		stats := id["statistics"].(map[string]interface{})
+		if stats["min"] != int64(1) || stats["max"] != int64(100) || stats["nullCount"] != int64(0) {
+			t.Errorf("expected id statistics 1 to 100 without nulls, got %v", stats)
+		}
+		if name := columns[1]["statistics"].(map[string]interface{}); name["min"] != "Test" {
+			t.Errorf("expected converted string statistics, got %v", name)
+		}
+	})
+
+	t.Run("Dictionary pages, bloom filters and timestamps", func(t *testing.T) {
+		type EventRow struct {
+			Kind string    `parquet:"kind,dict"`
+			At   time.Time `parquet:"at,timestamp(millisecond)"`
+		}
+		eventsFile := filepath.Join(tmpDir, "events.parquet")
+		f, err := os.Create(eventsFile)
+		if err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+		start := time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)
+		w := parquet.NewGenericWriter[EventRow](f, parquet.BloomFilters(parquet.SplitBlockFilter(10, "kind")))
+		if _, err := w.Write([]EventRow{{Kind: "a", At: start}, {Kind: "b", At: start.Add(time.Hour)}}); err != nil {
+			t.Fatalf("failed to write test data: %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("failed to close writer: %v", err)
+		}
+		f.Close()
+
+		metadata, err := p.GetMetadata(eventsFile, map[string]interface{}{"timestamps": "string"})
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		columns := metadata["rowGroups"].([]map[string]interface{})[0]["columns"].([]map[string]interface{})
+		kind, at := columns[0], columns[1]
+		if kind["hasDictionaryPage"] != true || kind["hasBloomFilter"] != true {
+			t.Errorf("expected a dictionary page and a bloom filter, got %v", kind)
+		}
+		if at["hasDictionaryPage"] != false || at["hasBloomFilter"] != false {
+			t.Errorf("expected no dictionary page nor bloom filter, got %v", at)
+		}
+		if stats := at["statistics"].(map[string]interface{}); stats["max"] != "2026-01-02T01:00:00Z" {
+			t.Errorf("expected timestamp statistics as strings, got %v", stats)
+		}
+
+		if _, err := p.GetMetadata(eventsFile, map[string]interface{}{"timestamps": "unix"}); err == nil {
+			t.Error("expected an error for an invalid timestamps option")
+		}
+	})
+
+	t.Run("GetMetadata unsigned statistics", func(t *testing.T) {
+		schema := parquet.NewSchema("test", parquet.Group{"n": parquet.Uint(32)})
+		leaf, _ := schema.Lookup("n")
+		low, high := binary.LittleEndian.AppendUint32(nil, 1), binary.LittleEndian.AppendUint32(nil, 1<<31+1)
+
+		// The legacy fields are in signed order, where 2^31+1 sorts first.
+		legacy := &format.Statistics{Min: high, Max: low, NullCount: 0}
+		if stats := columnStatistics(leaf, legacy, &ConvertOptions{}); stats["min"] != nil || stats["max"] != nil {
+			t.Errorf("expected legacy statistics of unsigned columns to be ignored, got %v", stats)
+		}
+		current := &format.Statistics{Min: high, Max: low, MinValue: low, MaxValue: high}
+		if stats := columnStatistics(leaf, current, &ConvertOptions{}); stats["min"] != uint32(1) || stats["max"] != uint32(1<<31+1) {
+			t.Errorf("expected unsigned statistics 1 to 2^31+1, got %v", stats)
+		}
+
+		filename := writeTestFile(t, map[string]interface{}{"n": "uint64"}, []map[string]interface{}{
+			{"n": uint64(1)}, {"n": new(big.Int).SetUint64(1<<63 + 5)},
+		})
+		metadata, err := p.GetMetadata(filename)
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		columns := metadata["rowGroups"].([]map[string]interface{})[0]["columns"].([]map[string]interface{})
+		if stats := columns[0]["statistics"].(map[string]interface{}); stats["min"] != uint64(1) || stats["max"] != uint64(1<<63+5) {
+			t.Errorf("expected unsigned statistics 1 to 2^63+5, got %v", stats)
+		}
+	})
+
 	t.Run("GetMetadata non-existent file", func(t *testing.T) {
 		_, err := p.GetMetadata("/non/existent/file.parquet")
 		if err == nil {
@@ -0,0 +1,742 @@
+package parquet
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/grafana/sobek"
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/compress"
+)
+
+// defaultInferenceRows is the number of rows buffered to infer the schema
+// of a writer created without one.
+const defaultInferenceRows = 100
+
+// WriteOptions defines options for writing Parquet files.
+type WriteOptions struct {
+	Compression   string // Compression codec of the column chunks
+	RowGroupSize  int64  // Maximum number of rows per row group (0 for the library default)
+	InferenceRows int    // Number of rows used to infer the schema when none is given
+
+	Metadata map[string]string // Key-value metadata of the file footer
+}
+
+// Writer writes rows to a Parquet file. Without an explicit schema, the
+// first rows are buffered until the schema can be inferred from them.
+type Writer struct {
+	file    *os.File
+	opts    WriteOptions
+	schema  *parquet.Schema
+	encoder *recordEncoder
+	writer  *parquet.Writer
+
+	pending []map[string]interface{} // rows buffered for schema inference
+	order   *keyOrder                // key order of the first row written from JS
+	closed  bool
+}
+
+// parseWriteOptions parses the options object passed from JS.
+func parseWriteOptions(options ...map[string]interface{}) (WriteOptions, error) {
+	opts := WriteOptions{
+		Compression:   "snappy",
+		InferenceRows: defaultInferenceRows,
+	}
+
+	if len(options) == 0 || options[0] == nil {
+		return opts, nil
+	}
+	o := options[0]
+
+	if compression, ok := o["compression"].(string); ok {
+		if _, err := compressionCodec(compression); err != nil {
+			return opts, err
+		}
+		opts.Compression = compression
+	}
+	if rowGroupSize, ok := intOption(o, "rowGroupSize"); ok {
+		if rowGroupSize < 0 {
+			return opts, fmt.Errorf("invalid rowGroupSize %d", rowGroupSize)
+		}
+		opts.RowGroupSize = int64(rowGroupSize)
+	}
+	if inferenceRows, ok := intOption(o, "inferenceRows"); ok && inferenceRows > 0 {
+		opts.InferenceRows = inferenceRows
+	}
+	if metadata, ok := o["metadata"]; ok && metadata != nil {
+		entries, ok := metadata.(map[string]interface{})
+		if !ok {
+			return opts, fmt.Errorf("invalid metadata: expected an object, got %T", metadata)
+		}
+		opts.Metadata = make(map[string]string, len(entries))
+		for key, value := range entries {
+			s, ok := value.(string)
+			if !ok {
+				return opts, fmt.Errorf("invalid metadata %q: expected a string, got %T", key, value)
+			}
+			opts.Metadata[key] = s
+		}
+	}
+
+	return opts, nil
+}
+
+// compressionCodec returns the codec of a compression option.
+func compressionCodec(name string) (compress.Codec, error) {
+	switch strings.ToLower(name) {
+	case "", "none", "uncompressed":
+		return &parquet.Uncompressed, nil
+	case "snappy":
+		return &parquet.Snappy, nil
+	case "gzip":
+		return &parquet.Gzip, nil
+	case "zstd":
+		return &parquet.Zstd, nil
+	case "lz4", "lz4_raw":
+		return &parquet.Lz4Raw, nil
+	case "brotli":
+		return &parquet.Brotli, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression codec %q", name)
+	}
+}
+
+// NewWriter creates a Parquet file writer. The schema maps column names to
+// type descriptors; when it is nil, the schema is inferred from the rows.
+// Columns, which Go maps do not order, are in lexical order.
+func NewWriter(filename string, schema map[string]interface{}, options ...map[string]interface{}) (*Writer, error) {
+	return newWriter(filename, schema, nil, options...)
+}
+
+// newWriter creates a Parquet file writer whose schema lists its columns in
+// the given key order of the schema object.
+func newWriter(
+	filename string, schema map[string]interface{}, order *keyOrder, options ...map[string]interface{},
+) (*Writer, error) {
+	opts, err := parseWriteOptions(options...)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &Writer{opts: opts}
+	if schema != nil {
+		w.schema, err = parseSchema(schema, order)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// #nosec G304 -- Users need to write files specified in k6 scripts
+	w.file, err = os.Create(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if w.schema != nil {
+		w.start()
+	}
+	return w, nil
+}
+
+// start creates the underlying writer once the schema is known.
+func (w *Writer) start() {
+	codec, _ := compressionCodec(w.opts.Compression)
+	writerOptions := []parquet.WriterOption{w.schema, parquet.Compression(codec)}
+	if w.opts.RowGroupSize > 0 {
+		writerOptions = append(writerOptions, parquet.MaxRowsPerRowGroup(w.opts.RowGroupSize))
+	}
+	keys := make([]string, 0, len(w.opts.Metadata))
+	for key := range w.opts.Metadata {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writerOptions = append(writerOptions, parquet.KeyValueMetadata(key, w.opts.Metadata[key]))
+	}
+
+	w.writer = parquet.NewWriter(w.file, writerOptions...)
+	w.encoder = newRecordEncoder(w.schema)
+}
+
+// Write writes a single row.
+func (w *Writer) Write(row map[string]interface{}) error {
+	return w.WriteBatch([]map[string]interface{}{row})
+}
+
+// WriteBatch writes several rows at once. Rows which are not objects are
+// rejected before any row is buffered or written.
+func (w *Writer) WriteBatch(rows []map[string]interface{}) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	for _, row := range rows {
+		if row == nil {
+			return errors.New("failed to write row: expected an object")
+		}
+	}
+
+	if w.writer == nil {
+		w.pending = append(w.pending, rows...)
+		if len(w.pending) < w.opts.InferenceRows {
+			return nil
+		}
+		return w.flushPending()
+	}
+
+	return w.writeRows(rows)
+}
+
+// writeRows encodes and writes rows with the schema of the writer.
+func (w *Writer) writeRows(rows []map[string]interface{}) error {
+	encoded := make([]parquet.Row, len(rows))
+	for i, row := range rows {
+		r, err := w.encoder.encode(row)
+		if err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+		encoded[i] = r
+	}
+
+	if _, err := w.writer.WriteRows(encoded); err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
+
+// flushPending infers the schema from the buffered rows and writes them.
+func (w *Writer) flushPending() error {
+	schema, err := inferSchema(w.pending, w.order)
+	if err != nil {
+		return err
+	}
+	w.schema = schema
+	w.start()
+
+	pending := w.pending
+	w.pending = nil
+	return w.writeRows(pending)
+}
+
+// SetMetadata sets a key-value metadata entry of the file footer, replacing
+// the value of an existing key.
+func (w *Writer) SetMetadata(key, value string) error {
+	if w.closed {
+		return errors.New("writer is closed")
+	}
+	if w.opts.Metadata == nil {
+		w.opts.Metadata = make(map[string]string)
+	}
+	w.opts.Metadata[key] = value
+	if w.writer != nil {
+		w.writer.SetKeyValueMetadata(key, value)
+	}
+	return nil
+}
+
+// Close flushes the buffered rows and writes the file footer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	defer w.file.Close()
+
+	if w.writer == nil {
+		if len(w.pending) == 0 {
+			return errors.New("failed to close writer: no schema given and no rows written to infer it")
+		}
+		if err := w.flushPending(); err != nil {
+			return err
+		}
+	}
+
+	if err := w.writer.Close(); err != nil {
+		return fmt.Errorf("failed to close writer: %w", err)
+	}
+	return nil
+}
+
+// parseSchema builds a Parquet schema from a JS schema object mapping column
+// names to type descriptors, with the columns in the key order of the object.
+func parseSchema(schema map[string]interface{}, order *keyOrder) (*parquet.Schema, error) {
+	group, err := parseGroup(schema, order)
+	if err != nil {
+		return nil, err
+	}
+	if len(group) == 0 {
+		return nil, errors.New("invalid schema: no columns")
+	}
+	return parquet.NewSchema("schema", group), nil
+}
+
+// parseGroup builds the fields of a group from their type descriptors.
+func parseGroup(fields map[string]interface{}, order *keyOrder) (orderedGroup, error) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	order.sort(names)
+
+	group := make(orderedGroup, 0, len(names))
+	for _, name := range names {
+		node, err := parseNode(fields[name], order.child(name))
+		if err != nil {
+			return nil, fmt.Errorf("invalid schema for column %q: %w", name, err)
+		}
+		group.set(name, node)
+	}
+	return group, nil
+}
+
+// parseNode builds a node from a type descriptor: either a type name such
+// as "int64", or an object with a "type" and the options of the type.
+func parseNode(descriptor interface{}, order *keyOrder) (parquet.Node, error) {
+	var d map[string]interface{}
+	switch x := descriptor.(type) {
+	case string:
+		d = map[string]interface{}{"type": x}
+	case map[string]interface{}:
+		d = x
+	default:
+		return nil, fmt.Errorf("expected a type name or an object, got %T", descriptor)
+	}
+
+	typeName, _ := d["type"].(string)
+	node, err := parseType(strings.ToLower(typeName), d, order)
+	if err != nil {
+		return nil, err
+	}
+
+	if repeated, _ := d["repeated"].(bool); repeated {
+		return parquet.Repeated(node), nil
+	}
+	if optional, _ := d["optional"].(bool); optional {
+		return parquet.Optional(node), nil
+	}
+	return node, nil
+}
+
+// parseType builds the node of a type name with the options of its
+// descriptor, whose key order is given.
+func parseType(typeName string, d map[string]interface{}, order *keyOrder) (parquet.Node, error) {
+	switch typeName {
+	case "boolean", "bool":
+		return parquet.Leaf(parquet.BooleanType), nil
+	case "int8", "int16", "int32", "int64":
+		return parquet.Int(integerBits[typeName[len("int"):]]), nil
+	case "uint8", "uint16", "uint32", "uint64":
+		return parquet.Uint(integerBits[typeName[len("uint"):]]), nil
+	case "float":
+		return parquet.Leaf(parquet.FloatType), nil
+	case "double", "number":
+		return parquet.Leaf(parquet.DoubleType), nil
+	case "string", "utf8":
+		return parquet.String(), nil
+	case "enum":
+		return parquet.Enum(), nil
+	case "binary", "bytes":
+		if length, ok := intOption(d, "length"); ok {
+			return parquet.Leaf(parquet.FixedLenByteArrayType(length)), nil
+		}
+		return parquet.Leaf(parquet.ByteArrayType), nil
+	case "json":
+		return parquet.JSON(), nil
+	case "uuid":
+		return parquet.UUID(), nil
+	case "date":
+		return parquet.Date(), nil
+	case "timestamp", "time":
+		unit, err := parseTimeUnit(d["unit"])
+		if err != nil {
+			return nil, err
+		}
+		if typeName == "time" {
+			return parquet.Time(unit), nil
+		}
+		return parquet.Timestamp(unit), nil
+	case "decimal":
+		return parseDecimalType(d)
+	case "list":
+		element, ok := d["element"]
+		if !ok {
+			return nil, errors.New(`list types require an "element" type`)
+		}
+		node, err := parseNode(element, order.child("element"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid list element: %w", err)
+		}
+		return parquet.List(node), nil
+	case "map":
+		key, err := parseNode(valueOr(d["key"], "string"), order.child("key"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid map key: %w", err)
+		}
+		value, ok := d["value"]
+		if !ok {
+			return nil, errors.New(`map types require a "value" type`)
+		}
+		node, err := parseNode(value, order.child("value"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid map value: %w", err)
+		}
+		return parquet.Map(key, node), nil
+	case "struct", "group", "object":
+		fields, ok := d["fields"].(map[string]interface{})
+		if !ok || len(fields) == 0 {
+			return nil, errors.New(`struct types require "fields"`)
+		}
+		return parseGroup(fields, order.child("fields"))
+	case "":
+		return nil, errors.New(`missing "type"`)
+	default:
+		return nil, fmt.Errorf("unknown type %q", typeName)
+	}
+}
+
+// integerBits maps the suffix of integer type names to their bit width.
+var integerBits = map[string]int{"8": 8, "16": 16, "32": 32, "64": 64}
+
+// parseTimeUnit parses the unit of TIMESTAMP and TIME types, milliseconds by
+// default.
+func parseTimeUnit(unit interface{}) (parquet.TimeUnit, error) {
+	name, _ := unit.(string)
+	switch strings.ToLower(name) {
+	case "", "ms", "millis", "milliseconds":
+		return parquet.Millisecond, nil
+	case "us", "micros", "microseconds":
+		return parquet.Microsecond, nil
+	case "ns", "nanos", "nanoseconds":
+		return parquet.Nanosecond, nil
+	default:
+		return nil, fmt.Errorf("unknown time unit %q", name)
+	}
+}
+
+// parseDecimalType builds a DECIMAL node stored in the smallest physical
+// type holding its precision.
+func parseDecimalType(d map[string]interface{}) (parquet.Node, error) {
+	precision, ok := intOption(d, "precision")
+	if !ok || precision <= 0 {
+		return nil, errors.New(`decimal types require a positive "precision"`)
+	}
+	scale, _ := intOption(d, "scale")
+	if scale < 0 || scale > precision {
+		return nil, fmt.Errorf("invalid decimal scale %d for precision %d", scale, precision)
+	}
+
+	switch {
+	case precision <= 9:
+		return parquet.Decimal(scale, precision, parquet.Int32Type), nil
+	case precision <= 18:
+		return parquet.Decimal(scale, precision, parquet.Int64Type), nil
+	default:
+		// Number of bytes holding 10^precision in two's complement.
+		size := int(math.Ceil((float64(precision)*math.Log2(10) + 1) / 8))
+		return parquet.Decimal(scale, precision, parquet.FixedLenByteArrayType(size)), nil
+	}
+}
+
+// valueOr returns v, or def when v is nil.
+func valueOr(v, def interface{}) interface{} {
+	if v == nil {
+		return def
+	}
+	return v
+}
+
+// inferSchema infers a schema from rows. Columns missing or null in some
+// rows are optional, and numbers are doubles if any of them is fractional.
+// Columns follow the key order of the first row, if known, and columns
+// missing from it come last in lexical order.
+func inferSchema(rows []map[string]interface{}, order *keyOrder) (*parquet.Schema, error) {
+	values := make([]interface{}, len(rows))
+	for i, row := range rows {
+		if row == nil {
+			return nil, errors.New("failed to infer schema: rows must be objects")
+		}
+		values[i] = row
+	}
+
+	group, err := inferGroup(values, order)
+	if err != nil {
+		return nil, err
+	}
+	if len(group) == 0 {
+		return nil, errors.New("failed to infer schema: rows have no columns")
+	}
+	return parquet.NewSchema("schema", group), nil
+}
+
+// inferGroup infers the fields of a group from sample objects.
+func inferGroup(objects []interface{}, order *keyOrder) (orderedGroup, error) {
+	samples := make(map[string][]interface{})
+	var names []string
+	for _, object := range objects {
+		for name, value := range object.(map[string]interface{}) {
+			if _, ok := samples[name]; !ok {
+				names = append(names, name)
+			}
+			samples[name] = append(samples[name], value)
+		}
+	}
+	order.sort(names)
+
+	group := make(orderedGroup, 0, len(names))
+	for _, name := range names {
+		node, err := inferNode(samples[name], order.child(name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to infer schema of column %q: %w", name, err)
+		}
+		// Columns missing from some objects are optional.
+		if len(samples[name]) < len(objects) && node.Required() {
+			node = parquet.Optional(node)
+		}
+		group.set(name, node)
+	}
+	return group, nil
+}
+
+// inferNode infers the node of a column from its sample values.
+func inferNode(samples []interface{}, order *keyOrder) (parquet.Node, error) {
+	var present []interface{}
+	for _, v := range samples {
+		if v != nil {
+			present = append(present, v)
+		}
+	}
+	if len(present) == 0 {
+		return parquet.Optional(parquet.String()), nil
+	}
+
+	node, err := inferType(present, order)
+	if err != nil {
+		return nil, err
+	}
+	if len(present) < len(samples) {
+		return parquet.Optional(node), nil
+	}
+	return node, nil
+}
+
+// inferType infers the type of non-null sample values.
+func inferType(values []interface{}, order *keyOrder) (parquet.Node, error) {
+	switch first := values[0].(type) {
+	case bool:
+		return parquet.Leaf(parquet.BooleanType), checkSamples(values, func(v interface{}) bool {
+			_, ok := v.(bool)
+			return ok
+		})
+	case string:
+		return parquet.String(), checkSamples(values, func(v interface{}) bool {
+			_, ok := v.(string)
+			return ok
+		})
+	case time.Time:
+		return parquet.Timestamp(parquet.Millisecond), checkSamples(values, func(v interface{}) bool {
+			_, ok := v.(time.Time)
+			return ok
+		})
+	case []byte, sobek.ArrayBuffer, *sobek.ArrayBuffer:
+		return parquet.Leaf(parquet.ByteArrayType), checkSamples(values, func(v interface{}) bool {
+			_, err := encodeBytes(v)
+			return err == nil
+		})
+	case *big.Int:
+		return parquet.Int(64), checkSamples(values, func(v interface{}) bool {
+			_, err := encodeInteger(v, math.MinInt64, math.MaxInt64)
+			return err == nil
+		})
+	case []interface{}:
+		var elements []interface{}
+		for _, v := range values {
+			list, ok := v.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("mixed types %T and %T", first, v)
+			}
+			elements = append(elements, list...)
+		}
+		if len(elements) == 0 {
+			return parquet.List(parquet.Optional(parquet.String())), nil
+		}
+		element, err := inferNode(elements, order)
+		if err != nil {
+			return nil, err
+		}
+		return parquet.List(element), nil
+	case map[string]interface{}:
+		for _, v := range values {
+			if _, ok := v.(map[string]interface{}); !ok {
+				return nil, fmt.Errorf("mixed types %T and %T", first, v)
+			}
+		}
+		group, err := inferGroup(values, order)
+		if err != nil {
+			return nil, err
+		}
+		if len(group) == 0 {
+			return nil, errors.New("objects have no fields")
+		}
+		return group, nil
+	}
+
+	if _, ok := toFloat(values[0]); ok {
+		integral := true
+		for _, v := range values {
+			f, ok := toFloat(v)
+			if !ok {
+				return nil, fmt.Errorf("mixed types %T and %T", values[0], v)
+			}
+			if _, ok := toInt64(v); !ok || math.IsInf(f, 0) {
+				integral = false
+			}
+		}
+		if integral {
+			return parquet.Int(64), nil
+		}
+		return parquet.Leaf(parquet.DoubleType), nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %T", values[0])
+}
+
+// checkSamples returns an error if any sample does not have the type of the
+// first one.
+func checkSamples(values []interface{}, sameType func(interface{}) bool) error {
+	for _, v := range values {
+		if !sameType(v) {
+			return fmt.Errorf("mixed types %T and %T", values[0], v)
+		}
+	}
+	return nil
+}
+
+// Writer creates a Parquet file writer, as NewWriter does, at a path that
+// resolves like the paths of read(). The writer must be closed to write the
+// file footer.
+func (p *Parquet) Writer(
+	filename string, schema map[string]interface{}, options ...map[string]interface{},
+) (*Writer, error) {
+	return NewWriter(p.files.resolve(filename), schema, options...)
+}
+
+// writerJS is the JS binding of Writer. Schemas and rows are exported to Go
+// maps, which lose the key order of the JS objects, so the binding records it
+// from the schema, or from the first row when the schema is inferred, and the
+// columns of the file follow it.
+func (p *Parquet) writerJS(filename string, schema sobek.Value, options ...map[string]interface{}) (*sobek.Object, error) {
+	rt := p.vu.Runtime()
+
+	var fields map[string]interface{}
+	if schema != nil && !sobek.IsUndefined(schema) && !sobek.IsNull(schema) {
+		var ok bool
+		if fields, ok = schema.Export().(map[string]interface{}); !ok {
+			return nil, errors.New("invalid schema: expected an object")
+		}
+	}
+	w, err := newWriter(p.files.resolve(filename), fields, keyOrderOf(rt, schema), options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// record keeps the key order of the first rows of a writer inferring its
+	// schema.
+	record := func(rows sobek.Value) {
+		if w.schema == nil && w.order == nil {
+			w.order = keyOrderOf(rt, rows)
+		}
+	}
+
+	obj := rt.NewObject()
+	for name, method := range map[string]interface{}{
+		"write": func(row sobek.Value) error {
+			record(row)
+			fields, _ := row.Export().(map[string]interface{})
+			return w.Write(fields)
+		},
+		"writeBatch": func(rows sobek.Value) error {
+			values, ok := rows.Export().([]interface{})
+			if !ok {
+				return errors.New("failed to write rows: expected an array of objects")
+			}
+			record(rows)
+			batch := make([]map[string]interface{}, len(values))
+			for i, value := range values {
+				batch[i], _ = value.(map[string]interface{})
+			}
+			return w.WriteBatch(batch)
+		},
+		"setMetadata": w.SetMetadata,
+		"close":       w.Close,
+	} {
+		if err := obj.Set(name, method); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
+
+// keyOrder is the key order of a JS object and of its nested objects.
+type keyOrder struct {
+	keys     []string
+	children map[string]*keyOrder
+}
+
+// keyOrderOf returns the key order of a JS object, or of the first element
+// of an array, or nil for other values.
+func keyOrderOf(rt *sobek.Runtime, v sobek.Value) *keyOrder {
+	obj, ok := v.(*sobek.Object)
+	if !ok {
+		return nil
+	}
+
+	switch obj.ClassName() {
+	case "Array":
+		length := obj.Get("length").ToInteger()
+		for i := int64(0); i < length; i++ {
+			element := obj.Get(strconv.FormatInt(i, 10))
+			if element != nil && !sobek.IsUndefined(element) && !sobek.IsNull(element) {
+				return keyOrderOf(rt, element)
+			}
+		}
+		return nil
+	case "Object":
+		o := &keyOrder{keys: obj.Keys(), children: make(map[string]*keyOrder)}
+		for _, key := range o.keys {
+			if child := keyOrderOf(rt, obj.Get(key)); child != nil {
+				o.children[key] = child
+			}
+		}
+		return o
+	default:
+		return nil
+	}
+}
+
+// child returns the key order of the object held by a key.
+func (o *keyOrder) child(key string) *keyOrder {
+	if o == nil {
+		return nil
+	}
+	return o.children[key]
+}
+
+// sort sorts names in key order, followed by the names that are not keys of
+// the object in lexical order.
+func (o *keyOrder) sort(names []string) {
+	sort.Strings(names)
+	if o == nil {
+		return
+	}
+	ranks := make(map[string]int, len(o.keys))
+	for i, key := range o.keys {
+		ranks[key] = i
+	}
+	sortByRank(names, ranks)
+}
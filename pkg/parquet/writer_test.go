@@ -0,0 +1,437 @@
+package parquet
+
+import (
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"go.k6.io/k6/js/modulestest"
+)
+
+// writeTestFile writes rows with a writer and returns the file name.
+func writeTestFile(
+	t *testing.T, schema map[string]interface{}, rows []map[string]interface{}, options ...map[string]interface{},
+) string {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "written.parquet")
+	w, err := NewWriter(filename, schema, options...)
+	if err != nil {
+		t.Fatalf("NewWriter() error = %v", err)
+	}
+	if err := w.WriteBatch(rows); err != nil {
+		t.Fatalf("WriteBatch() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	return filename
+}
+
+func TestWriter(t *testing.T) {
+	p := &Parquet{cache: NewReaderCache()}
+
+	t.Run("Explicit schema round trip", func(t *testing.T) {
+		schema := map[string]interface{}{
+			"id":    "int64",
+			"name":  map[string]interface{}{"type": "string"},
+			"score": map[string]interface{}{"type": "double", "optional": true},
+			"ok":    "boolean",
+			"tags":  map[string]interface{}{"type": "list", "element": "string"},
+			"attrs": map[string]interface{}{"type": "map", "value": "int32"},
+			"codes": map[string]interface{}{"type": "int32", "repeated": true},
+			"address": map[string]interface{}{
+				"type":     "struct",
+				"optional": true,
+				"fields": map[string]interface{}{
+					"city": "string",
+					"zip":  map[string]interface{}{"type": "string", "optional": true},
+				},
+			},
+		}
+		rows := []map[string]interface{}{
+			{
+				"id": int64(1), "name": "a", "score": 1.5, "ok": true,
+				"tags":    []interface{}{"x", "y"},
+				"attrs":   map[string]interface{}{"b": int64(2), "a": int64(1)},
+				"codes":   []interface{}{int64(3), int64(4)},
+				"address": map[string]interface{}{"city": "Paris", "zip": "75001"},
+			},
+			{
+				"id": int64(2), "name": "b", "ok": false,
+				"tags":    []interface{}{},
+				"address": map[string]interface{}{"city": "Berlin"},
+			},
+		}
+		filename := writeTestFile(t, schema, rows)
+
+		results, err := p.Read(filename)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		expected := []map[string]interface{}{
+			{
+				"id": int64(1), "name": "a", "score": 1.5, "ok": true,
+				"tags":    []interface{}{"x", "y"},
+				"attrs":   map[string]interface{}{"a": int32(1), "b": int32(2)},
+				"codes":   []interface{}{int32(3), int32(4)},
+				"address": map[string]interface{}{"city": "Paris", "zip": "75001"},
+			},
+			{
+				"id": int64(2), "name": "b", "score": nil, "ok": false,
+				"tags":    []interface{}{},
+				"attrs":   map[string]interface{}{},
+				"codes":   []interface{}{},
+				"address": map[string]interface{}{"city": "Berlin", "zip": nil},
+			},
+		}
+		if !reflect.DeepEqual(results, expected) {
+			t.Errorf("expected %v, got %v", expected, results)
+		}
+	})
+
+	t.Run("Logical types round trip", func(t *testing.T) {
+		ts := time.Date(2024, 3, 1, 12, 30, 0, 123000000, time.UTC)
+		schema := map[string]interface{}{
+			"ts":     map[string]interface{}{"type": "timestamp", "unit": "micros"},
+			"day":    "date",
+			"at":     map[string]interface{}{"type": "time", "unit": "millis"},
+			"price":  map[string]interface{}{"type": "decimal", "precision": 9, "scale": 2},
+			"amount": map[string]interface{}{"type": "decimal", "precision": 30, "scale": 3},
+			"id":     "uuid",
+			"doc":    "json",
+			"big":    "uint64",
+			"small":  "int8",
+		}
+		rows := []map[string]interface{}{{
+			"ts":     ts,
+			"day":    "2024-03-01",
+			"at":     "08:15:30.250",
+			"price":  "-12.5",
+			"amount": "123456789012345678901234.567",
+			"id":     "0f8fad5b-d9cb-469f-a165-70867728950e",
+			"doc":    map[string]interface{}{"k": "v"},
+			"big":    new(big.Int).SetUint64(1<<63 + 5),
+			"small":  int64(-8),
+		}}
+		filename := writeTestFile(t, schema, rows)
+
+		results, err := p.Read(filename)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		row := results[0]
+		if got, ok := row["ts"].(time.Time); !ok || !got.Equal(ts) {
+			t.Errorf("expected ts %v, got %v", ts, row["ts"])
+		}
+		if got, ok := row["day"].(time.Time); !ok || got.Format(time.DateOnly) != "2024-03-01" {
+			t.Errorf("expected day 2024-03-01, got %v", row["day"])
+		}
+		for column, expected := range map[string]interface{}{
+			"at":     "08:15:30.25",
+			"price":  "-12.50",
+			"amount": "123456789012345678901234.567",
+			"id":     "0f8fad5b-d9cb-469f-a165-70867728950e",
+			"doc":    `{"k":"v"}`,
+			"big":    uint64(1<<63 + 5),
+			"small":  int32(-8),
+		} {
+			if !reflect.DeepEqual(row[column], expected) {
+				t.Errorf("%s: expected %v (%T), got %v (%T)", column, expected, expected, row[column], row[column])
+			}
+		}
+	})
+
+	t.Run("Schema inferred from the first rows", func(t *testing.T) {
+		rows := []map[string]interface{}{
+			{"id": int64(1), "value": int64(1), "name": "a", "tags": []interface{}{"x"}},
+			{"id": int64(2), "value": 2.5, "geo": map[string]interface{}{"lat": 1.5}},
+			{"id": int64(3), "value": int64(3), "at": time.UnixMilli(0).UTC()},
+		}
+		filename := filepath.Join(t.TempDir(), "inferred.parquet")
+		w, err := NewWriter(filename, nil, map[string]interface{}{"inferenceRows": 2})
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		for _, row := range rows {
+			if err := w.Write(row); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+
+		_, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		// The third row is written with the schema inferred from the first two:
+		// its "at" column is dropped.
+		expected := parquet.NewSchema("schema", parquet.Group{
+			"id":    parquet.Int(64),
+			"value": parquet.Leaf(parquet.DoubleType),
+			"name":  parquet.Optional(parquet.String()),
+			"tags":  parquet.Optional(parquet.List(parquet.String())),
+			"geo":   parquet.Optional(parquet.Group{"lat": parquet.Leaf(parquet.DoubleType)}),
+		})
+		if pf.Schema().String() != expected.String() {
+			t.Errorf("expected schema %s, got %s", expected, pf.Schema())
+		}
+		if pf.NumRows() != 3 {
+			t.Errorf("expected 3 rows, got %d", pf.NumRows())
+		}
+	})
+
+	t.Run("Rows buffered for inference are written on close", func(t *testing.T) {
+		rows := []map[string]interface{}{{"id": int64(1)}, {"id": int64(2)}}
+		filename := writeTestFile(t, nil, rows)
+
+		results, err := p.Read(filename)
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+		if !reflect.DeepEqual(results, []map[string]interface{}{{"id": int64(1)}, {"id": int64(2)}}) {
+			t.Errorf("unexpected rows: %v", results)
+		}
+	})
+
+	t.Run("Compression and row group size", func(t *testing.T) {
+		rows := make([]map[string]interface{}, 250)
+		for i := range rows {
+			rows[i] = map[string]interface{}{"id": int64(i)}
+		}
+		filename := writeTestFile(t, map[string]interface{}{"id": "int64"}, rows, map[string]interface{}{
+			"compression":  "zstd",
+			"rowGroupSize": 100,
+		})
+
+		_, pf, err := openParquetFile(osFS, filename)
+		if err != nil {
+			t.Fatalf("openParquetFile() error = %v", err)
+		}
+		if len(pf.RowGroups()) != 3 {
+			t.Errorf("expected 3 row groups, got %d", len(pf.RowGroups()))
+		}
+		codec := pf.Metadata().RowGroups[0].Columns[0].MetaData.Codec
+		if codec.String() != "ZSTD" {
+			t.Errorf("expected ZSTD compression, got %s", codec)
+		}
+	})
+
+	t.Run("Key-value metadata", func(t *testing.T) {
+		filename := filepath.Join(t.TempDir(), "tagged.parquet")
+		w, err := NewWriter(filename, nil, map[string]interface{}{
+			"metadata": map[string]interface{}{"dataset.version": "1", "owner": "perf"},
+		})
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		// Set before the schema is inferred, and after.
+		if err := w.SetMetadata("dataset.version", "2"); err != nil {
+			t.Fatalf("SetMetadata() error = %v", err)
+		}
+		rows := make([]map[string]interface{}, defaultInferenceRows)
+		for i := range rows {
+			rows[i] = map[string]interface{}{"id": int64(i)}
+		}
+		if err := w.WriteBatch(rows); err != nil {
+			t.Fatalf("WriteBatch() error = %v", err)
+		}
+		if err := w.SetMetadata("rows", "100"); err != nil {
+			t.Fatalf("SetMetadata() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := w.SetMetadata("late", "x"); err == nil {
+			t.Error("expected an error setting metadata on a closed writer")
+		}
+
+		metadata, err := p.GetMetadata(filename)
+		if err != nil {
+			t.Fatalf("GetMetadata() error = %v", err)
+		}
+		expected := map[string]interface{}{"dataset.version": "2", "owner": "perf", "rows": "100"}
+		if kv := metadata["keyValueMetadata"]; !reflect.DeepEqual(kv, expected) {
+			t.Errorf("expected key-value metadata %v, got %v", expected, kv)
+		}
+		if createdBy, _ := metadata["createdBy"].(string); !strings.Contains(createdBy, "parquet-go") {
+			t.Errorf("expected the writer in createdBy, got %q", createdBy)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		dir := t.TempDir()
+
+		for name, schema := range map[string]map[string]interface{}{
+			"unknown type":     {"a": "int128"},
+			"missing element":  {"a": map[string]interface{}{"type": "list"}},
+			"invalid decimal":  {"a": map[string]interface{}{"type": "decimal"}},
+			"invalid unit":     {"a": map[string]interface{}{"type": "timestamp", "unit": "days"}},
+			"empty schema":     {},
+			"invalid type arg": {"a": 1},
+		} {
+			if _, err := NewWriter(filepath.Join(dir, "invalid.parquet"), schema); err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+		}
+
+		if _, err := NewWriter(filepath.Join(dir, "invalid.parquet"), nil, map[string]interface{}{
+			"compression": "rar",
+		}); err == nil {
+			t.Error("expected an error for an unknown codec")
+		}
+		for _, metadata := range []interface{}{"version=1", map[string]interface{}{"version": 1}} {
+			if _, err := NewWriter(filepath.Join(dir, "invalid.parquet"), nil, map[string]interface{}{
+				"metadata": metadata,
+			}); err == nil {
+				t.Errorf("expected an error for metadata %v", metadata)
+			}
+		}
+
+		w, err := NewWriter(filepath.Join(dir, "rows.parquet"), map[string]interface{}{
+			"id":  "int32",
+			"tag": map[string]interface{}{"type": "string", "optional": true},
+		})
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		for _, row := range []map[string]interface{}{
+			{"tag": "missing id"},
+			{"id": "x"},
+			{"id": int64(1) << 40},
+			{"id": int64(1), "tag": true},
+		} {
+			if err := w.Write(row); err == nil {
+				t.Errorf("expected an error writing %v", row)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		if err := w.Write(map[string]interface{}{"id": int64(1)}); err == nil || !strings.Contains(err.Error(), "closed") {
+			t.Errorf("expected a closed writer error, got %v", err)
+		}
+
+		// Rows buffered to infer the schema are checked when written.
+		w, err = NewWriter(filepath.Join(dir, "inferred.parquet"), nil)
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		if err := w.Write(nil); err == nil || !strings.Contains(err.Error(), "expected an object") {
+			t.Errorf("expected an error writing a non-object row, got %v", err)
+		}
+		if err := w.WriteBatch([]map[string]interface{}{{"id": int64(1)}, nil}); err == nil {
+			t.Error("expected an error writing a batch with a non-object row")
+		}
+		if err := w.Write(map[string]interface{}{"id": int64(2)}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+		if err := w.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+		rows, err := p.Read(filepath.Join(dir, "inferred.parquet"))
+		if err != nil || len(rows) != 1 || rows[0]["id"] != int64(2) {
+			t.Errorf("expected only the valid row to be written, got %v, %v", rows, err)
+		}
+
+		w, err = NewWriter(filepath.Join(dir, "empty.parquet"), nil)
+		if err != nil {
+			t.Fatalf("NewWriter() error = %v", err)
+		}
+		if err := w.Close(); err == nil {
+			t.Error("expected an error closing a writer without schema nor rows")
+		}
+	})
+}
+
+func TestWriterJS(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	instance := New().NewModuleInstance(runtime.VU)
+	if err := runtime.VU.Runtime().Set("parquet", instance.Exports().Named); err != nil {
+		t.Fatalf("failed to set exports: %v", err)
+	}
+	filename := filepath.Join(t.TempDir(), "summary.parquet")
+	if err := runtime.VU.Runtime().Set("filename", filename); err != nil {
+		t.Fatalf("failed to set filename: %v", err)
+	}
+
+	v, err := runtime.VU.Runtime().RunString(`
+		const w = parquet.writer(filename, {
+			metric: 'string',
+			value: 'double',
+			at: { type: 'timestamp', unit: 'millis' },
+		}, { compression: 'gzip', metadata: { 'dataset.version': '2026.10' } });
+		w.setMetadata('k6.vus', '10');
+		w.write({ metric: 'http_req_duration', value: 12.5, at: new Date(0) });
+		w.writeBatch([
+			{ metric: 'iterations', value: 3, at: new Date(1000) },
+			{ metric: 'vus', value: 10, at: new Date(2000) },
+		]);
+		w.close();
+		const rows = parquet.read(filename);
+		const { keyValueMetadata } = parquet.getMetadata(filename);
+		[
+			rows.length, rows[1].metric, rows[2].value, rows[2].at.getTime(),
+			keyValueMetadata['dataset.version'], keyValueMetadata['k6.vus'],
+		].join(',');
+	`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	if v.String() != "3,iterations,10,2000,2026.10,10" {
+		t.Errorf("unexpected result: %s", v.String())
+	}
+}
+
+func TestWriterJSColumnOrder(t *testing.T) {
+	runtime := modulestest.NewRuntime(t)
+	instance := New().NewModuleInstance(runtime.VU)
+	if err := runtime.VU.Runtime().Set("parquet", instance.Exports().Named); err != nil {
+		t.Fatalf("failed to set exports: %v", err)
+	}
+	dir := t.TempDir()
+	if err := runtime.VU.Runtime().Set("dir", dir); err != nil {
+		t.Fatalf("failed to set dir: %v", err)
+	}
+
+	v, err := runtime.VU.Runtime().RunString(`
+		const inferred = dir + '/inferred.parquet';
+		const w = parquet.writer(inferred);
+		w.writeBatch([
+			{ zulu: 'a', alpha: 1, point: { yankee: 2, bravo: 3 }, tags: [{ mike: true, kilo: 1 }] },
+			{ zulu: 'b', alpha: 2, point: { yankee: 4, bravo: 5 }, tags: [], echo: 'extra' },
+		]);
+		w.close();
+
+		const explicit = dir + '/explicit.parquet';
+		const e = parquet.writer(explicit, {
+			zulu: 'string',
+			alpha: 'int64',
+			point: { type: 'struct', fields: { yankee: 'int32', bravo: 'int32' } },
+		});
+		e.write({ alpha: 1, point: { bravo: 3, yankee: 2 }, zulu: 'a' });
+		e.close();
+
+		const names = (schema) => schema.fields.map(f => f.name).join(' ');
+		const row = parquet.read(inferred)[0];
+		[
+			names(parquet.getSchema(inferred)),
+			Object.keys(row.point).join(' '),
+			Object.keys(row.tags[0]).join(' '),
+			JSON.stringify(parquet.read(explicit)[0]),
+		].join('|');
+	`)
+	if err != nil {
+		t.Fatalf("script error: %v", err)
+	}
+	expected := `zulu alpha point tags echo|yankee bravo|mike kilo|{"zulu":"a","alpha":1,"point":{"yankee":2,"bravo":3}}`
+	if v.String() != expected {
+		t.Errorf("unexpected result: %s", v.String())
+	}
+}